@@ -0,0 +1,86 @@
+package core
+
+import (
+	"fmt"
+
+	"github.com/openqe/openqe/pkg/common"
+	"github.com/openqe/openqe/pkg/tls"
+	"github.com/spf13/cobra"
+)
+
+// ============    APPLY COMMAND     ==============================
+
+type ApplyOptions struct {
+	SpecFile string
+}
+
+func NewApplyCommand(globalOpts *common.GlobalOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "apply",
+		Short: "Generate a CA hierarchy and leaf certificates declared in a YAML spec",
+		Long: `Generate a CA hierarchy and leaf certificates declared in a YAML spec.
+The spec is rendered as a template first, so it can use the env variables like {{ env.HOME }}.
+Applying a spec is idempotent: the key/cert pairs which exist and are still valid are kept,
+the missing, changed or expiring ones (see min_remaining) are generated, and so are the ones issued by a regenerated CA.
+The relative file paths are relative to output_dir, the files of a CA or a certificate default to <name>.key and <name>.crt.
+A private key is read and written as encrypted PKCS#8 with the passphrase in key_passphrase_file when it is set.
+
+Example spec:
+  output_dir: {{ env.HOME }}/pki
+  min_remaining: 720h
+  cas:
+    - name: root
+      subject: CN=Root CA, OU=QE
+      validity: 87600h
+      path_len: 1
+    - name: intermediate
+      parent: root
+      subject: CN=Intermediate CA, OU=QE
+      key_algorithm: ecdsa
+  certs:
+    - name: server
+      ca: intermediate
+      subject: CN=server, OU=QE
+      dns_names: [server.openqe.github.io]
+      ip_addresses: [127.0.0.1]
+      profile: server
+      chain_file: server-chain.crt
+    - name: client
+      ca: intermediate
+      subject: CN=client, OU=QE
+      profile: client
+      validity: 24h
+`,
+		SilenceErrors: true,
+		SilenceUsage:  true,
+	}
+
+	opts := &ApplyOptions{}
+	cmd.Flags().StringVarP(&opts.SpecFile, "file", "f", opts.SpecFile, "The YAML spec file of the CAs and the certificates")
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		logger := common.NewLoggerFromOptions(globalOpts, "TLS")
+
+		if opts.SpecFile == "" {
+			cmd.Usage()
+			return fmt.Errorf("Error: --file is required")
+		}
+		spec, err := tls.LoadPKISpec(opts.SpecFile)
+		if err != nil {
+			return err
+		}
+		results, err := tls.ApplyPKISpec(spec)
+		for _, result := range results {
+			if result.Reason != "" {
+				logger.Info("%s %s %s: %s", result.Kind, result.Name, result.Action, result.Reason)
+			} else {
+				logger.Info("%s %s %s", result.Kind, result.Name, result.Action)
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("Failed to apply the PKI spec: %w", err)
+		}
+		return nil
+	}
+	return cmd
+}
@@ -0,0 +1,285 @@
+package core
+
+import (
+	"crypto/x509"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/openqe/openqe/pkg/common"
+	"github.com/openqe/openqe/pkg/openshift"
+	"github.com/openqe/openqe/pkg/tls"
+	"github.com/spf13/cobra"
+)
+
+// ============    BUNDLE COMMAND     ==============================
+
+type BundleOptions struct {
+	OcpOpts    *openshift.OcpOptions
+	File       string
+	ConfigMap  string
+	BackupFile string
+}
+
+// NewBundleCommand creates the command to list and edit the certificates of a CA bundle file or ConfigMap
+func NewBundleCommand(globalOpts *common.GlobalOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "bundle",
+		Short: "List and edit the certificates of a CA bundle file or the ca-bundle.crt key of a ConfigMap",
+		Long: `List and edit the certificates of a CA bundle file or the ca-bundle.crt key of a ConfigMap.
+The certificates are matched by their SHA-256 fingerprints, the order of the other certificates and the
+comments in front of them are kept. Before the bundle is changed, the original is written to --backup-file,
+which defaults to <file>.bak, or <namespace>-<name>-ca-bundle.crt.bak in the current directory for a ConfigMap.
+
+Examples:
+  # List the certificates of the trusted CA bundle of the cluster proxy
+  openqe tls bundle list --configmap openshift-config/user-ca-bundle
+
+  # Add a CA to a bundle file, nothing changes if it is already there
+  openqe tls bundle add ca.crt --file ca-bundle.crt
+
+  # Remove a certificate by its fingerprint, then the duplicated and the expired ones
+  openqe tls bundle remove --fingerprint ED:18:A5:62:... --file ca-bundle.crt
+  openqe tls bundle dedupe --file ca-bundle.crt
+  openqe tls bundle prune-expired --configmap openshift-config/user-ca-bundle
+`,
+		SilenceUsage: true,
+	}
+	cmd.Run = func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	}
+
+	opts := &BundleOptions{
+		OcpOpts: openshift.DefaultOcpOptions(),
+	}
+	flags := cmd.PersistentFlags()
+	flags.StringVar(&opts.File, "file", opts.File, "The CA bundle file")
+	flags.StringVar(&opts.ConfigMap, "configmap", opts.ConfigMap, "The ConfigMap with the ca-bundle.crt key in form of <namespace>/<name>")
+	flags.StringVar(&opts.OcpOpts.KUBECONFIG, "kubeconfig", opts.OcpOpts.KUBECONFIG, "The kubeconfig file used to read and update the ConfigMap")
+	flags.StringVar(&opts.BackupFile, "backup-file", opts.BackupFile, "The file to write the original bundle to before changing it")
+
+	cmd.AddCommand(newBundleListCommand(globalOpts, opts))
+	cmd.AddCommand(newBundleAddCommand(globalOpts, opts))
+	cmd.AddCommand(newBundleRemoveCommand(globalOpts, opts))
+	cmd.AddCommand(newBundleDedupeCommand(globalOpts, opts))
+	cmd.AddCommand(newBundlePruneExpiredCommand(globalOpts, opts))
+	return cmd
+}
+
+// validate checks that exactly one of the bundle file and the ConfigMap is specified
+func (o *BundleOptions) validate() error {
+	if (o.File == "") == (o.ConfigMap == "") {
+		return fmt.Errorf("Error: exactly one of --file and --configmap is required")
+	}
+	return nil
+}
+
+// read returns the PEM data of the bundle and a description of where it comes from
+func (o *BundleOptions) read() ([]byte, string, error) {
+	if err := o.validate(); err != nil {
+		return nil, "", err
+	}
+	if o.File != "" {
+		data, err := os.ReadFile(o.File)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to read CA bundle file: %w", err)
+		}
+		return data, o.File, nil
+	}
+	namespace, name, err := openshift.ParseObjectReference(o.ConfigMap)
+	if err != nil {
+		return nil, "", err
+	}
+	data, err := openshift.CertificateDataFromCluster(o.OcpOpts.KUBECONFIG, openshift.KindConfigMap, namespace, name, tls.UserCABundleMapKey)
+	if err != nil {
+		return nil, "", err
+	}
+	return data[tls.UserCABundleMapKey], fmt.Sprintf("%s %s[%s]", openshift.KindConfigMap, o.ConfigMap, tls.UserCABundleMapKey), nil
+}
+
+// edit edits the bundle file or the ConfigMap with edit, which returns the added or removed certificates to log
+func (o *BundleOptions) edit(logger *common.Logger, action string, edit func(*tls.CABundle) ([]*x509.Certificate, error)) error {
+	if err := o.validate(); err != nil {
+		return err
+	}
+	logged := func(bundle *tls.CABundle) error {
+		certs, err := edit(bundle)
+		for _, cert := range certs {
+			logger.Info("%s %s (%s)", action, cert.Subject, tls.Fingerprint(cert))
+		}
+		return err
+	}
+	target, backupFile := o.File, o.BackupFile
+	var changed, backedUp bool
+	if o.File != "" {
+		if backupFile == "" {
+			backupFile = o.File + ".bak"
+		}
+		var err error
+		if changed, backedUp, err = tls.EditCABundleFile(o.File, backupFile, logged); err != nil {
+			return fmt.Errorf("Failed to edit CA bundle file %s: %w", o.File, err)
+		}
+	} else {
+		namespace, name, err := openshift.ParseObjectReference(o.ConfigMap)
+		if err != nil {
+			return err
+		}
+		target = fmt.Sprintf("%s %s", openshift.KindConfigMap, o.ConfigMap)
+		if backupFile == "" {
+			backupFile = fmt.Sprintf("%s-%s-%s.bak", namespace, name, tls.UserCABundleMapKey)
+		}
+		if changed, backedUp, err = openshift.EditCABundleConfigMap(o.OcpOpts.KUBECONFIG, namespace, name, backupFile, logged); err != nil {
+			return fmt.Errorf("Failed to edit %s: %w", target, err)
+		}
+	}
+	switch {
+	case changed && backedUp:
+		logger.Info("CA bundle %s updated, the original is saved in %s", target, backupFile)
+	case changed:
+		logger.Info("CA bundle %s updated", target)
+	default:
+		logger.Info("CA bundle %s unchanged", target)
+	}
+	return nil
+}
+
+func newBundleListCommand(globalOpts *common.GlobalOptions, opts *BundleOptions) *cobra.Command {
+	output := tls.OutputFormatText
+	cmd := &cobra.Command{
+		Use:           "list",
+		Short:         "Print a summary of each certificate in the CA bundle",
+		SilenceErrors: true,
+		SilenceUsage:  true,
+	}
+	cmd.Flags().StringVarP(&output, "output", "o", output, "The output format: text or json")
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		data, source, err := opts.read()
+		if err != nil {
+			return err
+		}
+		summaries, err := tls.SummarizeCertificates(data, source)
+		if err != nil {
+			return fmt.Errorf("failed to parse certificates in %s: %w", source, err)
+		}
+		return tls.WriteCertificateSummaries(cmd.OutOrStdout(), summaries, output)
+	}
+	return cmd
+}
+
+func newBundleAddCommand(globalOpts *common.GlobalOptions, opts *BundleOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:           "add cert-file...",
+		Short:         "Append the certificates of the files which are not in the CA bundle yet",
+		Args:          cobra.MinimumNArgs(1),
+		SilenceErrors: true,
+		SilenceUsage:  true,
+	}
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		logger := common.NewLoggerFromOptions(globalOpts, "TLS")
+
+		certs, err := certificatesFromFiles(args)
+		if err != nil {
+			return err
+		}
+		return opts.edit(logger, "Added", func(bundle *tls.CABundle) ([]*x509.Certificate, error) {
+			return bundle.Add(certs...), nil
+		})
+	}
+	return cmd
+}
+
+func newBundleRemoveCommand(globalOpts *common.GlobalOptions, opts *BundleOptions) *cobra.Command {
+	var fingerprints []string
+	cmd := &cobra.Command{
+		Use:           "remove [cert-file...]",
+		Short:         "Remove the certificates of the files, or with the fingerprints, from the CA bundle",
+		SilenceErrors: true,
+		SilenceUsage:  true,
+	}
+	cmd.Flags().StringArrayVar(&fingerprints, "fingerprint", fingerprints, "The SHA-256 fingerprint of the certificate to remove, with or without colons. Can be specified multiple times")
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		logger := common.NewLoggerFromOptions(globalOpts, "TLS")
+
+		if len(args) == 0 && len(fingerprints) == 0 {
+			cmd.Usage()
+			return fmt.Errorf("Error: at least one certificate file or --fingerprint is required")
+		}
+		certs, err := certificatesFromFiles(args)
+		if err != nil {
+			return err
+		}
+		remove := fingerprints
+		for _, cert := range certs {
+			remove = append(remove, tls.Fingerprint(cert))
+		}
+		return opts.edit(logger, "Removed", func(bundle *tls.CABundle) ([]*x509.Certificate, error) {
+			return bundle.Remove(remove...), nil
+		})
+	}
+	return cmd
+}
+
+func newBundleDedupeCommand(globalOpts *common.GlobalOptions, opts *BundleOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:           "dedupe",
+		Short:         "Remove the certificates which appear earlier in the CA bundle",
+		SilenceErrors: true,
+		SilenceUsage:  true,
+	}
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		logger := common.NewLoggerFromOptions(globalOpts, "TLS")
+
+		return opts.edit(logger, "Removed duplicated", func(bundle *tls.CABundle) ([]*x509.Certificate, error) {
+			return bundle.Dedupe(), nil
+		})
+	}
+	return cmd
+}
+
+func newBundlePruneExpiredCommand(globalOpts *common.GlobalOptions, opts *BundleOptions) *cobra.Command {
+	var at string
+	cmd := &cobra.Command{
+		Use:           "prune-expired",
+		Short:         "Remove the expired certificates from the CA bundle",
+		SilenceErrors: true,
+		SilenceUsage:  true,
+	}
+	cmd.Flags().StringVar(&at, "at", at, "Prune the certificates expired at this time instead of now, in RFC 3339, 2006-01-02 or like +720h")
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		logger := common.NewLoggerFromOptions(globalOpts, "TLS")
+
+		now := time.Now()
+		if at != "" {
+			var err error
+			if now, err = tls.ParseTime(at); err != nil {
+				return err
+			}
+		}
+		return opts.edit(logger, "Removed expired", func(bundle *tls.CABundle) ([]*x509.Certificate, error) {
+			return bundle.PruneExpired(now), nil
+		})
+	}
+	return cmd
+}
+
+// certificatesFromFiles reads all certificates of the PEM files
+func certificatesFromFiles(files []string) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read certificate file: %w", err)
+		}
+		fileCerts, err := tls.PemToCertificates(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load certificates from %s: %w", file, err)
+		}
+		certs = append(certs, fileCerts...)
+	}
+	return certs, nil
+}
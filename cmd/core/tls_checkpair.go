@@ -0,0 +1,91 @@
+package core
+
+import (
+	"fmt"
+
+	"github.com/openqe/openqe/pkg/common"
+	"github.com/openqe/openqe/pkg/openshift"
+	"github.com/openqe/openqe/pkg/tls"
+	"github.com/spf13/cobra"
+)
+
+// ============    CHECK-PAIR COMMAND     ==============================
+
+type CheckPairOptions struct {
+	*tls.CheckPairOptions
+	OcpOpts *openshift.OcpOptions
+	// Secret is a kubernetes.io/tls Secret in form of <namespace>/<name>, checked instead of the files
+	Secret string
+	Output string
+}
+
+func NewCheckPairCommand(globalOpts *common.GlobalOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "check-pair",
+		Short: "Check that a private key matches a certificate, its SANs and its remaining validity",
+		Long: `Check that a private key matches a certificate, its SANs and its remaining validity.
+The key/cert pair is read from files, or from the tls.key and tls.crt keys of a TLS Secret with --secret.
+The expected subject and SANs are checked only when they are specified, each kind of SANs separately.
+The problems found are reported and the command exits with code 1, so it can gate a CI pipeline.
+
+Examples:
+  # Check the key matches the certificate, which is valid for 30 more days
+  openqe tls check-pair --key-file tls.key --cert-file tls.crt --min-remaining 720h
+
+  # Check the serving certificate of the router did not drift from the expected wildcard
+  openqe tls check-pair --secret openshift-ingress/router-certs-default --dns-name '*.apps.example.com' -o json
+`,
+		SilenceErrors: true,
+		SilenceUsage:  true,
+	}
+
+	opts := &CheckPairOptions{
+		CheckPairOptions: tls.DefaultCheckPairOptions(),
+		OcpOpts:          openshift.DefaultOcpOptions(),
+		Output:           tls.OutputFormatText,
+	}
+	flags := cmd.Flags()
+	flags.StringVar(&opts.KeyFile, "key-file", opts.KeyFile, "The private key file to check.")
+	flags.StringVar(&opts.KeyPassphraseFile, "key-passphrase-file", opts.KeyPassphraseFile, "The file with the passphrase of the private key when it is encrypted.")
+	flags.StringVar(&opts.CertFile, "cert-file", opts.CertFile, "The certificate file to check, the first certificate is checked.")
+	flags.StringVar(&opts.Secret, "secret", opts.Secret, "The TLS Secret to check instead of the files, in form of <namespace>/<name>.")
+	flags.StringVar(&opts.OcpOpts.KUBECONFIG, "kubeconfig", opts.OcpOpts.KUBECONFIG, "The kubeconfig file used to read the Secret.")
+	flags.StringVar(&opts.Subject, "subject", opts.Subject, "The expected subject of the certificate, "+SubjectFormatHelp)
+	BindSANOptions(&opts.SANOptions, "", "expected SANs of the certificate", flags)
+	flags.DurationVar(&opts.MinRemaining, "min-remaining", opts.MinRemaining, "The minimum remaining validity of the certificate, like 720h.")
+	flags.StringVarP(&opts.Output, "output", "o", opts.Output, "The output format: text or json")
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		var result *tls.CheckPairResult
+		var err error
+		if opts.Secret != "" {
+			result, err = opts.checkSecret()
+		} else {
+			result, err = tls.CheckPairFiles(opts.CheckPairOptions)
+		}
+		if err != nil {
+			return fmt.Errorf("Failed to check the key/cert pair: %w", err)
+		}
+		if err := tls.WriteCheckPairResult(cmd.OutOrStdout(), result, opts.Output); err != nil {
+			return err
+		}
+		if !result.OK() {
+			return fmt.Errorf("Key/cert pair %s failed the check with %d problem(s)", result.Source, len(result.Problems))
+		}
+		return nil
+	}
+	return cmd
+}
+
+// checkSecret checks the key/cert pair in the tls.key and tls.crt keys of the Secret
+func (o *CheckPairOptions) checkSecret() (*tls.CheckPairResult, error) {
+	namespace, name, err := openshift.ParseObjectReference(o.Secret)
+	if err != nil {
+		return nil, err
+	}
+	data, err := openshift.CertificateDataFromCluster(o.OcpOpts.KUBECONFIG, openshift.KindSecret, namespace, name, tls.TLSSignerKeyMapKey, tls.TLSSignerCertMapKey)
+	if err != nil {
+		return nil, err
+	}
+	return tls.CheckKeyPair(data[tls.TLSSignerKeyMapKey], data[tls.TLSSignerCertMapKey], fmt.Sprintf("%s %s", openshift.KindSecret, o.Secret), o.CheckPairOptions)
+}
@@ -2,6 +2,7 @@ package core
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/openqe/openqe/pkg/common"
 	"github.com/openqe/openqe/pkg/tls"
@@ -21,30 +22,112 @@ func NewTLSCommand(globalOpts *common.GlobalOptions) *cobra.Command {
 	cmd.AddCommand(NewCAGenCommand(globalOpts))
 	cmd.AddCommand(NewTLSGenCommand(globalOpts))
 	cmd.AddCommand(NewCACheckCommand(globalOpts))
+	cmd.AddCommand(NewInspectCommand(globalOpts))
+	cmd.AddCommand(NewCSRGenCommand(globalOpts))
+	cmd.AddCommand(NewSignCSRCommand(globalOpts))
+	cmd.AddCommand(NewRevokeCommand(globalOpts))
+	cmd.AddCommand(NewCRLGenCommand(globalOpts))
+	cmd.AddCommand(NewOCSPServeCommand(globalOpts))
+	cmd.AddCommand(NewExportCommand(globalOpts))
+	cmd.AddCommand(NewVerifyCommand(globalOpts))
+	cmd.AddCommand(NewApplyCommand(globalOpts))
+	cmd.AddCommand(NewServeCommand(globalOpts))
+	cmd.AddCommand(NewProbeCommand(globalOpts))
+	cmd.AddCommand(NewBundleCommand(globalOpts))
+	cmd.AddCommand(NewRenewCommand(globalOpts))
+	cmd.AddCommand(NewCrossSignCommand(globalOpts))
+	cmd.AddCommand(NewConvertCommand(globalOpts))
+	cmd.AddCommand(NewCheckPairCommand(globalOpts))
 	return cmd
 }
 
+// SubjectFormatHelp describes the subject forms accepted by the subject flags
+const SubjectFormatHelp = "in the RFC 4514 form like 'CN=server, O=Example, C=US' or the OpenSSL form like /C=US/O=Example/CN=server. " +
+	"The RFC 4514 form lists the most specific RDN first and is encoded in reverse order, so a subject like 'C=US, O=Example, CN=server' is encoded with CN first."
+
+// WarnCountryFirstSubject warns when a subject flag is in the RFC 4514 form with the country first,
+// which is encoded in reverse order with the common name first.
+func WarnCountryFirstSubject(logger *common.Logger, flag, subject string) {
+	if tls.IsCountryFirstSubject(subject) {
+		logger.Info("Warning: --%s %q is in the RFC 4514 form which lists the most specific RDN first, so it is encoded with CN first. "+
+			"Reverse it, or use the OpenSSL form like /C=US/O=Example/CN=server, to encode C first.", flag, subject)
+	}
+}
+
 // ============    CA-GEN COMMAND     ==============================
 func BindCAOptions(opts *tls.CAOptions, flags *flag.FlagSet) {
-	flags.StringVar(&opts.Subject, "ca-subject", opts.Subject, "The CA certificate subject used to generate the TLS CA.")
-	flags.StringVar(&opts.DNSName, "ca-dns-name", opts.DNSName, "The SAN used to generate the TLS CA.")
+	flags.StringVar(&opts.Subject, "ca-subject", opts.Subject, "The CA certificate subject used to generate the TLS CA, "+SubjectFormatHelp)
+	BindSANOptions(&opts.SANOptions, "ca-", "TLS CA", flags)
 	flags.StringVar(&opts.CaKeyFile, "ca-key-file", opts.CaKeyFile, "The CA private key file path to be generated to.")
 	flags.StringVar(&opts.CaCertFile, "ca-cert-file", opts.CaCertFile, "The CA certificate file path to be generated to.")
 }
 
+// BindSANOptions binds the subject alternative name options with the flag prefix, all of them can be repeated
+func BindSANOptions(opts *tls.SANOptions, prefix, target string, flags *flag.FlagSet) {
+	flags.StringArrayVar(&opts.DNSNames, prefix+"dns-name", opts.DNSNames, "The DNS SAN added to the "+target+", can be specified multiple times.")
+	flags.StringArrayVar(&opts.IPAddresses, prefix+"ip-address", opts.IPAddresses, "The IP address SAN added to the "+target+", can be specified multiple times.")
+	flags.StringArrayVar(&opts.URIs, prefix+"uri-san", opts.URIs, "The URI SAN added to the "+target+", e.g. spiffe://cluster.local/ns/default/sa/default, can be specified multiple times.")
+	flags.StringArrayVar(&opts.EmailAddresses, prefix+"email-san", opts.EmailAddresses, "The email SAN added to the "+target+", can be specified multiple times.")
+}
+
+// BindCAGenOptions binds the options of the CA private key and the CA parent, they are only meaningful when generating a CA
+func BindCAGenOptions(opts *tls.CAOptions, flags *flag.FlagSet) {
+	flags.StringVar(&opts.ParentCaKeyFile, "parent-ca-key", opts.ParentCaKeyFile, "The parent CA private key file, generates an intermediate CA signed by the parent CA.")
+	flags.StringVar(&opts.ParentCaCertFile, "parent-ca-cert", opts.ParentCaCertFile, "The parent CA certificate file, generates an intermediate CA signed by the parent CA.")
+	flags.StringVar(&opts.ParentKeyPassphraseFile, "parent-ca-key-passphrase-file", opts.ParentKeyPassphraseFile, "The file with the passphrase of the parent CA private key when it is encrypted.")
+	flags.StringVar(&opts.ParentCaDBFile, "parent-ca-db-file", opts.ParentCaDBFile, "The CA database file of the parent CA to record the intermediate CA in, like ca.db.json alongside ca.crt. Nothing is recorded when not specified.")
+	flags.DurationVar(&opts.Validity, "validity", opts.Validity, "The validity of the CA certificate, defaults to one year.")
+	flags.IntVar(&opts.PathLen, "path-len", opts.PathLen, "The maximum number of intermediate CAs below the generated CA, negative means unlimited.")
+	flags.StringVar(&opts.KeyAlgorithm, "key-algorithm", opts.KeyAlgorithm, "The CA private key algorithm: rsa, ecdsa or ed25519.")
+	flags.IntVar(&opts.KeySize, "key-size", opts.KeySize, "The CA private key size: RSA bits (default 2048) or ECDSA curve size: 256 (default), 384, 521. Ignored for ed25519.")
+	flags.StringVar(&opts.KeyPassphraseFile, "key-passphrase-file", opts.KeyPassphraseFile, "The file with the passphrase to write the CA private key as encrypted PKCS#8.")
+}
+
+// BindCAKeyPassphraseOption binds the passphrase file of an encrypted CA private key used for signing
+func BindCAKeyPassphraseOption(opts *tls.CAOptions, flags *flag.FlagSet) {
+	flags.StringVar(&opts.KeyPassphraseFile, "ca-key-passphrase-file", opts.KeyPassphraseFile, "The file with the passphrase of the CA private key when it is encrypted.")
+}
+
+// BindCADBFileOption binds the CA database file recording the certificates issued by the CA, which is opt-in
+func BindCADBFileOption(opts *tls.CAOptions, flags *flag.FlagSet) {
+	flags.StringVar(&opts.CaDBFile, "ca-db-file", opts.CaDBFile, "The CA database file to record the issued certificate in for 'tls revoke', 'tls crl-gen' and 'tls ocsp-serve', like ca.db.json alongside ca.crt. Nothing is recorded when not specified.")
+}
+
 func NewCAGenCommand(globalOpts *common.GlobalOptions) *cobra.Command {
 	cmd := &cobra.Command{
-		Use:           "ca-gen",
-		Short:         "Generate CA key/cert pair to files",
+		Use:   "ca-gen",
+		Short: "Generate CA key/cert pair to files",
+		Long: `Generate CA key/cert pair to files.
+The CA is self-signed by default. When --parent-ca-key and --parent-ca-cert are specified,
+an intermediate CA signed by the parent CA is generated instead, and the CA certificate file
+contains the intermediate CA certificate followed by the intermediate chain of the parent CA.
+
+With --output-format k8s, the CA is written as a Secret with the ca.crt and ca.key keys and a ConfigMap
+with the ca-bundle.crt key instead of files, and --apply creates or updates them in the cluster:
+  openqe tls ca-gen --output-format k8s --name my-ca --namespace test --label app=test --apply`,
 		SilenceErrors: true,
 		SilenceUsage:  true,
 	}
 
 	opts := tls.DefaultCAOptions()
+	manifestOpts := NewManifestOptions("openqe-ca")
 	BindCAOptions(opts, cmd.Flags())
+	BindCAGenOptions(opts, cmd.Flags())
+	BindManifestOptions(manifestOpts, true, cmd.Flags())
 	cmd.RunE = func(cmd *cobra.Command, args []string) error {
 		logger := common.NewLoggerFromOptions(globalOpts, "TLS")
+		WarnCountryFirstSubject(logger, "ca-subject", opts.Subject)
 
+		if err := manifestOpts.Validate(); err != nil {
+			return err
+		}
+		if manifestOpts.k8s() {
+			secret, bundle, err := tls.GenerateCAManifests(opts, manifestOpts.ManifestOptions)
+			if err != nil {
+				return fmt.Errorf("Failed to generate the CA key/cert pair: %v", err)
+			}
+			return manifestOpts.writeManifests(cmd, logger, secret, bundle)
+		}
 		if err := tls.GenerateCAToFiles(opts); err != nil {
 			return fmt.Errorf("Failed to generate the CA key/cert pair: %v", err)
 		}
@@ -60,8 +143,14 @@ func BindPKIOptions(opts *tls.PKIOptions, flags *flag.FlagSet) {
 	BindCAOptions(opts.CaGenOpt, flags)
 	flags.StringVar(&opts.CertFile, "tls-cert-file", opts.CertFile, "The file path of the TLS certificate to be generated to.")
 	flags.StringVar(&opts.KeyFile, "tls-key-file", opts.KeyFile, "The file path of the TLS private key to be generated to.")
-	flags.StringVar(&opts.Subject, "subject", opts.Subject, "The TLS certificate subject.")
-	flags.StringVar(&opts.DNSName, "dns-name", opts.DNSName, "The SAN added to the TLS certificate.")
+	flags.StringArrayVar(&opts.CRLDistributionPoints, "crl-url", opts.CRLDistributionPoints, "The CRL distribution point URL embedded in the TLS certificate, can be specified multiple times.")
+	flags.StringArrayVar(&opts.OCSPServers, "ocsp-url", opts.OCSPServers, "The OCSP responder URL embedded in the authority information access extension of the TLS certificate, can be specified multiple times.")
+	flags.BoolVar(&opts.MustStaple, "must-staple", opts.MustStaple, "Add the TLS feature extension requiring the server to staple an OCSP response (OCSP must-staple).")
+	flags.StringVar(&opts.ChainFile, "chain-file", opts.ChainFile, "The file path of the TLS certificate chain (leaf and intermediate CAs) to be generated to. When not set, the chain is written to the TLS certificate file.")
+	flags.StringVar(&opts.Subject, "subject", opts.Subject, "The TLS certificate subject, "+SubjectFormatHelp)
+	BindSANOptions(&opts.SANOptions, "", "TLS certificate", flags)
+	flags.StringVar(&opts.KeyAlgorithm, "key-algorithm", opts.KeyAlgorithm, "The TLS private key algorithm: rsa, ecdsa or ed25519.")
+	flags.IntVar(&opts.KeySize, "key-size", opts.KeySize, "The TLS private key size: RSA bits (default 2048) or ECDSA curve size: 256 (default), 384, 521. Ignored for ed25519.")
 }
 
 func NewTLSGenCommand(globalOpts *common.GlobalOptions) *cobra.Command {
@@ -70,15 +159,53 @@ func NewTLSGenCommand(globalOpts *common.GlobalOptions) *cobra.Command {
 		Short: "Generate TLS key/cert pair to files, signed by a given CA",
 		Long: `Generate TLS key/cert pair to files, signed by a given CA.
 The CA key/cert files must be provided to sign the generated TLS certificate.
-You can use the 'tls ca-gen' command to generate a CA key/cert pair for testing purpose.`,
+You can use the 'tls ca-gen' command to generate a CA key/cert pair for testing purpose.
+
+The --defect flag generates a deliberately broken key/cert pair for negative testing:
+  expired, not-yet-valid:  the validity window ends yesterday or starts tomorrow
+  wrong-hostname:          the only SAN is ` + tls.WrongHostname + `
+  sha1:                    the certificate has a SHA-1 signature
+  weak-key:                the key is a 1024-bit RSA key
+  no-server-auth:          the certificate has the clientAuth extended key usage only
+  ca-leaf:                 the leaf certificate has CA:TRUE
+  self-signed:             the certificate is self-signed instead of signed by the CA
+  mismatched-key:          the key file does not match the certificate
+  truncated-pem:           the certificate file is cut in the middle
+
+With --output-format k8s, the key/cert pair is written as a kubernetes.io/tls Secret, with the CA
+certificate in the ca.crt key, instead of files, and --apply creates or updates it in the cluster:
+  openqe tls cert-gen --output-format k8s --name my-tls --namespace test --dns-name my.example.com`,
 		SilenceErrors: true,
 		SilenceUsage:  true,
 	}
 
 	opts := tls.DefaultPKIOptions()
-	BindPKIOptions(opts, cmd.Flags())
+	flags := cmd.Flags()
+	BindPKIOptions(opts, flags)
+	BindCAKeyPassphraseOption(opts.CaGenOpt, flags)
+	BindCADBFileOption(opts.CaGenOpt, flags)
+	flags.StringVar(&opts.KeyPassphraseFile, "key-passphrase-file", opts.KeyPassphraseFile, "The file with the passphrase to write the TLS private key as encrypted PKCS#8.")
+	flags.DurationVar(&opts.Validity, "validity", opts.Validity, "The validity of the TLS certificate, defaults to one year.")
+	flags.StringVar(&opts.NotBefore, "not-before", opts.NotBefore, "The start of the TLS certificate validity in RFC 3339, a date like 2006-01-02, or relative to now like -24h. Defaults to now.")
+	flags.StringVar(&opts.NotAfter, "not-after", opts.NotAfter, "The end of the TLS certificate validity in the same formats as --not-before. Defaults to one year after the start.")
+	BindProfileOptions(&opts.Profile, &opts.ExtKeyUsages, flags)
+	flags.StringVar(&opts.Defect, "defect", opts.Defect, "Generate a deliberately broken TLS certificate for negative testing: "+defectNames()+".")
+	manifestOpts := NewManifestOptions("openqe-tls")
+	BindManifestOptions(manifestOpts, false, flags)
 	cmd.RunE = func(cmd *cobra.Command, args []string) error {
 		logger := common.NewLoggerFromOptions(globalOpts, "TLS")
+		WarnCountryFirstSubject(logger, "subject", opts.Subject)
+
+		if err := manifestOpts.Validate(); err != nil {
+			return err
+		}
+		if manifestOpts.k8s() {
+			secret, err := tls.GenerateTLSSecret(opts, manifestOpts.ManifestOptions)
+			if err != nil {
+				return fmt.Errorf("Failed to generate the TLS key/cert pair: %s", err)
+			}
+			return manifestOpts.writeManifests(cmd, logger, secret)
+		}
 
 		if err := tls.GenerateTLSKeyCertPairToFiles(opts); err != nil {
 			return fmt.Errorf("Failed to generate the TLS key/cert pair: %s", err)
@@ -89,6 +216,25 @@ You can use the 'tls ca-gen' command to generate a CA key/cert pair for testing
 	return cmd
 }
 
+// BindProfileOptions binds the certificate profile and the extended key usage options
+func BindProfileOptions(profile *string, extKeyUsages *[]string, flags *flag.FlagSet) {
+	names := make([]string, 0, len(tls.Profiles))
+	for _, p := range tls.Profiles {
+		names = append(names, string(p))
+	}
+	flags.StringVar(profile, "profile", *profile, "The certificate profile setting the key usages, extended key usages and basic constraints, one of "+strings.Join(names, ", ")+", or empty for none.")
+	flags.StringArrayVar(extKeyUsages, "ext-key-usage", *extKeyUsages, "An extended key usage added to the ones of the profile, by name like serverAuth, clientAuth, codeSigning, or by dotted OID. Can be specified multiple times.")
+}
+
+// defectNames returns the names of the supported defects separated by commas
+func defectNames() string {
+	names := make([]string, 0, len(tls.Defects))
+	for _, d := range tls.Defects {
+		names = append(names, string(d))
+	}
+	return strings.Join(names, ", ")
+}
+
 // ============    CA-CHECK COMMAND     ==============================
 
 type CACheckOptions struct {
@@ -108,7 +254,7 @@ or failure (exit code 1) if it is not found.`,
 	}
 
 	opts := &CACheckOptions{
-		CABundleFile: "/etc/pki/tls/certs/ca-bundle.crt", // default ca bundle file
+		CABundleFile: tls.DefaultCABundleFile,
 	}
 	cmd.Flags().StringVar(&opts.CACertFile, "ca-cert-file", opts.CACertFile, "The CA certificate file to check")
 	cmd.Flags().StringVar(&opts.CABundleFile, "ca-bundle-file", opts.CABundleFile, "The CA bundle file to check against")
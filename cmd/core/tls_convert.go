@@ -0,0 +1,72 @@
+package core
+
+import (
+	"fmt"
+
+	"github.com/openqe/openqe/pkg/common"
+	"github.com/openqe/openqe/pkg/tls"
+	"github.com/spf13/cobra"
+)
+
+// ============    CONVERT COMMAND     ==============================
+
+func NewConvertCommand(globalOpts *common.GlobalOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "convert",
+		Short: "Convert a private or public key between PKCS#1, PKCS#8, SEC1, PEM, DER and JWK",
+		Long: `Convert a private or public key between PKCS#1, PKCS#8, SEC1, PEM, DER and JWK.
+The input is a RSA, ECDSA or Ed25519 private key in PKCS#1, SEC1, PKCS#8 or encrypted PKCS#8, in PEM or DER,
+or a public key or a certificate when only the public key is exported with --public.
+
+Private key formats:  pkcs1 (RSA only), pkcs8 (default), sec1 (ECDSA only)
+Public key formats:   pkix (default), pkcs1 (RSA only), jwk
+
+Examples:
+  # Convert a PKCS#1 RSA key to PKCS#8
+  openqe tls convert --in-file tls.key --out-file tls-pkcs8.key
+
+  # Encrypt a private key, and decrypt it again
+  openqe tls convert --in-file tls.key --out-file tls-enc.key --out-passphrase-file pass.txt
+  openqe tls convert --in-file tls-enc.key --passphrase-file pass.txt --format sec1 --out-file tls.key
+
+  # Export the public key of a key or a certificate as DER, or as a JWK
+  openqe tls convert --in-file tls.key --public --encoding der --out-file tls.pub.der
+  openqe tls convert --in-file tls.crt --format jwk
+`,
+		SilenceErrors: true,
+		SilenceUsage:  true,
+	}
+
+	opts := tls.DefaultConvertOptions()
+	flags := cmd.Flags()
+	flags.StringVar(&opts.InFile, "in-file", opts.InFile, "The private key, public key or certificate file to convert.")
+	flags.StringVar(&opts.PassphraseFile, "passphrase-file", opts.PassphraseFile, "The file with the passphrase of the input private key when it is encrypted.")
+	flags.StringVar(&opts.OutFile, "out-file", opts.OutFile, "The file path of the converted key, the standard output is used when not set.")
+	flags.StringVar(&opts.Format, "format", opts.Format, "The output format: pkcs1, pkcs8 or sec1 for a private key, pkix, pkcs1 or jwk for a public key.")
+	flags.StringVar(&opts.Encoding, "encoding", opts.Encoding, "The output encoding: pem or der.")
+	flags.BoolVar(&opts.Public, "public", opts.Public, "Export the public key, implied by the pkix and jwk formats.")
+	flags.StringVar(&opts.OutPassphraseFile, "out-passphrase-file", opts.OutPassphraseFile, "The file with the passphrase to write the private key as encrypted PKCS#8.")
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		logger := common.NewLoggerFromOptions(globalOpts, "TLS")
+
+		if opts.InFile == "" {
+			cmd.Usage()
+			return fmt.Errorf("Error: --in-file is required")
+		}
+		if opts.OutFile == "" {
+			out, err := tls.ConvertKey(opts)
+			if err != nil {
+				return fmt.Errorf("Failed to convert the key: %w", err)
+			}
+			_, err = cmd.OutOrStdout().Write(out)
+			return err
+		}
+		if err := tls.ConvertKeyToFile(opts); err != nil {
+			return fmt.Errorf("Failed to convert the key: %w", err)
+		}
+		logger.Info("Key %s converted to %s", opts.InFile, opts.OutFile)
+		return nil
+	}
+	return cmd
+}
@@ -0,0 +1,76 @@
+package core
+
+import (
+	"fmt"
+
+	"github.com/openqe/openqe/pkg/common"
+	"github.com/openqe/openqe/pkg/tls"
+	"github.com/spf13/cobra"
+)
+
+// ============    REVOKE COMMAND     ==============================
+
+func NewRevokeCommand(globalOpts *common.GlobalOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "revoke",
+		Short: "Revoke a certificate issued by a given CA",
+		Long: `Revoke a certificate issued by a given CA, specified by either --serial or --cert-file.
+The revocation is recorded in the CA database file alongside the CA files, like ca.db.json for ca.crt.
+Use the 'tls crl-gen' command afterwards to publish the revocation in a CRL.`,
+		SilenceErrors: true,
+		SilenceUsage:  true,
+	}
+
+	opts := tls.DefaultRevokeOptions()
+	flags := cmd.Flags()
+	flags.StringVar(&opts.CaGenOpt.CaCertFile, "ca-cert-file", opts.CaGenOpt.CaCertFile, "The CA certificate file which issued the certificate.")
+	flags.StringVar(&opts.CaGenOpt.CaDBFile, "ca-db-file", opts.CaGenOpt.CaDBFile, "The CA database file, defaults to the CA certificate file name with the .db.json extension.")
+	flags.StringVar(&opts.Serial, "serial", opts.Serial, "The serial number in hex of the certificate to revoke.")
+	flags.StringVar(&opts.CertFile, "cert-file", opts.CertFile, "The certificate file to revoke.")
+	flags.StringVar(&opts.Reason, "reason", opts.Reason, "The revocation reason, like keyCompromise, superseded or cessationOfOperation.")
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		logger := common.NewLoggerFromOptions(globalOpts, "TLS")
+
+		serial, err := tls.RevokeCertificate(opts)
+		if err != nil {
+			return fmt.Errorf("Failed to revoke the certificate: %w", err)
+		}
+		logger.Info("Certificate with serial number %s revoked in CA database: %s", serial.Text(16), opts.CaGenOpt.DBFile())
+		return nil
+	}
+	return cmd
+}
+
+// ============    CRL-GEN COMMAND     ==============================
+
+func NewCRLGenCommand(globalOpts *common.GlobalOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "crl-gen",
+		Short: "Generate a CRL signed by a given CA",
+		Long: `Generate a CRL signed by a given CA, containing the certificates revoked by the 'tls revoke' command.
+Serve the CRL file at the URL specified by 'tls cert-gen --crl-url' to test the CRL handling of clients.`,
+		SilenceErrors: true,
+		SilenceUsage:  true,
+	}
+
+	opts := tls.DefaultCRLOptions()
+	flags := cmd.Flags()
+	flags.StringVar(&opts.CaGenOpt.CaKeyFile, "ca-key-file", opts.CaGenOpt.CaKeyFile, "The CA private key file used to sign the CRL.")
+	flags.StringVar(&opts.CaGenOpt.CaCertFile, "ca-cert-file", opts.CaGenOpt.CaCertFile, "The CA certificate file of the CRL issuer.")
+	BindCAKeyPassphraseOption(opts.CaGenOpt, flags)
+	flags.StringVar(&opts.CaGenOpt.CaDBFile, "ca-db-file", opts.CaGenOpt.CaDBFile, "The CA database file, defaults to the CA certificate file name with the .db.json extension.")
+	flags.StringVar(&opts.CRLFile, "crl-file", opts.CRLFile, "The file path of the CRL to be generated to.")
+	flags.DurationVar(&opts.NextUpdate, "next-update", opts.NextUpdate, "The duration from now until the next update of the CRL, a negative value generates a stale CRL.")
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		logger := common.NewLoggerFromOptions(globalOpts, "TLS")
+
+		if err := tls.GenerateCRLToFile(opts); err != nil {
+			return fmt.Errorf("Failed to generate the CRL: %w", err)
+		}
+		logger.Info("CRL generated to crlFile: %s", opts.CRLFile)
+		return nil
+	}
+	return cmd
+}
@@ -0,0 +1,80 @@
+package core
+
+import (
+	"fmt"
+
+	"github.com/openqe/openqe/pkg/common"
+	"github.com/openqe/openqe/pkg/tls"
+	"github.com/spf13/cobra"
+)
+
+// ============    CSR-GEN COMMAND     ==============================
+
+func NewCSRGenCommand(globalOpts *common.GlobalOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "csr-gen",
+		Short: "Generate a private key and a PKCS#10 certificate request to files",
+		Long: `Generate a private key and a PKCS#10 certificate request (CSR) to files.
+The CSR can be signed by the 'tls sign-csr' command, or submitted to the product under test.`,
+		SilenceErrors: true,
+		SilenceUsage:  true,
+	}
+
+	opts := tls.DefaultCSROptions()
+	flags := cmd.Flags()
+	BindSANOptions(&opts.SANOptions, "", "certificate request", flags)
+	flags.StringVar(&opts.Subject, "subject", opts.Subject, "The certificate request subject, "+SubjectFormatHelp)
+	flags.StringVar(&opts.KeyAlgorithm, "key-algorithm", opts.KeyAlgorithm, "The private key algorithm: rsa, ecdsa or ed25519.")
+	flags.IntVar(&opts.KeySize, "key-size", opts.KeySize, "The private key size: RSA bits (default 2048) or ECDSA curve size: 256 (default), 384, 521. Ignored for ed25519.")
+	flags.StringVar(&opts.KeyFile, "key-file", opts.KeyFile, "The file path of the private key to be generated to.")
+	flags.StringVar(&opts.CSRFile, "csr-file", opts.CSRFile, "The file path of the certificate request to be generated to.")
+	flags.StringVar(&opts.KeyPassphraseFile, "key-passphrase-file", opts.KeyPassphraseFile, "The file with the passphrase to write the private key as encrypted PKCS#8.")
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		logger := common.NewLoggerFromOptions(globalOpts, "TLS")
+		WarnCountryFirstSubject(logger, "subject", opts.Subject)
+
+		if err := tls.GenerateCSRToFiles(opts); err != nil {
+			return fmt.Errorf("Failed to generate the certificate request: %w", err)
+		}
+		logger.Info("Certificate request generated to keyFile: %s, csrFile: %s", opts.KeyFile, opts.CSRFile)
+		return nil
+	}
+	return cmd
+}
+
+// ============    SIGN-CSR COMMAND     ==============================
+
+func NewSignCSRCommand(globalOpts *common.GlobalOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "sign-csr",
+		Short: "Sign a PKCS#10 certificate request with a given CA",
+		Long: `Sign a PKCS#10 certificate request (CSR) with a given CA.
+The subject, the SANs and the public key of the issued certificate come from the CSR,
+which can be generated by the 'tls csr-gen' command or by any other tool.`,
+		SilenceErrors: true,
+		SilenceUsage:  true,
+	}
+
+	opts := tls.DefaultSignCSROptions()
+	flags := cmd.Flags()
+	flags.StringVar(&opts.CaGenOpt.CaKeyFile, "ca-key-file", opts.CaGenOpt.CaKeyFile, "The CA private key file used to sign the certificate request.")
+	flags.StringVar(&opts.CaGenOpt.CaCertFile, "ca-cert-file", opts.CaGenOpt.CaCertFile, "The CA certificate file used to sign the certificate request.")
+	BindCAKeyPassphraseOption(opts.CaGenOpt, flags)
+	BindCADBFileOption(opts.CaGenOpt, flags)
+	flags.StringVar(&opts.CSRFile, "csr-file", opts.CSRFile, "The certificate request file to sign.")
+	flags.StringVar(&opts.CertFile, "tls-cert-file", opts.CertFile, "The file path of the issued certificate to be generated to.")
+	flags.DurationVar(&opts.Validity, "validity", opts.Validity, "The validity of the issued certificate.")
+	BindProfileOptions(&opts.Profile, &opts.ExtKeyUsages, flags)
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		logger := common.NewLoggerFromOptions(globalOpts, "TLS")
+
+		if err := tls.SignCSRToFile(opts); err != nil {
+			return fmt.Errorf("Failed to sign the certificate request: %w", err)
+		}
+		logger.Info("Certificate request %s signed to certFile: %s", opts.CSRFile, opts.CertFile)
+		return nil
+	}
+	return cmd
+}
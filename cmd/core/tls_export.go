@@ -0,0 +1,56 @@
+package core
+
+import (
+	"fmt"
+
+	"github.com/openqe/openqe/pkg/common"
+	"github.com/openqe/openqe/pkg/tls"
+	"github.com/spf13/cobra"
+)
+
+// ============    EXPORT COMMAND     ==============================
+
+func NewExportCommand(globalOpts *common.GlobalOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export PEM files into a password protected PKCS#12 keystore or a Java truststore",
+		Long: `Export PEM files into a password protected PKCS#12 keystore or a Java truststore.
+With --key-file, the private key and the certificate chain of --cert-file, like the files generated by
+'tls cert-gen', are exported into a PKCS#12 keystore. Without --key-file, the certificates of --cert-file,
+like a CA or a CA bundle, are exported into a PKCS#12 or a JKS truststore.
+
+The password is read from --password-file, or from the keyring by --password-keyring in form of 'service,secret'.
+
+Examples:
+  # Export the TLS key and certificate chain into tls.p12
+  openqe tls export --key-file tls.key --cert-file tls.crt --password-file password.txt
+
+  # Export a CA bundle into a JKS truststore with the password in the keyring
+  openqe tls export --format jks-truststore --cert-file ca-bundle.crt --out-file truststore.jks --password-keyring openqe,truststore
+`,
+		SilenceErrors: true,
+		SilenceUsage:  true,
+	}
+
+	opts := tls.DefaultExportOptions()
+	flags := cmd.Flags()
+	flags.StringVar(&opts.Format, "format", opts.Format, "The keystore format: pkcs12 or jks-truststore.")
+	flags.StringVar(&opts.KeyFile, "key-file", opts.KeyFile, "The private key file of the certificate, a truststore is exported when not set.")
+	flags.StringVar(&opts.KeyPassphraseFile, "key-passphrase-file", opts.KeyPassphraseFile, "The file with the passphrase of the private key when it is encrypted.")
+	flags.StringVar(&opts.CertFile, "cert-file", opts.CertFile, "The certificate file followed by its chain, or the CA certificates of a truststore.")
+	flags.StringVar(&opts.OutFile, "out-file", opts.OutFile, "The keystore file, defaults to the certificate file name with the .p12 or .jks extension.")
+	flags.StringVar(&opts.PasswordFile, "password-file", opts.PasswordFile, "The file containing the keystore password.")
+	flags.StringVar(&opts.PasswordKeyring, "password-keyring", opts.PasswordKeyring, "The keyring entry of the keystore password in form of 'service,secret'.")
+	flags.StringVar(&opts.Alias, "alias", opts.Alias, "The alias prefix of the JKS truststore entries.")
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		logger := common.NewLoggerFromOptions(globalOpts, "TLS")
+
+		if err := tls.ExportToFile(opts); err != nil {
+			return fmt.Errorf("Failed to export the keystore: %w", err)
+		}
+		logger.Info("Certificates in %s exported to %s keystore: %s", opts.CertFile, opts.Format, opts.OutputFile())
+		return nil
+	}
+	return cmd
+}
@@ -0,0 +1,107 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/openqe/openqe/pkg/common"
+	"github.com/openqe/openqe/pkg/openshift"
+	"github.com/openqe/openqe/pkg/tls"
+	"github.com/spf13/cobra"
+)
+
+type InspectOptions struct {
+	OcpOpts   *openshift.OcpOptions
+	Secret    string
+	ConfigMap string
+	Keys      []string
+	Output    string
+}
+
+// NewInspectCommand creates the command to print a summary of each certificate in files, Secrets or ConfigMaps
+func NewInspectCommand(globalOpts *common.GlobalOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "inspect [file...]",
+		Short: "Print a summary of each certificate in PEM files, bundles, Secrets or ConfigMaps",
+		Long: `Print a summary of each certificate in PEM files, bundles, Secrets or ConfigMaps.
+The summary contains the subject, issuer, SANs, key type and size, validity window with days remaining,
+SHA-256 fingerprint, SPKI pin, isCA and path length of each certificate.
+
+Examples:
+  # Inspect a certificate or a bundle file
+  openqe tls inspect tls.crt /etc/pki/tls/certs/ca-bundle.crt
+
+  # Inspect the tls.crt, ca.crt and ca-bundle.crt keys of a live Secret in JSON
+  openqe tls inspect --secret openshift-ingress/router-certs-default -o json
+
+  # Inspect a specific key of a live ConfigMap
+  openqe tls inspect --configmap openshift-config/user-ca-bundle --key ca-bundle.crt
+`,
+		SilenceErrors: true,
+		SilenceUsage:  true,
+	}
+
+	opts := &InspectOptions{
+		OcpOpts: openshift.DefaultOcpOptions(),
+		Output:  tls.OutputFormatText,
+	}
+	flags := cmd.Flags()
+	flags.StringVar(&opts.OcpOpts.KUBECONFIG, "kubeconfig", opts.OcpOpts.KUBECONFIG, "The kubeconfig file used to read the Secret or ConfigMap")
+	flags.StringVar(&opts.Secret, "secret", opts.Secret, "The Secret to inspect in form of <namespace>/<name>")
+	flags.StringVar(&opts.ConfigMap, "configmap", opts.ConfigMap, "The ConfigMap to inspect in form of <namespace>/<name>")
+	flags.StringArrayVar(&opts.Keys, "key", opts.Keys, "The data key of the Secret or ConfigMap to inspect, defaults to tls.crt, ca.crt and ca-bundle.crt if present. Can be specified multiple times")
+	flags.StringVarP(&opts.Output, "output", "o", opts.Output, "The output format: text or json")
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		logger := common.NewLoggerFromOptions(globalOpts, "TLS")
+
+		if len(args) == 0 && opts.Secret == "" && opts.ConfigMap == "" {
+			cmd.Usage()
+			return fmt.Errorf("Error: at least one file, --secret or --configmap is required")
+		}
+
+		var summaries []tls.CertificateSummary
+		for _, file := range args {
+			data, err := os.ReadFile(file)
+			if err != nil {
+				return fmt.Errorf("failed to read certificate file: %w", err)
+			}
+			fileSummaries, err := tls.SummarizeCertificates(data, file)
+			if err != nil {
+				return fmt.Errorf("failed to parse certificates in %s: %w", file, err)
+			}
+			summaries = append(summaries, fileSummaries...)
+		}
+		for _, obj := range [][2]string{{openshift.KindSecret, opts.Secret}, {openshift.KindConfigMap, opts.ConfigMap}} {
+			kind, ref := obj[0], obj[1]
+			if ref == "" {
+				continue
+			}
+			namespace, name, err := openshift.ParseObjectReference(ref)
+			if err != nil {
+				return err
+			}
+			logger.Debug("Reading certificates from %s %s/%s", kind, namespace, name)
+			data, err := openshift.CertificateDataFromCluster(opts.OcpOpts.KUBECONFIG, kind, namespace, name, opts.Keys...)
+			if err != nil {
+				return fmt.Errorf("failed to read %s %s: %w", kind, ref, err)
+			}
+			keys := make([]string, 0, len(data))
+			for k := range data {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				source := fmt.Sprintf("%s %s/%s[%s]", kind, namespace, name, k)
+				keySummaries, err := tls.SummarizeCertificates(data[k], source)
+				if err != nil {
+					return fmt.Errorf("failed to parse certificates in %s: %w", source, err)
+				}
+				summaries = append(summaries, keySummaries...)
+			}
+		}
+		return tls.WriteCertificateSummaries(cmd.OutOrStdout(), summaries, opts.Output)
+	}
+	return cmd
+}
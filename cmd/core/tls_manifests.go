@@ -0,0 +1,92 @@
+package core
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/openqe/openqe/pkg/common"
+	"github.com/openqe/openqe/pkg/openshift"
+	"github.com/openqe/openqe/pkg/tls"
+	"github.com/spf13/cobra"
+	flag "github.com/spf13/pflag"
+	"k8s.io/apimachinery/pkg/runtime"
+	occlient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ManifestOptions contains the options to write the generated keys and certificates as Kubernetes manifests,
+// and to apply them to a cluster
+type ManifestOptions struct {
+	*tls.ManifestOptions
+	OcpOpts *openshift.OcpOptions
+	Apply   bool
+}
+
+func NewManifestOptions(name string) *ManifestOptions {
+	return &ManifestOptions{
+		ManifestOptions: tls.DefaultManifestOptions(name),
+		OcpOpts:         openshift.DefaultOcpOptions(),
+	}
+}
+
+// BindManifestOptions binds the options of the Kubernetes manifests, the CA bundle name is only bound for a CA
+func BindManifestOptions(opts *ManifestOptions, ca bool, flags *flag.FlagSet) {
+	flags.StringVar(&opts.OutputFormat, "output-format", opts.OutputFormat, "The output format: files writes PEM files, k8s writes Secret and ConfigMap manifests instead.")
+	flags.StringVar(&opts.Name, "name", opts.Name, "The name of the Secret in the k8s output format.")
+	flags.StringVar(&opts.Namespace, "namespace", opts.Namespace, "The namespace of the manifests in the k8s output format.")
+	flags.StringArrayVar(&opts.Labels, "label", opts.Labels, "A label of the manifests in form of key=value in the k8s output format. Can be specified multiple times.")
+	if ca {
+		flags.StringVar(&opts.BundleName, "bundle-name", opts.BundleName, "The name of the CA bundle ConfigMap in the k8s output format, defaults to <name>-bundle.")
+	}
+	flags.StringVar(&opts.ManifestFile, "manifest-file", opts.ManifestFile, "The YAML file to write the manifests to, defaults to the standard output unless --apply is set.")
+	flags.BoolVar(&opts.Apply, "apply", opts.Apply, "Create or update the manifests in the cluster in the k8s output format.")
+	flags.StringVar(&opts.OcpOpts.KUBECONFIG, "kubeconfig", opts.OcpOpts.KUBECONFIG, "The kubeconfig file used to apply the manifests")
+}
+
+// k8s tells whether the manifests are generated instead of the files
+func (o *ManifestOptions) k8s() bool {
+	return o.OutputFormat == tls.OutputFormatK8s
+}
+
+// Validate checks the manifest options, and the kubeconfig when the manifests are applied
+func (o *ManifestOptions) Validate() error {
+	if err := o.ManifestOptions.Validate(); err != nil {
+		return err
+	}
+	if o.k8s() && o.Apply {
+		return o.OcpOpts.Validate()
+	}
+	return nil
+}
+
+// writeManifests writes the manifests to the manifest file or the standard output, and applies them if requested
+func (o *ManifestOptions) writeManifests(cmd *cobra.Command, logger *common.Logger, objs ...occlient.Object) error {
+	if !o.Apply || o.ManifestFile != "" {
+		var w io.Writer = cmd.OutOrStdout()
+		if o.ManifestFile != "" {
+			f, err := os.OpenFile(o.ManifestFile, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+			if err != nil {
+				return fmt.Errorf("Failed to create the manifest file: %w", err)
+			}
+			defer f.Close()
+			w = f
+		}
+		manifests := make([]runtime.Object, 0, len(objs))
+		for _, obj := range objs {
+			manifests = append(manifests, obj)
+		}
+		if err := tls.WriteManifests(w, manifests...); err != nil {
+			return fmt.Errorf("Failed to write the manifests: %w", err)
+		}
+		if o.ManifestFile != "" {
+			logger.Info("Manifests written to %s", o.ManifestFile)
+		}
+	}
+	if o.Apply {
+		if err := openshift.ApplyObjects(o.OcpOpts.KUBECONFIG, objs...); err != nil {
+			return fmt.Errorf("Failed to apply the manifests: %w", err)
+		}
+		logger.Info("Manifests applied to namespace %s", o.Namespace)
+	}
+	return nil
+}
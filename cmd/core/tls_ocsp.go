@@ -0,0 +1,71 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/openqe/openqe/pkg/common"
+	"github.com/openqe/openqe/pkg/tls"
+	"github.com/spf13/cobra"
+)
+
+// ============    OCSP-SERVE COMMAND     ==============================
+
+func NewOCSPServeCommand(globalOpts *common.GlobalOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "ocsp-serve",
+		Short: "Run a local OCSP responder of a given CA",
+		Long: `Run a local OCSP responder of a given CA until interrupted.
+The status of a certificate is good if its issuance is recorded by 'tls cert-gen --ca-db-file', revoked if it is
+revoked by the 'tls revoke' command, and unknown otherwise, according to the CA database file alongside the CA files,
+like ca.db.json for ca.crt.
+Use 'tls cert-gen --ocsp-url' to embed the responder URL in the certificates, and '--must-staple' to require stapling.
+
+The --mode flag makes the responder misbehave to test the OCSP handling of clients and servers:
+  normal:       answer good, revoked or unknown
+  malformed:    answer bytes which are not an OCSP response
+  stale:        answer signed responses whose next update is in the past
+  unauthorized: answer the unauthorized error response
+  try-later:    answer the try later error response
+
+Examples:
+  openqe tls cert-gen --ca-db-file ca.db.json --ocsp-url http://127.0.0.1:8888 --must-staple
+  openqe tls ocsp-serve --listen 127.0.0.1:8888
+  openssl ocsp -issuer ca.crt -cert tls.crt -url http://127.0.0.1:8888 -CAfile ca.crt
+`,
+		SilenceErrors: true,
+		SilenceUsage:  true,
+	}
+
+	opts := tls.DefaultOCSPOptions()
+	flags := cmd.Flags()
+	flags.StringVar(&opts.CaGenOpt.CaKeyFile, "ca-key-file", opts.CaGenOpt.CaKeyFile, "The CA private key file used to sign the OCSP responses.")
+	flags.StringVar(&opts.CaGenOpt.CaCertFile, "ca-cert-file", opts.CaGenOpt.CaCertFile, "The CA certificate file of the OCSP responder.")
+	BindCAKeyPassphraseOption(opts.CaGenOpt, flags)
+	flags.StringVar(&opts.CaGenOpt.CaDBFile, "ca-db-file", opts.CaGenOpt.CaDBFile, "The CA database file, defaults to the CA certificate file name with the .db.json extension.")
+	flags.StringVar(&opts.Listen, "listen", opts.Listen, "The address the OCSP responder listens on.")
+	flags.StringVar(&opts.Mode, "mode", opts.Mode, "The responder mode: normal, malformed, stale, unauthorized or try-later.")
+	flags.DurationVar(&opts.NextUpdate, "next-update", opts.NextUpdate, "The duration until the next update of the OCSP responses.")
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		logger := common.NewLoggerFromOptions(globalOpts, "TLS")
+
+		responder, err := tls.NewOCSPResponder(opts)
+		if err != nil {
+			return fmt.Errorf("Failed to create the OCSP responder: %w", err)
+		}
+		server := &http.Server{Addr: opts.Listen, Handler: responder}
+		go func() {
+			<-cmd.Context().Done()
+			server.Shutdown(context.Background())
+		}()
+		logger.Info("OCSP responder of %s listening on http://%s in %s mode", opts.CaGenOpt.CaCertFile, opts.Listen, opts.Mode)
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			return fmt.Errorf("Failed to run the OCSP responder: %w", err)
+		}
+		return nil
+	}
+	return cmd
+}
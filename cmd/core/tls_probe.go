@@ -0,0 +1,68 @@
+package core
+
+import (
+	"fmt"
+
+	"github.com/openqe/openqe/pkg/common"
+	"github.com/openqe/openqe/pkg/tls"
+	"github.com/spf13/cobra"
+)
+
+// ============    PROBE COMMAND     ==============================
+
+type ProbeOptions struct {
+	*tls.ProbeOptions
+	Output string
+}
+
+func NewProbeCommand(globalOpts *common.GlobalOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "probe host:port",
+		Short: "Fetch and verify the certificate chain presented by a TLS endpoint",
+		Long: `Fetch and verify the certificate chain presented by a TLS endpoint, like a route or an API server.
+The negotiated TLS version and cipher suite, and the subject, issuer, SANs and expiry of each presented
+certificate are printed. The chain is verified for the server name against --ca-file, which defaults to
+the system CA bundle, and the command exits with code 1 if the verification fails.
+
+Examples:
+  # Probe the API server of a cluster with its CA
+  openqe tls probe api.mycluster.example.com:6443 --ca-file ca.crt
+
+  # Probe a route through the router IP and list the accepted TLS versions
+  openqe tls probe 10.0.0.10:443 --servername myapp.apps.mycluster.example.com --enumerate-versions
+`,
+		Args:          cobra.ExactArgs(1),
+		SilenceErrors: true,
+		SilenceUsage:  true,
+	}
+
+	opts := &ProbeOptions{
+		ProbeOptions: tls.DefaultProbeOptions(),
+		Output:       tls.OutputFormatText,
+	}
+	flags := cmd.Flags()
+	flags.StringVar(&opts.ServerName, "servername", opts.ServerName, "The server name sent as SNI and verified in the certificate, defaults to the host.")
+	flags.StringVar(&opts.CAFile, "ca-file", opts.CAFile, "The CA or CA bundle file to verify the chain, the system roots are used if the default file does not exist.")
+	flags.DurationVar(&opts.Timeout, "timeout", opts.Timeout, "The timeout of connecting to the endpoint.")
+	flags.BoolVar(&opts.EnumerateVersions, "enumerate-versions", opts.EnumerateVersions, "Probe TLS 1.0 to 1.3 separately to list the versions accepted by the server.")
+	flags.StringVarP(&opts.Output, "output", "o", opts.Output, "The output format: text or json")
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		logger := common.NewLoggerFromOptions(globalOpts, "TLS")
+
+		opts.Address = args[0]
+		logger.Debug("Probing %s with CA file %s", opts.Address, opts.CAFile)
+		result, err := tls.Probe(cmd.Context(), opts.ProbeOptions)
+		if err != nil {
+			return err
+		}
+		if err := tls.WriteProbeResult(cmd.OutOrStdout(), result, opts.Output); err != nil {
+			return err
+		}
+		if !result.Verified {
+			return fmt.Errorf("Verification of the chain presented by %s failed: %s", opts.Address, result.VerifyError)
+		}
+		return nil
+	}
+	return cmd
+}
@@ -0,0 +1,105 @@
+package core
+
+import (
+	"fmt"
+
+	"github.com/openqe/openqe/pkg/common"
+	"github.com/openqe/openqe/pkg/tls"
+	"github.com/spf13/cobra"
+)
+
+// ============    RENEW COMMAND     ==============================
+
+func NewRenewCommand(globalOpts *common.GlobalOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "renew",
+		Short: "Renew a certificate for its existing private key",
+		Long: `Renew a certificate for its existing private key.
+The renewed certificate keeps the subject, the SANs and the extensions of the certificate, and is valid from now.
+A self-signed certificate, like a CA, is signed by its own key again. A certificate issued by a CA needs
+--ca-key-file and --ca-cert-file, which can also be a different CA to re-sign the certificate by a new CA.
+
+Examples:
+  # Renew a self-signed CA for another year
+  openqe tls renew --key-file ca.key --cert-file ca.crt --validity 8760h
+
+  # Re-sign a leaf certificate by a new CA into a new file, keeping the key
+  openqe tls renew --key-file tls.key --cert-file tls.crt --ca-key-file new-ca.key --ca-cert-file new-ca.crt --out-file tls-new.crt
+`,
+		SilenceErrors: true,
+		SilenceUsage:  true,
+	}
+
+	opts := tls.DefaultRenewOptions()
+	flags := cmd.Flags()
+	flags.StringVar(&opts.KeyFile, "key-file", opts.KeyFile, "The private key file of the certificate.")
+	flags.StringVar(&opts.KeyPassphraseFile, "key-passphrase-file", opts.KeyPassphraseFile, "The file with the passphrase of the private key when it is encrypted.")
+	flags.StringVar(&opts.CertFile, "cert-file", opts.CertFile, "The certificate file to renew.")
+	flags.StringVar(&opts.CaGenOpt.CaKeyFile, "ca-key-file", opts.CaGenOpt.CaKeyFile, "The CA private key file used to sign the renewed certificate.")
+	flags.StringVar(&opts.CaGenOpt.CaCertFile, "ca-cert-file", opts.CaGenOpt.CaCertFile, "The CA certificate file used to sign the renewed certificate.")
+	BindCAKeyPassphraseOption(opts.CaGenOpt, flags)
+	BindCADBFileOption(opts.CaGenOpt, flags)
+	flags.StringVar(&opts.OutFile, "out-file", opts.OutFile, "The file path of the renewed certificate, defaults to overwriting --cert-file.")
+	flags.DurationVar(&opts.Validity, "validity", opts.Validity, "The validity of the renewed certificate, defaults to the validity of the certificate.")
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		logger := common.NewLoggerFromOptions(globalOpts, "TLS")
+
+		if err := tls.RenewToFile(opts); err != nil {
+			return fmt.Errorf("Failed to renew the certificate: %w", err)
+		}
+		outFile := opts.OutFile
+		if outFile == "" {
+			outFile = opts.CertFile
+		}
+		logger.Info("Certificate %s renewed to certFile: %s", opts.CertFile, outFile)
+		return nil
+	}
+	return cmd
+}
+
+// ============    CROSS-SIGN COMMAND     ==============================
+
+func NewCrossSignCommand(globalOpts *common.GlobalOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "cross-sign",
+		Short: "Issue a certificate for an existing CA under a different issuer CA",
+		Long: `Issue a certificate for an existing CA under a different issuer CA.
+The cross-signed certificate has the subject, the public key, the subject key identifier and the extensions of
+the existing CA, so the certificates issued by the existing CA chain up to either CA. This is used to test
+trust transitions, where clients trusting only the old CA accept the certificates issued by a new CA.
+
+Examples:
+  # Cross-sign the new CA by the old CA, and serve the cross-signed certificate as the intermediate
+  openqe tls cross-sign --cert-file new-ca.crt --ca-key-file old-ca.key --ca-cert-file old-ca.crt --out-file new-ca-cross.crt
+  cat tls.crt new-ca-cross.crt > tls-chain.crt
+`,
+		SilenceErrors: true,
+		SilenceUsage:  true,
+	}
+
+	opts := tls.DefaultCrossSignOptions()
+	flags := cmd.Flags()
+	flags.StringVar(&opts.CertFile, "cert-file", opts.CertFile, "The certificate file of the CA to cross-sign.")
+	flags.StringVar(&opts.CaGenOpt.CaKeyFile, "ca-key-file", opts.CaGenOpt.CaKeyFile, "The private key file of the issuer CA.")
+	flags.StringVar(&opts.CaGenOpt.CaCertFile, "ca-cert-file", opts.CaGenOpt.CaCertFile, "The certificate file of the issuer CA.")
+	BindCAKeyPassphraseOption(opts.CaGenOpt, flags)
+	BindCADBFileOption(opts.CaGenOpt, flags)
+	flags.StringVar(&opts.OutFile, "out-file", opts.OutFile, "The file path of the cross-signed certificate to be generated to.")
+	flags.DurationVar(&opts.Validity, "validity", opts.Validity, "The validity of the cross-signed certificate, defaults to the validity of the CA certificate.")
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		logger := common.NewLoggerFromOptions(globalOpts, "TLS")
+
+		if opts.CertFile == "" {
+			cmd.Usage()
+			return fmt.Errorf("Error: --cert-file is required")
+		}
+		if err := tls.CrossSignToFile(opts); err != nil {
+			return fmt.Errorf("Failed to cross-sign the CA: %w", err)
+		}
+		logger.Info("CA %s cross-signed by %s to certFile: %s", opts.CertFile, opts.CaGenOpt.CaCertFile, opts.OutFile)
+		return nil
+	}
+	return cmd
+}
@@ -0,0 +1,75 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/openqe/openqe/pkg/common"
+	"github.com/openqe/openqe/pkg/tls"
+	"github.com/spf13/cobra"
+)
+
+// ============    SERVE COMMAND     ==============================
+
+func NewServeCommand(globalOpts *common.GlobalOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Run a local HTTPS server echoing the request details as JSON",
+		Long: `Run a local HTTPS server until interrupted, answering every request with its details as JSON,
+including the TLS version, the cipher suite and the certificate chain presented by the client.
+The key/cert files generated by 'tls cert-gen' can be served directly, the certificate file may contain the chain.
+
+The --client-auth flag controls the client certificates, by default they are required and verified
+when --client-ca is set, and requested otherwise:
+  none, request, require, verify-if-given, require-and-verify
+
+Examples:
+  # Serve TLS 1.2 only with a single cipher suite for downgrade tests
+  openqe tls serve --cert tls.crt --key tls.key --min-version 1.2 --max-version 1.2 --ciphers TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256
+
+  # Require client certificates issued by a CA
+  openqe tls serve --cert tls.crt --key tls.key --client-ca ca.crt
+  curl --cacert ca.crt --cert client.crt --key client.key https://127.0.0.1:8443/
+`,
+		SilenceErrors: true,
+		SilenceUsage:  true,
+	}
+
+	opts := tls.DefaultServeOptions()
+	flags := cmd.Flags()
+	flags.StringVar(&opts.CertFile, "cert", opts.CertFile, "The server certificate file, optionally followed by its intermediate CAs.")
+	flags.StringVar(&opts.KeyFile, "key", opts.KeyFile, "The server private key file.")
+	flags.StringVar(&opts.KeyPassphraseFile, "key-passphrase-file", opts.KeyPassphraseFile, "The file with the passphrase of the private key when it is encrypted.")
+	flags.StringVar(&opts.ClientCAFile, "client-ca", opts.ClientCAFile, "The CA or CA bundle file to verify the client certificates.")
+	flags.StringVar(&opts.ClientAuth, "client-auth", opts.ClientAuth, "The client certificate policy: auto, none, request, require, verify-if-given or require-and-verify.")
+	flags.StringVar(&opts.Listen, "listen", opts.Listen, "The address the HTTPS server listens on.")
+	flags.StringVar(&opts.MinVersion, "min-version", opts.MinVersion, "The minimum TLS version: 1.0, 1.1, 1.2 or 1.3.")
+	flags.StringVar(&opts.MaxVersion, "max-version", opts.MaxVersion, "The maximum TLS version: 1.0, 1.1, 1.2 or 1.3.")
+	flags.StringArrayVar(&opts.CipherSuites, "ciphers", opts.CipherSuites, "The cipher suites of TLS 1.2 and lower by their standard names, separated by commas or specified multiple times. TLS 1.3 cipher suites are not configurable.")
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		logger := common.NewLoggerFromOptions(globalOpts, "TLS")
+
+		tlsConfig, err := tls.ServerTLSConfig(opts)
+		if err != nil {
+			return fmt.Errorf("Failed to configure the HTTPS server: %w", err)
+		}
+		handler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			logger.Debug("%s %s from %s", req.Method, req.URL, req.RemoteAddr)
+			tls.EchoHandler{}.ServeHTTP(w, req)
+		})
+		server := &http.Server{Addr: opts.Listen, Handler: handler, TLSConfig: tlsConfig}
+		go func() {
+			<-cmd.Context().Done()
+			server.Shutdown(context.Background())
+		}()
+		logger.Info("HTTPS server listening on https://%s", opts.Listen)
+		if err := server.ListenAndServeTLS("", ""); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			return fmt.Errorf("Failed to run the HTTPS server: %w", err)
+		}
+		return nil
+	}
+	return cmd
+}
@@ -0,0 +1,83 @@
+package openshift
+
+import (
+	"fmt"
+
+	"github.com/openqe/openqe/pkg/common"
+	"github.com/openqe/openqe/pkg/openshift"
+	"github.com/spf13/cobra"
+	flag "github.com/spf13/pflag"
+)
+
+// NewCertManagerCommand creates the root command for cert-manager operations
+func NewCertManagerCommand(globalOpts *common.GlobalOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "cert-manager",
+		Short:        "cert-manager integration utilities",
+		SilenceUsage: true,
+	}
+
+	cmd.AddCommand(NewSetupCAIssuerCommand(globalOpts))
+
+	cmd.Run = func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	}
+	return cmd
+}
+
+func BindCertManagerOptions(opts *openshift.CertManagerOptions, flags *flag.FlagSet) {
+	BindOcpOptions(opts.OcpOpts, flags)
+	BindCAOptions(opts.CaOpts, flags)
+	flags.StringVar(&opts.Namespace, "namespace", opts.Namespace, "The namespace of the Issuer, its CA Secret and the Certificates")
+	flags.StringVar(&opts.SecretName, "secret-name", opts.SecretName, "The name of the Secret which stores the CA")
+	flags.StringVar(&opts.IssuerName, "issuer-name", opts.IssuerName, "The name of the Issuer or ClusterIssuer")
+	flags.BoolVar(&opts.ClusterIssuer, "cluster-issuer", opts.ClusterIssuer, "Create a ClusterIssuer instead of a namespaced Issuer")
+	flags.StringVar(&opts.ClusterResourceNamespace, "cluster-resource-namespace", opts.ClusterResourceNamespace, "The cluster resource namespace of cert-manager, where the CA Secret of a ClusterIssuer is stored")
+	flags.StringArrayVar(&opts.Certificates, "certificate", nil, "Certificate to create in form <name>:<dns-name>[,<dns-name>...], its Secret has the same name. You can specify multiple certificates")
+	flags.BoolVar(&opts.Wait, "wait", opts.Wait, "Wait for the issuer and the Certificates to become Ready")
+	flags.DurationVar(&opts.Timeout, "timeout", opts.Timeout, "The timeout to wait for each resource to become Ready")
+}
+
+// NewSetupCAIssuerCommand creates the command for setting up a CA issuer from an openqe CA
+func NewSetupCAIssuerCommand(globalOpts *common.GlobalOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "setup-ca-issuer",
+		Short: "Create a cert-manager CA Issuer or ClusterIssuer from an openqe CA",
+		Long: `Create a cert-manager CA Issuer or ClusterIssuer from an openqe CA generated by tls ca-gen.
+The CA is stored in a Secret with the ca.crt and ca.key keys, and the tls.crt and tls.key keys read by cert-manager.
+The Secret of a ClusterIssuer is stored in the cluster resource namespace of cert-manager.
+Certificates issued by the issuer can be created as well, and waited for until they are Ready.
+
+Examples:
+  # Create an Issuer in namespace test from ca.key and ca.crt
+  openqe openshift cert-manager setup-ca-issuer --namespace test
+
+  # Create a ClusterIssuer and a Certificate in namespace test, and wait for them to become Ready
+  openqe openshift cert-manager setup-ca-issuer --cluster-issuer --namespace test \
+    --certificate my-serving-cert:my-svc.test.svc,my-svc.test.svc.cluster.local --wait
+`,
+		SilenceUsage: true,
+	}
+
+	opts := openshift.DefaultCertManagerOptions()
+	opts.GlobalOpts = globalOpts
+	BindCertManagerOptions(opts, cmd.Flags())
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		logger := common.NewLoggerFromOptions(globalOpts, "OPENSHIFT")
+
+		if err := opts.OcpOpts.Validate(); err != nil {
+			return err
+		}
+		issuer, certs, err := openshift.SetupCAIssuer(opts)
+		if err != nil {
+			return fmt.Errorf("Failed to set up the CA issuer: %v", err)
+		}
+		logger.Info("%s %s was created.", issuer.GetKind(), issuer.GetName())
+		for _, cert := range certs {
+			logger.Info("Certificate %s/%s was created.", cert.GetNamespace(), cert.GetName())
+		}
+		return nil
+	}
+	return cmd
+}
@@ -0,0 +1,84 @@
+package openshift
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/openqe/openqe/pkg/common"
+	"github.com/openqe/openqe/pkg/openshift"
+	"github.com/openqe/openqe/pkg/tls"
+	"github.com/openqe/openqe/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+type CertReportCmdOptions struct {
+	*openshift.CertReportOptions
+	// FailWithin is a duration like 720h or a number of days like 30d
+	FailWithin string
+	Output     string
+}
+
+func NewCertReportCommand(globalOpts *common.GlobalOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "cert-report",
+		Short: "Report the certificates in the cluster sorted by the soonest expiry",
+		Long: `Report the certificates in the cluster sorted by the soonest expiry.
+The tls.crt and ca.crt keys of every kubernetes.io/tls Secret and the ca-bundle.crt key of every ConfigMap are scanned,
+and with --ca-bundles the caBundles of the webhook configurations, the CRD conversion webhooks and the APIServices too.
+The subject, issuer, SANs and expiry of each certificate are reported with the resource owning it.
+With --fail-within, the command exits with code 1 when any certificate expires within the duration,
+or when any data failed to be parsed, as the expiry of its certificates is unknown.
+
+Examples:
+  # Report the certificates of all namespaces as a table
+  openqe openshift cert-report --ca-bundles
+
+  # Fail before a soak test when a certificate in namespace test expires within 30 days
+  openqe openshift cert-report --namespace test --fail-within 30d -o json
+`,
+		SilenceUsage: true,
+	}
+
+	opts := &CertReportCmdOptions{
+		CertReportOptions: openshift.DefaultCertReportOptions(),
+		Output:            tls.OutputFormatText,
+	}
+	opts.GlobalOpts = globalOpts
+	flags := cmd.Flags()
+	BindOcpOptions(opts.OcpOpts, flags)
+	flags.StringVar(&opts.Namespace, "namespace", opts.Namespace, "The namespace of the Secrets and ConfigMaps to scan, all namespaces when not specified")
+	flags.BoolVar(&opts.CABundles, "ca-bundles", opts.CABundles, "Scan the caBundles of the webhook configurations, the CRD conversion webhooks and the APIServices too")
+	flags.StringVar(&opts.FailWithin, "fail-within", opts.FailWithin, "Fail when any certificate expires within the duration, like 720h or 30d, or when any data failed to be parsed")
+	flags.StringVarP(&opts.Output, "output", "o", opts.Output, "The output format: text or json")
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		if err := opts.OcpOpts.Validate(); err != nil {
+			return err
+		}
+		var failWithin time.Duration
+		if opts.FailWithin != "" {
+			d, err := utils.ParseDuration(opts.FailWithin)
+			if err != nil {
+				return fmt.Errorf("Invalid --fail-within: %w", err)
+			}
+			failWithin = d
+		}
+		report, err := openshift.CertificateReport(opts.CertReportOptions)
+		if err != nil {
+			return fmt.Errorf("Failed to report the certificates: %v", err)
+		}
+		if err := openshift.WriteCertReport(cmd.OutOrStdout(), report, opts.Output); err != nil {
+			return err
+		}
+		if opts.FailWithin != "" {
+			if expiring := report.ExpiringWithin(failWithin); len(expiring) > 0 {
+				return fmt.Errorf("%d certificate(s) expire within %s", len(expiring), opts.FailWithin)
+			}
+			if len(report.Errors) > 0 {
+				return fmt.Errorf("%d error(s) in the report, the expiry of their certificates is unknown", len(report.Errors))
+			}
+		}
+		return nil
+	}
+	return cmd
+}
@@ -0,0 +1,68 @@
+package openshift
+
+import (
+	"fmt"
+
+	"github.com/openqe/openqe/cmd/core"
+	"github.com/openqe/openqe/pkg/common"
+	"github.com/openqe/openqe/pkg/openshift"
+	"github.com/spf13/cobra"
+	flag "github.com/spf13/pflag"
+)
+
+func BindCSRSignerOptions(opts *openshift.CSRSignerOptions, flags *flag.FlagSet) {
+	BindOcpOptions(opts.OcpOpts, flags)
+	BindCAOptions(opts.CaOpts, flags)
+	core.BindCADBFileOption(opts.CaOpts, flags)
+	flags.StringVar(&opts.SignerName, "signer-name", opts.SignerName, "The signerName of the CertificateSigningRequests to sign, like example.com/foo")
+	flags.BoolVar(&opts.AutoApprove, "auto-approve", opts.AutoApprove, "Approve the pending requests which pass the policy of --allowed-usage and --subject-pattern")
+	flags.StringArrayVar(&opts.AllowedUsages, "allowed-usage", nil, "Usage a request may ask for to be auto-approved, like 'client auth'. Any usage is allowed when not specified. You can specify multiple usages")
+	flags.StringArrayVar(&opts.SubjectPatterns, "subject-pattern", nil, "Regular expression one of which must match the RFC 4514 subject of a request to be auto-approved, like '^CN=system:node:.*'. You can specify multiple patterns")
+	flags.DurationVar(&opts.Validity, "validity", opts.Validity, "The validity of the certificates, unless a request asks for a shorter one with expirationSeconds")
+	flags.DurationVar(&opts.Interval, "interval", opts.Interval, "The interval between the scans of the requests")
+	flags.BoolVar(&opts.Once, "once", opts.Once, "Scan the requests only once and exit")
+}
+
+func NewCSRSignerCommand(globalOpts *common.GlobalOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "csr-signer",
+		Short: "Sign the CertificateSigningRequests of a custom signerName with an openqe CA",
+		Long: `Sign the certificates.k8s.io/v1 CertificateSigningRequests of a custom signerName with an openqe CA.
+The requests of the signer are scanned every --interval. The approved ones are signed with the usages they request,
+and the certificate followed by the intermediate CAs is written to their status.certificate.
+A request which can not be signed is marked as Failed, the denied and failed requests are skipped.
+With --auto-approve, the pending requests which ask only for the allowed usages, and whose subject matches
+one of the subject patterns, are approved first. Use --once to handle the current requests and exit.
+
+Examples:
+  # Sign the approved requests of signer example.com/foo until interrupted
+  openqe openshift csr-signer --signer-name example.com/foo --ca-key-file ca.key --ca-cert-file ca.crt
+
+  # Approve and sign the client certificate requests of the current requests once
+  openqe openshift csr-signer --signer-name example.com/foo --auto-approve --once \
+    --allowed-usage 'digital signature' --allowed-usage 'client auth' --subject-pattern '^CN=app-.*'
+`,
+		SilenceUsage: true,
+	}
+
+	opts := openshift.DefaultCSRSignerOptions()
+	opts.GlobalOpts = globalOpts
+	BindCSRSignerOptions(opts, cmd.Flags())
+	cmd.MarkFlagRequired("signer-name")
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		logger := common.NewLoggerFromOptions(globalOpts, "OPENSHIFT")
+
+		if err := opts.OcpOpts.Validate(); err != nil {
+			return err
+		}
+		if !opts.Once {
+			logger.Info("Signing the CSRs of signer %s every %s", opts.SignerName, opts.Interval)
+		}
+		if err := openshift.RunCSRSigner(opts); err != nil {
+			return fmt.Errorf("Failed to sign the CSRs: %v", err)
+		}
+		return nil
+	}
+	return cmd
+}
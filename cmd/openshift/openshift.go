@@ -1,8 +1,10 @@
 package openshift
 
 import (
+	"github.com/openqe/openqe/cmd/core"
 	"github.com/openqe/openqe/pkg/common"
 	"github.com/openqe/openqe/pkg/openshift"
+	"github.com/openqe/openqe/pkg/tls"
 	"github.com/spf13/cobra"
 	flag "github.com/spf13/pflag"
 )
@@ -15,6 +17,13 @@ func BindOcpOptions(opts *openshift.OcpOptions, flags *flag.FlagSet) {
 	flags.StringVar(&opts.KUBECONFIG, "kubeconfig", opts.KUBECONFIG, "The kubeconfig file used to communicate with the OpenShift cluster")
 }
 
+// BindCAOptions binds the files of an openqe CA generated by tls ca-gen
+func BindCAOptions(opts *tls.CAOptions, flags *flag.FlagSet) {
+	flags.StringVar(&opts.CaKeyFile, "ca-key-file", opts.CaKeyFile, "The CA private key file generated by tls ca-gen.")
+	flags.StringVar(&opts.CaCertFile, "ca-cert-file", opts.CaCertFile, "The CA certificate file generated by tls ca-gen.")
+	core.BindCAKeyPassphraseOption(opts, flags)
+}
+
 func NewCommand(globalOpts *common.GlobalOptions) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:          "openshift",
@@ -26,6 +35,11 @@ func NewCommand(globalOpts *common.GlobalOptions) *cobra.Command {
 	BindOcpOptions(opts, cmd.Flags())
 	cmd.AddCommand(NewImageRegistryCommand(globalOpts))
 	cmd.AddCommand(NewDockerPullSecretCommand(globalOpts))
+	cmd.AddCommand(NewCertManagerCommand(globalOpts))
+	cmd.AddCommand(NewWebhookCertsCommand(globalOpts))
+	cmd.AddCommand(NewCSRSignerCommand(globalOpts))
+	cmd.AddCommand(NewCertReportCommand(globalOpts))
+	cmd.AddCommand(NewEnsureSignedSecretCommand(globalOpts))
 	cmd.Run = func(cmd *cobra.Command, args []string) {
 		cmd.Help()
 	}
@@ -0,0 +1,79 @@
+package openshift
+
+import (
+	"fmt"
+
+	"github.com/openqe/openqe/cmd/core"
+	"github.com/openqe/openqe/pkg/common"
+	"github.com/openqe/openqe/pkg/openshift"
+	"github.com/spf13/cobra"
+	flag "github.com/spf13/pflag"
+)
+
+func BindEnsureSignedSecretOptions(opts *openshift.EnsureSignedSecretOptions, flags *flag.FlagSet) {
+	BindOcpOptions(opts.OcpOpts, flags)
+	flags.StringVar(&opts.CASecret, "ca-secret", opts.CASecret, "The CA Secret signing the certificate in form <namespace>/<name>")
+	flags.StringVar(&opts.CACertKey, "ca-cert-key", opts.CACertKey, "The key of the CA certificate in the CA Secret")
+	flags.StringVar(&opts.CAKeyKey, "ca-key-key", opts.CAKeyKey, "The key of the CA private key in the CA Secret")
+	flags.StringVar(&opts.Namespace, "namespace", opts.Namespace, "The namespace of the TLS Secret")
+	flags.StringVar(&opts.SecretName, "secret-name", opts.SecretName, "The name of the TLS Secret")
+
+	signedOpts := opts.SignedOpts
+	core.BindSANOptions(&signedOpts.SANOptions, "", "certificate", flags)
+	flags.StringVar(&signedOpts.Subject, "subject", signedOpts.Subject, "The subject of the certificate, "+core.SubjectFormatHelp)
+	flags.StringVar(&signedOpts.KeyAlgorithm, "key-algorithm", signedOpts.KeyAlgorithm, "The private key algorithm: rsa, ecdsa or ed25519.")
+	flags.IntVar(&signedOpts.KeySize, "key-size", signedOpts.KeySize, "The private key size: RSA bits (default 2048) or ECDSA curve size: 256 (default), 384, 521. Ignored for ed25519.")
+	flags.DurationVar(&signedOpts.Validity, "validity", signedOpts.Validity, "The validity of the certificate.")
+	core.BindProfileOptions(&signedOpts.Profile, &signedOpts.ExtKeyUsages, flags)
+	flags.DurationVar(&signedOpts.MinRemaining, "min-remaining", signedOpts.MinRemaining, "The minimum remaining validity, the certificate is re-signed when its remaining validity is shorter.")
+}
+
+func NewEnsureSignedSecretCommand(globalOpts *common.GlobalOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "ensure-signed-secret",
+		Short: "Create or update a TLS Secret signed by a CA Secret, re-signing it only when needed",
+		Long: `Create or update a TLS Secret signed by a CA Secret, re-signing it only when needed.
+The TLS Secret has the tls.crt, tls.key and ca.crt keys, and the hash of the CA in the hypershiftlite.openshift.io/ca-hash annotation.
+Like HyperShift reconciles its signed Secrets, the certificate is only re-signed when the CA hash changes,
+when the certificate does not match the subject, SANs, validity and usages, or when its remaining validity
+is shorter than --min-remaining. Otherwise the Secret is left as is, so CA rotation can be exercised by running
+the command again after rotating the CA Secret.
+
+Examples:
+  # Keep the serving certificate of my-svc signed by the CA in Secret test/my-ca
+  openqe openshift ensure-signed-secret --ca-secret test/my-ca --namespace test --secret-name my-svc-tls \
+    --subject CN=my-svc.test.svc --dns-name my-svc.test.svc --dns-name my-svc.test.svc.cluster.local
+
+  # Sign with a CA stored in a TLS Secret, re-signing when less than 60 days remain
+  openqe openshift ensure-signed-secret --ca-secret test/my-ca-tls --ca-cert-key tls.crt --ca-key-key tls.key \
+    --namespace test --secret-name client-tls --profile client --min-remaining 1440h
+`,
+		SilenceUsage: true,
+	}
+
+	opts := openshift.DefaultEnsureSignedSecretOptions()
+	opts.GlobalOpts = globalOpts
+	BindEnsureSignedSecretOptions(opts, cmd.Flags())
+	cmd.MarkFlagRequired("ca-secret")
+	cmd.MarkFlagRequired("secret-name")
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		logger := common.NewLoggerFromOptions(globalOpts, "OPENSHIFT")
+		core.WarnCountryFirstSubject(logger, "subject", opts.SignedOpts.Subject)
+
+		if err := opts.OcpOpts.Validate(); err != nil {
+			return err
+		}
+		secret, changed, err := openshift.EnsureSignedSecret(opts)
+		if err != nil {
+			return fmt.Errorf("Failed to ensure the signed Secret: %v", err)
+		}
+		if changed {
+			logger.Info("Secret: %s/%s was signed by CA Secret %s.", secret.Namespace, secret.Name, opts.CASecret)
+		} else {
+			logger.Info("Secret: %s/%s is up to date.", secret.Namespace, secret.Name)
+		}
+		return nil
+	}
+	return cmd
+}
@@ -0,0 +1,68 @@
+package openshift
+
+import (
+	"fmt"
+
+	"github.com/openqe/openqe/pkg/common"
+	"github.com/openqe/openqe/pkg/openshift"
+	"github.com/spf13/cobra"
+	flag "github.com/spf13/pflag"
+)
+
+func BindWebhookCertsOptions(opts *openshift.WebhookCertsOptions, flags *flag.FlagSet) {
+	BindOcpOptions(opts.OcpOpts, flags)
+	BindCAOptions(opts.CaOpts, flags)
+	flags.StringVar(&opts.Service, "service", opts.Service, "The service of the webhook or the aggregated API server")
+	flags.StringVar(&opts.Namespace, "namespace", opts.Namespace, "The namespace of the service and the TLS Secret")
+	flags.StringVar(&opts.SecretName, "secret-name", opts.SecretName, "The name of the TLS Secret of the serving certificate, defaults to <service>-tls")
+	flags.StringArrayVar(&opts.Targets, "target", nil, "Resource whose caBundle is patched in form <kind>/<name>, kind: validatingwebhookconfiguration, mutatingwebhookconfiguration, crd or apiservice. You can specify multiple targets")
+	flags.DurationVar(&opts.Validity, "validity", opts.Validity, "The validity of the serving certificate")
+	flags.BoolVar(&opts.Rotate, "rotate", opts.Rotate, "Re-sign the serving certificate when the TLS Secret exists, and add the CA to the caBundles, which keep the old unexpired CAs")
+}
+
+func NewWebhookCertsCommand(globalOpts *common.GlobalOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "webhook-certs",
+		Short: "Set up the serving certificate of a webhook or an aggregated API service and inject its caBundle",
+		Long: `Set up the serving certificate of a webhook or an aggregated API service and inject its caBundle.
+A serving certificate for <service>.<namespace>.svc is signed by the openqe CA, the CA certificates are patched
+as the caBundle of every client config of the targets referencing the service: the webhooks of
+Validating/MutatingWebhookConfigurations, the conversion webhook of a CRD or an APIService, then the serving
+certificate is stored in a TLS Secret. Nothing is changed when a target does not reference the service.
+The TLS Secret is not overwritten unless --rotate is set, which re-signs the certificate and adds the CA certificates
+to the caBundles. The caBundles keep the old CA certificates until they expire, so the clients trust the serving
+certificates of both the old and the new CA during the rotation.
+
+Examples:
+  # Set up the serving certificate of a validating webhook
+  openqe openshift webhook-certs --service my-webhook --namespace test \
+    --target validatingwebhookconfiguration/my-webhook
+
+  # Rotate the serving certificate of a conversion webhook and an aggregated API with a new CA
+  openqe openshift webhook-certs --service my-api --namespace test --ca-key-file new-ca.key --ca-cert-file new-ca.crt \
+    --target crd/foos.example.com --target apiservice/v1.example.com --rotate
+`,
+		SilenceUsage: true,
+	}
+
+	opts := openshift.DefaultWebhookCertsOptions()
+	opts.GlobalOpts = globalOpts
+	BindWebhookCertsOptions(opts, cmd.Flags())
+	cmd.MarkFlagRequired("service")
+	cmd.MarkFlagRequired("namespace")
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		logger := common.NewLoggerFromOptions(globalOpts, "OPENSHIFT")
+
+		if err := opts.OcpOpts.Validate(); err != nil {
+			return err
+		}
+		secret, err := openshift.SetupWebhookCerts(opts)
+		if err != nil {
+			return fmt.Errorf("Failed to set up the webhook certificates: %v", err)
+		}
+		logger.Info("Serving certificate Secret: %s/%s is ready.", secret.Namespace, secret.Name)
+		return nil
+	}
+	return cmd
+}
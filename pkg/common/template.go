@@ -109,3 +109,22 @@ func GetKeyringSecret(serviceName, secretName string) (string, error) {
 
 	return secret, nil
 }
+
+// ReadSecret reads a secret from a file, or from the keyring when keyringRef is set in form of 'service,secret'
+// like the keyring template filter. The trailing line break of the file is removed.
+func ReadSecret(file, keyringRef string) (string, error) {
+	switch {
+	case file != "" && keyringRef != "":
+		return "", fmt.Errorf("only one of the secret file and the keyring reference can be specified")
+	case file != "":
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return "", fmt.Errorf("failed to read secret file: %w", err)
+		}
+		return strings.TrimRight(string(data), "\r\n"), nil
+	case keyringRef != "":
+		serviceName, secretName, _ := strings.Cut(keyringRef, ",")
+		return GetKeyringSecret(strings.TrimSpace(serviceName), strings.TrimSpace(secretName))
+	}
+	return "", fmt.Errorf("either the secret file or the keyring reference needs to be specified")
+}
@@ -0,0 +1,120 @@
+package openshift
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/openqe/openqe/pkg/tls"
+	corev1 "k8s.io/api/core/v1"
+	occlient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	KindSecret    = "secret"
+	KindConfigMap = "configmap"
+)
+
+// DefaultCertificateDataKeys are the data keys which are read when no key is specified
+var DefaultCertificateDataKeys = []string{tls.TLSSignerCertMapKey, tls.CASignerCertMapKey, tls.UserCABundleMapKey}
+
+// ParseObjectReference parses a reference in form of <namespace>/<name>
+func ParseObjectReference(ref string) (string, string, error) {
+	parts := strings.SplitN(ref, "/", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("invalid reference %q, expected <namespace>/<name>", ref)
+	}
+	return parts[0], parts[1], nil
+}
+
+// CertificateDataFromCluster reads the data of a Secret or a ConfigMap which contains certificates in PEM format.
+// The result is keyed by the data key, when keys is empty the DefaultCertificateDataKeys are read if present.
+func CertificateDataFromCluster(kubeconfig, kind, namespace, name string, keys ...string) (map[string][]byte, error) {
+	client, ctx, _, err := GetOrCreateOCClient(kubeconfig)
+	if err != nil {
+		return nil, err
+	}
+	data := map[string][]byte{}
+	switch strings.ToLower(kind) {
+	case KindSecret:
+		secret := &corev1.Secret{}
+		if err := client.Get(ctx, occlient.ObjectKey{Name: name, Namespace: namespace}, secret); err != nil {
+			return nil, err
+		}
+		for k, v := range secret.Data {
+			data[k] = v
+		}
+	case KindConfigMap:
+		cm := &corev1.ConfigMap{}
+		if err := client.Get(ctx, occlient.ObjectKey{Name: name, Namespace: namespace}, cm); err != nil {
+			return nil, err
+		}
+		for k, v := range cm.Data {
+			data[k] = []byte(v)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported kind: %s, supported: %s, %s", kind, KindSecret, KindConfigMap)
+	}
+
+	result := map[string][]byte{}
+	if len(keys) == 0 {
+		for _, k := range DefaultCertificateDataKeys {
+			if v, ok := data[k]; ok {
+				result[k] = v
+			}
+		}
+		if len(result) == 0 {
+			return nil, fmt.Errorf("%s %s/%s has none of the keys: %s", kind, namespace, name, strings.Join(DefaultCertificateDataKeys, ", "))
+		}
+		return result, nil
+	}
+	for _, k := range keys {
+		v, ok := data[k]
+		if !ok {
+			return nil, fmt.Errorf("%s %s/%s has no key: %s", kind, namespace, name, k)
+		}
+		result[k] = v
+	}
+	return result, nil
+}
+
+// EditCABundleConfigMap edits the ca-bundle.crt key of a ConfigMap with edit and updates the ConfigMap if it is changed.
+// The original bundle is written to backupFile before the update, unless backupFile is empty.
+// It returns whether the ConfigMap is changed and whether the backup is written.
+func EditCABundleConfigMap(kubeconfig, namespace, name, backupFile string, edit func(*tls.CABundle) error) (bool, bool, error) {
+	client, ctx, _, err := GetOrCreateOCClient(kubeconfig)
+	if err != nil {
+		return false, false, err
+	}
+	cm := &corev1.ConfigMap{}
+	if err := client.Get(ctx, occlient.ObjectKey{Name: name, Namespace: namespace}, cm); err != nil {
+		return false, false, err
+	}
+	data, exists := cm.Data[tls.UserCABundleMapKey]
+	bundle, err := tls.ParseCABundle([]byte(data))
+	if err != nil {
+		return false, false, fmt.Errorf("invalid %s in ConfigMap %s/%s: %w", tls.UserCABundleMapKey, namespace, name, err)
+	}
+	if err := edit(bundle); err != nil {
+		return false, false, err
+	}
+	edited := string(bundle.Bytes())
+	if edited == data {
+		return false, false, nil
+	}
+	backedUp := false
+	if backupFile != "" && exists {
+		if err := os.WriteFile(backupFile, []byte(data), 0644); err != nil {
+			return false, false, fmt.Errorf("failed to write the backup of ConfigMap %s/%s: %w", namespace, name, err)
+		}
+		backedUp = true
+	}
+	if cm.Data == nil {
+		cm.Data = map[string]string{}
+	}
+	cm.Data[tls.UserCABundleMapKey] = edited
+	if err := client.Update(ctx, cm); err != nil {
+		return false, backedUp, err
+	}
+	return true, backedUp, nil
+}
@@ -0,0 +1,198 @@
+package openshift
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/openqe/openqe/pkg/tls"
+	"github.com/openqe/openqe/pkg/utils"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	occlient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// The cert-manager resources are handled as unstructured objects, so the scheme of the client needs no cert-manager types
+const (
+	CertManagerGroup      = "cert-manager.io"
+	CertManagerAPIVersion = CertManagerGroup + "/v1"
+
+	KindIssuer        = "Issuer"
+	KindClusterIssuer = "ClusterIssuer"
+	KindCertificate   = "Certificate"
+)
+
+// NewCertManagerIssuer makes a CA Issuer, or a ClusterIssuer when cluster is true, which signs with the CA in the Secret
+func NewCertManagerIssuer(namespace, name, secretName string, cluster bool) *unstructured.Unstructured {
+	issuer := &unstructured.Unstructured{Object: map[string]any{
+		"spec": map[string]any{
+			"ca": map[string]any{
+				"secretName": secretName,
+			},
+		},
+	}}
+	issuer.SetAPIVersion(CertManagerAPIVersion)
+	issuer.SetKind(KindIssuer)
+	issuer.SetName(name)
+	if cluster {
+		issuer.SetKind(KindClusterIssuer)
+	} else {
+		issuer.SetNamespace(namespace)
+	}
+	return issuer
+}
+
+// NewCertManagerCertificate makes a Certificate issued by the issuer of issuerKind, its key/cert pair is stored in
+// the Secret with the same name as the Certificate
+func NewCertManagerCertificate(namespace, name, issuerName, issuerKind string, dnsNames []string) *unstructured.Unstructured {
+	names := make([]any, 0, len(dnsNames))
+	for _, dnsName := range dnsNames {
+		names = append(names, dnsName)
+	}
+	cert := &unstructured.Unstructured{Object: map[string]any{
+		"spec": map[string]any{
+			"secretName": name,
+			"dnsNames":   names,
+			"issuerRef": map[string]any{
+				"name":  issuerName,
+				"kind":  issuerKind,
+				"group": CertManagerGroup,
+			},
+		},
+	}}
+	cert.SetAPIVersion(CertManagerAPIVersion)
+	cert.SetKind(KindCertificate)
+	cert.SetNamespace(namespace)
+	cert.SetName(name)
+	return cert
+}
+
+// ParseCertManagerCertificate parses a Certificate in form of <name>:<dns-name>[,<dns-name>...]
+func ParseCertManagerCertificate(spec string) (string, []string, error) {
+	name, dnsNames, found := strings.Cut(spec, ":")
+	name = strings.TrimSpace(name)
+	if !found || name == "" {
+		return "", nil, fmt.Errorf("invalid certificate %q, expected <name>:<dns-name>[,<dns-name>...]", spec)
+	}
+	var names []string
+	for _, dnsName := range strings.Split(dnsNames, ",") {
+		if dnsName = strings.TrimSpace(dnsName); dnsName != "" {
+			names = append(names, dnsName)
+		}
+	}
+	if len(names) == 0 {
+		return "", nil, fmt.Errorf("invalid certificate %q, at least one DNS name is required", spec)
+	}
+	return name, names, nil
+}
+
+// SetupCAIssuer stores the CA of the options in a Secret and creates a cert-manager CA Issuer or ClusterIssuer with it,
+// then creates the Certificates of the options issued by it. The Secret has the ca.crt and ca.key keys of an openqe CA,
+// and the tls.crt and tls.key keys which the CA issuer of cert-manager reads. The CA private key is stored unencrypted,
+// as cert-manager can not decrypt it. When opts.Wait is set, it waits for the issuer and the Certificates to become Ready.
+// It returns the issuer and the Certificates.
+func SetupCAIssuer(opts *CertManagerOptions) (*unstructured.Unstructured, []*unstructured.Unstructured, error) {
+	_, _, log, err := GetOrCreateOCClient(opts.OcpOpts.KUBECONFIG)
+	if err != nil {
+		return nil, nil, err
+	}
+	var certs []*unstructured.Unstructured
+	issuerKind := KindIssuer
+	if opts.ClusterIssuer {
+		issuerKind = KindClusterIssuer
+	}
+	for _, spec := range opts.Certificates {
+		name, dnsNames, err := ParseCertManagerCertificate(spec)
+		if err != nil {
+			return nil, nil, err
+		}
+		certs = append(certs, NewCertManagerCertificate(opts.Namespace, name, opts.IssuerName, issuerKind, dnsNames))
+	}
+
+	caKey, caCerts, err := opts.CaOpts.LoadCA()
+	if err != nil {
+		return nil, nil, err
+	}
+	keyPEM, err := tls.PrivateKeyToPem(caKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	certPEM := tls.CertsToPem(caCerts)
+	secretNamespace := opts.Namespace
+	if opts.ClusterIssuer {
+		// the CA Secret of a ClusterIssuer is read from the cluster resource namespace of cert-manager
+		secretNamespace = opts.ClusterResourceNamespace
+	}
+	secret := tls.NewTLSSecret(metav1.ObjectMeta{Name: opts.SecretName, Namespace: secretNamespace}, keyPEM, certPEM, certPEM)
+	secret.Data[tls.CASignerKeyMapKey] = keyPEM
+	if err := ApplyObjects(opts.OcpOpts.KUBECONFIG, secret); err != nil {
+		return nil, nil, err
+	}
+	log.Info("CA Secret %s/%s is ready\n", secretNamespace, opts.SecretName)
+
+	issuer := NewCertManagerIssuer(opts.Namespace, opts.IssuerName, opts.SecretName, opts.ClusterIssuer)
+	objs := []occlient.Object{issuer}
+	for _, cert := range certs {
+		objs = append(objs, cert)
+	}
+	if err := ApplyObjects(opts.OcpOpts.KUBECONFIG, objs...); err != nil {
+		if meta.IsNoMatchError(err) {
+			return nil, nil, fmt.Errorf("cert-manager is not installed in the cluster: %w", err)
+		}
+		return nil, nil, err
+	}
+	if !opts.Wait {
+		return issuer, certs, nil
+	}
+	for _, obj := range append([]*unstructured.Unstructured{issuer}, certs...) {
+		if err := WaitForReadyCondition(opts.OcpOpts.KUBECONFIG, obj, opts.Timeout); err != nil {
+			return nil, nil, err
+		}
+		log.Info("%s %s is Ready\n", obj.GetKind(), obj.GetName())
+	}
+	return issuer, certs, nil
+}
+
+// WaitForReadyCondition waits until the Ready condition in the status of the object becomes True, like the
+// conditions of the cert-manager resources. The object is updated to the last one read from the cluster.
+func WaitForReadyCondition(kubeconfig string, obj *unstructured.Unstructured, timeout time.Duration) error {
+	client, ctx, _, err := GetOrCreateOCClient(kubeconfig)
+	if err != nil {
+		return err
+	}
+	var message string
+	_, err = utils.Eventually(
+		func() (bool, error) {
+			if err := client.Get(ctx, occlient.ObjectKeyFromObject(obj), obj); err != nil {
+				return false, err
+			}
+			var ready bool
+			ready, message = readyCondition(obj)
+			return ready, nil
+		},
+		func(ready bool) bool {
+			return ready
+		},
+		utils.ShortInterval,
+		timeout,
+	)
+	if err != nil {
+		return fmt.Errorf("%s %s is not Ready: %w, last message: %s", obj.GetKind(), occlient.ObjectKeyFromObject(obj), err, message)
+	}
+	return nil
+}
+
+// readyCondition returns whether the Ready condition of the object is True, and its message
+func readyCondition(obj *unstructured.Unstructured) (bool, string) {
+	conditions, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	for _, c := range conditions {
+		condition, ok := c.(map[string]any)
+		if !ok || condition["type"] != "Ready" {
+			continue
+		}
+		message, _ := condition["message"].(string)
+		return condition["status"] == string(metav1.ConditionTrue), message
+	}
+	return false, "no Ready condition yet"
+}
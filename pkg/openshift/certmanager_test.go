@@ -0,0 +1,43 @@
+package openshift
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestParseCertManagerCertificate(t *testing.T) {
+	name, dnsNames, err := ParseCertManagerCertificate(" my-cert : a.example.com, ,b.example.com")
+	require.NoError(t, err)
+	assert.Equal(t, "my-cert", name)
+	assert.Equal(t, []string{"a.example.com", "b.example.com"}, dnsNames)
+
+	for _, spec := range []string{"my-cert", ":a.example.com", "my-cert:", "my-cert: , "} {
+		_, _, err := ParseCertManagerCertificate(spec)
+		assert.Error(t, err, spec)
+	}
+}
+
+func TestReadyCondition(t *testing.T) {
+	obj := &unstructured.Unstructured{Object: map[string]any{}}
+	ready, message := readyCondition(obj)
+	assert.False(t, ready)
+	assert.Equal(t, "no Ready condition yet", message)
+
+	setConditions := func(conditions ...any) {
+		require.NoError(t, unstructured.SetNestedSlice(obj.Object, conditions, "status", "conditions"))
+	}
+	setConditions(
+		map[string]any{"type": "Issuing", "status": "True"},
+		map[string]any{"type": "Ready", "status": "False", "message": "Issuing certificate as Secret does not exist"},
+	)
+	ready, message = readyCondition(obj)
+	assert.False(t, ready)
+	assert.Equal(t, "Issuing certificate as Secret does not exist", message)
+
+	setConditions(map[string]any{"type": "Ready", "status": "True", "message": "Certificate is up to date and has not expired"})
+	ready, _ = readyCondition(obj)
+	assert.True(t, ready)
+}
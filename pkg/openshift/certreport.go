@@ -0,0 +1,179 @@
+package openshift
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/openqe/openqe/pkg/tls"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	occlient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// CertReportEntry is a certificate found in the cluster and the resource owning it
+type CertReportEntry struct {
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+	// Key is the data key of a Secret or a ConfigMap, or the field of a caBundle
+	Key         string                 `json:"key"`
+	Certificate tls.CertificateSummary `json:"certificate"`
+}
+
+// CertReport is the certificates found in the cluster sorted by the soonest expiry, and the data failed to be parsed
+type CertReport struct {
+	Entries []CertReportEntry `json:"certificates"`
+	Errors  []string          `json:"errors,omitempty"`
+}
+
+// ExpiringWithin returns the entries of the certificates which expire within d from now
+func (r *CertReport) ExpiringWithin(d time.Duration) []CertReportEntry {
+	deadline := time.Now().Add(d)
+	var entries []CertReportEntry
+	for _, e := range r.Entries {
+		if e.Certificate.NotAfter.Before(deadline) {
+			entries = append(entries, e)
+		}
+	}
+	return entries
+}
+
+// add adds the certificates of the PEM data owned by the resource, or the error of parsing them
+func (r *CertReport) add(kind, namespace, name, key string, data []byte) {
+	summaries, err := tls.SummarizeCertificates(data, "")
+	if err != nil {
+		owner := name
+		if namespace != "" {
+			owner = namespace + "/" + name
+		}
+		r.Errors = append(r.Errors, fmt.Sprintf("%s %s %s: %v", kind, owner, key, err))
+		return
+	}
+	for _, s := range summaries {
+		r.Entries = append(r.Entries, CertReportEntry{Kind: kind, Namespace: namespace, Name: name, Key: key, Certificate: s})
+	}
+}
+
+// CertificateReport scans the certificates in the tls.crt and ca.crt keys of every kubernetes.io/tls Secret, and in
+// the ca-bundle.crt key of every ConfigMap, in opts.Namespace or in all namespaces. With opts.CABundles, the caBundles
+// of the Validating/MutatingWebhookConfigurations, the CRD conversion webhooks and the APIServices are scanned as well.
+// The certificates are sorted by the soonest expiry.
+func CertificateReport(opts *CertReportOptions) (*CertReport, error) {
+	client, ctx, _, err := GetOrCreateOCClient(opts.OcpOpts.KUBECONFIG)
+	if err != nil {
+		return nil, err
+	}
+	report := &CertReport{}
+	secrets := &corev1.SecretList{}
+	if err := client.List(ctx, secrets, occlient.InNamespace(opts.Namespace), occlient.MatchingFields{"type": string(corev1.SecretTypeTLS)}); err != nil {
+		return nil, fmt.Errorf("failed to list the TLS Secrets: %w", err)
+	}
+	for _, secret := range secrets.Items {
+		for _, key := range []string{tls.TLSSignerCertMapKey, tls.CASignerCertMapKey} {
+			if data, ok := secret.Data[key]; ok && len(data) > 0 {
+				report.add("Secret", secret.Namespace, secret.Name, key, data)
+			}
+		}
+	}
+	configMaps := &corev1.ConfigMapList{}
+	if err := client.List(ctx, configMaps, occlient.InNamespace(opts.Namespace)); err != nil {
+		return nil, fmt.Errorf("failed to list the ConfigMaps: %w", err)
+	}
+	for _, cm := range configMaps.Items {
+		if data, ok := cm.Data[tls.UserCABundleMapKey]; ok && data != "" {
+			report.add("ConfigMap", cm.Namespace, cm.Name, tls.UserCABundleMapKey, []byte(data))
+		}
+	}
+	if opts.CABundles {
+		for _, kind := range []string{targetValidatingWebhookConfiguration, targetMutatingWebhookConfiguration, targetCustomResourceDefinition, targetAPIService} {
+			gvk := caBundleTargetKinds[kind]
+			list := &unstructured.UnstructuredList{}
+			list.SetGroupVersionKind(gvk.GroupVersion().WithKind(gvk.Kind + "List"))
+			if err := client.List(ctx, list); err != nil {
+				return nil, fmt.Errorf("failed to list the %ss: %w", gvk.Kind, err)
+			}
+			for _, obj := range list.Items {
+				for _, field := range caBundleFields(&obj) {
+					data, err := base64.StdEncoding.DecodeString(field.value)
+					if err != nil {
+						report.Errors = append(report.Errors, fmt.Sprintf("%s %s %s: %v", gvk.Kind, obj.GetName(), field.path, err))
+						continue
+					}
+					report.add(gvk.Kind, "", obj.GetName(), field.path, data)
+				}
+			}
+		}
+	}
+	slices.SortStableFunc(report.Entries, func(a, b CertReportEntry) int {
+		return a.Certificate.NotAfter.Compare(b.Certificate.NotAfter)
+	})
+	return report, nil
+}
+
+type caBundleField struct {
+	path  string
+	value string
+}
+
+// caBundleFields returns the non-empty caBundles of a webhook configuration, a CRD or an APIService
+func caBundleFields(obj *unstructured.Unstructured) []caBundleField {
+	var fields []caBundleField
+	switch obj.GetKind() {
+	case "ValidatingWebhookConfiguration", "MutatingWebhookConfiguration":
+		webhooks, _, _ := unstructured.NestedSlice(obj.Object, "webhooks")
+		for _, w := range webhooks {
+			webhook, ok := w.(map[string]any)
+			if !ok {
+				continue
+			}
+			name, _, _ := unstructured.NestedString(webhook, "name")
+			if caBundle, _, _ := unstructured.NestedString(webhook, "clientConfig", "caBundle"); caBundle != "" {
+				fields = append(fields, caBundleField{path: fmt.Sprintf("webhooks[%s].clientConfig.caBundle", name), value: caBundle})
+			}
+		}
+	case "CustomResourceDefinition":
+		if caBundle, _, _ := unstructured.NestedString(obj.Object, "spec", "conversion", "webhook", "clientConfig", "caBundle"); caBundle != "" {
+			fields = append(fields, caBundleField{path: "spec.conversion.webhook.clientConfig.caBundle", value: caBundle})
+		}
+	case "APIService":
+		if caBundle, _, _ := unstructured.NestedString(obj.Object, "spec", "caBundle"); caBundle != "" {
+			fields = append(fields, caBundleField{path: "spec.caBundle", value: caBundle})
+		}
+	}
+	return fields
+}
+
+// WriteCertReport writes the report to w in the output format: text as a table, or json
+func WriteCertReport(w io.Writer, report *CertReport, format string) error {
+	switch format {
+	case tls.OutputFormatJSON:
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(data))
+		return err
+	case "", tls.OutputFormatText:
+		tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "NOT AFTER\tDAYS\tKIND\tNAMESPACE\tNAME\tKEY\tSUBJECT\tISSUER\tSANS")
+		for _, e := range report.Entries {
+			c := e.Certificate
+			fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", c.NotAfter.UTC().Format(time.RFC3339), c.DaysRemaining,
+				e.Kind, e.Namespace, e.Name, e.Key, c.Subject, c.Issuer, strings.Join(c.SANs(), ","))
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+		for _, e := range report.Errors {
+			fmt.Fprintf(w, "error: %s\n", e)
+		}
+		return nil
+	}
+	return fmt.Errorf("unsupported output format: %s, supported: %s, %s", format, tls.OutputFormatText, tls.OutputFormatJSON)
+}
@@ -0,0 +1,105 @@
+package openshift
+
+import (
+	"encoding/base64"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/openqe/openqe/pkg/tls"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	occlient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestCABundleFields(t *testing.T) {
+	obj := &unstructured.Unstructured{}
+	obj.SetKind("MutatingWebhookConfiguration")
+	require.NoError(t, unstructured.SetNestedSlice(obj.Object, []any{
+		map[string]any{"name": "a.example.com", "clientConfig": map[string]any{"caBundle": "YQ=="}},
+		map[string]any{"name": "b.example.com", "clientConfig": map[string]any{}},
+	}, "webhooks"))
+	assert.Equal(t, []caBundleField{{path: "webhooks[a.example.com].clientConfig.caBundle", value: "YQ=="}}, caBundleFields(obj))
+
+	obj = &unstructured.Unstructured{}
+	obj.SetKind("CustomResourceDefinition")
+	require.NoError(t, unstructured.SetNestedField(obj.Object, "Yg==", "spec", "conversion", "webhook", "clientConfig", "caBundle"))
+	assert.Equal(t, []caBundleField{{path: "spec.conversion.webhook.clientConfig.caBundle", value: "Yg=="}}, caBundleFields(obj))
+
+	obj = &unstructured.Unstructured{}
+	obj.SetKind("APIService")
+	assert.Empty(t, caBundleFields(obj))
+	require.NoError(t, unstructured.SetNestedField(obj.Object, "Yw==", "spec", "caBundle"))
+	assert.Equal(t, []caBundleField{{path: "spec.caBundle", value: "Yw=="}}, caBundleFields(obj))
+}
+
+func TestCertificateReport(t *testing.T) {
+	dir := t.TempDir()
+	newCert := func(name string, validity time.Duration) []byte {
+		ca := tls.DefaultCAOptions()
+		ca.Subject = "CN=" + name + ", OU=openqe"
+		ca.Validity = validity
+		ca.CaKeyFile = filepath.Join(dir, name+".key")
+		ca.CaCertFile = filepath.Join(dir, name+".crt")
+		_, chain, err := tls.GenerateCAChain(ca)
+		require.NoError(t, err)
+		return tls.CertsToPem(chain)
+	}
+	secret := func(name string, secretType corev1.SecretType, cert []byte) *corev1.Secret {
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "test"},
+			Type:       secretType,
+			Data:       map[string][]byte{tls.TLSSignerCertMapKey: cert},
+		}
+	}
+	apiService := &unstructured.Unstructured{}
+	gvk, _, err := ParseCABundleTarget("apiservice/v1.example.com")
+	require.NoError(t, err)
+	apiService.SetGroupVersionKind(gvk)
+	apiService.SetName("v1.example.com")
+	require.NoError(t, unstructured.SetNestedField(apiService.Object, base64.StdEncoding.EncodeToString(newCert("api", 48*time.Hour)), "spec", "caBundle"))
+
+	client := fake.NewClientBuilder().
+		WithObjects(
+			secret("year", corev1.SecretTypeTLS, newCert("year", 365*24*time.Hour)),
+			secret("day", corev1.SecretTypeTLS, newCert("day", 24*time.Hour)),
+			secret("opaque", corev1.SecretTypeOpaque, newCert("opaque", time.Hour)),
+			secret("invalid", corev1.SecretTypeTLS, []byte("not a certificate")),
+			&corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: "bundle", Namespace: "test"},
+				Data:       map[string]string{tls.UserCABundleMapKey: string(newCert("month", 30*24*time.Hour))},
+			},
+			apiService).
+		WithIndex(&corev1.Secret{}, "type", func(obj occlient.Object) []string {
+			return []string{string(obj.(*corev1.Secret).Type)}
+		}).
+		Build()
+	cacheClient(t, "cert-report", client)
+
+	opts := DefaultCertReportOptions()
+	opts.OcpOpts.KUBECONFIG = "cert-report"
+	report, err := CertificateReport(opts)
+	require.NoError(t, err)
+	names := func(entries []CertReportEntry) []string {
+		var names []string
+		for _, e := range entries {
+			names = append(names, e.Kind+"/"+e.Name)
+		}
+		return names
+	}
+	assert.Equal(t, []string{"Secret/day", "ConfigMap/bundle", "Secret/year"}, names(report.Entries), "sorted by the soonest expiry")
+	require.Len(t, report.Errors, 1)
+	assert.Contains(t, report.Errors[0], "Secret test/invalid tls.crt")
+	assert.Equal(t, []string{"Secret/day"}, names(report.ExpiringWithin(7*24*time.Hour)))
+	assert.Empty(t, report.ExpiringWithin(time.Hour))
+
+	opts.CABundles = true
+	report, err = CertificateReport(opts)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Secret/day", "APIService/v1.example.com", "ConfigMap/bundle", "Secret/year"}, names(report.Entries))
+	assert.Equal(t, "spec.caBundle", report.Entries[1].Key)
+}
@@ -0,0 +1,283 @@
+package openshift
+
+import (
+	"crypto"
+	"crypto/x509"
+	"fmt"
+	"regexp"
+	"slices"
+	"time"
+
+	"github.com/openqe/openqe/pkg/tls"
+	certificatesv1 "k8s.io/api/certificates/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+)
+
+// csrKeyUsages are the key usages of the CertificateSigningRequest usages
+var csrKeyUsages = map[certificatesv1.KeyUsage]x509.KeyUsage{
+	certificatesv1.UsageSigning:           x509.KeyUsageDigitalSignature,
+	certificatesv1.UsageDigitalSignature:  x509.KeyUsageDigitalSignature,
+	certificatesv1.UsageContentCommitment: x509.KeyUsageContentCommitment,
+	certificatesv1.UsageKeyEncipherment:   x509.KeyUsageKeyEncipherment,
+	certificatesv1.UsageKeyAgreement:      x509.KeyUsageKeyAgreement,
+	certificatesv1.UsageDataEncipherment:  x509.KeyUsageDataEncipherment,
+	certificatesv1.UsageCertSign:          x509.KeyUsageCertSign,
+	certificatesv1.UsageCRLSign:           x509.KeyUsageCRLSign,
+	certificatesv1.UsageEncipherOnly:      x509.KeyUsageEncipherOnly,
+	certificatesv1.UsageDecipherOnly:      x509.KeyUsageDecipherOnly,
+}
+
+// csrExtKeyUsages are the extended key usages of the CertificateSigningRequest usages
+var csrExtKeyUsages = map[certificatesv1.KeyUsage]x509.ExtKeyUsage{
+	certificatesv1.UsageAny:             x509.ExtKeyUsageAny,
+	certificatesv1.UsageServerAuth:      x509.ExtKeyUsageServerAuth,
+	certificatesv1.UsageClientAuth:      x509.ExtKeyUsageClientAuth,
+	certificatesv1.UsageCodeSigning:     x509.ExtKeyUsageCodeSigning,
+	certificatesv1.UsageEmailProtection: x509.ExtKeyUsageEmailProtection,
+	certificatesv1.UsageSMIME:           x509.ExtKeyUsageEmailProtection,
+	certificatesv1.UsageIPsecEndSystem:  x509.ExtKeyUsageIPSECEndSystem,
+	certificatesv1.UsageIPsecTunnel:     x509.ExtKeyUsageIPSECTunnel,
+	certificatesv1.UsageIPsecUser:       x509.ExtKeyUsageIPSECUser,
+	certificatesv1.UsageTimestamping:    x509.ExtKeyUsageTimeStamping,
+	certificatesv1.UsageOCSPSigning:     x509.ExtKeyUsageOCSPSigning,
+	certificatesv1.UsageMicrosoftSGC:    x509.ExtKeyUsageMicrosoftServerGatedCrypto,
+	certificatesv1.UsageNetscapeSGC:     x509.ExtKeyUsageNetscapeServerGatedCrypto,
+}
+
+// csrUsages converts the usages of a CertificateSigningRequest to the key usages and the extended key usages
+func csrUsages(usages []certificatesv1.KeyUsage) (x509.KeyUsage, []x509.ExtKeyUsage, error) {
+	var keyUsages x509.KeyUsage
+	var extKeyUsages []x509.ExtKeyUsage
+	for _, usage := range usages {
+		if ku, ok := csrKeyUsages[usage]; ok {
+			keyUsages |= ku
+		} else if eku, ok := csrExtKeyUsages[usage]; ok {
+			if !slices.Contains(extKeyUsages, eku) {
+				extKeyUsages = append(extKeyUsages, eku)
+			}
+		} else {
+			return 0, nil, fmt.Errorf("unknown usage: %s", usage)
+		}
+	}
+	return keyUsages, extKeyUsages, nil
+}
+
+// csrPolicy decides whether a pending CertificateSigningRequest is approved
+type csrPolicy struct {
+	allowedUsages []certificatesv1.KeyUsage
+	subjects      []*regexp.Regexp
+}
+
+func (o *CSRSignerOptions) policy() (*csrPolicy, error) {
+	p := &csrPolicy{}
+	for _, usage := range o.AllowedUsages {
+		if _, _, err := csrUsages([]certificatesv1.KeyUsage{certificatesv1.KeyUsage(usage)}); err != nil {
+			return nil, err
+		}
+		p.allowedUsages = append(p.allowedUsages, certificatesv1.KeyUsage(usage))
+	}
+	for _, pattern := range o.SubjectPatterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid subject pattern %q: %w", pattern, err)
+		}
+		p.subjects = append(p.subjects, re)
+	}
+	return p, nil
+}
+
+// check returns why the request is not approved by the policy, or nil when it is approved
+func (p *csrPolicy) check(csr *certificatesv1.CertificateSigningRequest, req *x509.CertificateRequest) error {
+	if len(p.allowedUsages) > 0 {
+		for _, usage := range csr.Spec.Usages {
+			if !slices.Contains(p.allowedUsages, usage) {
+				return fmt.Errorf("usage %q is not allowed", usage)
+			}
+		}
+	}
+	if len(p.subjects) > 0 {
+		subject := req.Subject.String()
+		if !slices.ContainsFunc(p.subjects, func(re *regexp.Regexp) bool { return re.MatchString(subject) }) {
+			return fmt.Errorf("subject %q matches none of the subject patterns", subject)
+		}
+	}
+	return nil
+}
+
+// csrCondition returns the condition of the type in the status of the request, or nil
+func csrCondition(csr *certificatesv1.CertificateSigningRequest, conditionType certificatesv1.RequestConditionType) *certificatesv1.CertificateSigningRequestCondition {
+	for i := range csr.Status.Conditions {
+		if csr.Status.Conditions[i].Type == conditionType {
+			return &csr.Status.Conditions[i]
+		}
+	}
+	return nil
+}
+
+// csrSigner signs the CertificateSigningRequests of the signer of the options with the CA, which is loaded once
+type csrSigner struct {
+	opts    *CSRSignerOptions
+	policy  *csrPolicy
+	caKey   crypto.Signer
+	caChain []*x509.Certificate
+}
+
+func newCSRSigner(opts *CSRSignerOptions) (*csrSigner, error) {
+	policy, err := opts.policy()
+	if err != nil {
+		return nil, err
+	}
+	caKey, caChain, err := opts.CaOpts.LoadCA()
+	if err != nil {
+		return nil, err
+	}
+	return &csrSigner{opts: opts, policy: policy, caKey: caKey, caChain: caChain}, nil
+}
+
+// SignCSRs scans the CertificateSigningRequests of the signer once. The pending requests are approved when
+// opts.AutoApprove is set and they pass the policy of the options, the approved requests are signed by the CA
+// and their certificates are written to status.certificate. A request which can not be signed is marked as Failed.
+// It returns the names of the signed requests, and the errors of the requests which failed to be handled.
+func SignCSRs(opts *CSRSignerOptions) ([]string, error) {
+	signer, err := newCSRSigner(opts)
+	if err != nil {
+		return nil, err
+	}
+	return signer.signCSRs()
+}
+
+func (s *csrSigner) signCSRs() ([]string, error) {
+	opts := s.opts
+	client, ctx, log, err := GetOrCreateOCClient(opts.OcpOpts.KUBECONFIG)
+	if err != nil {
+		return nil, err
+	}
+	list := &certificatesv1.CertificateSigningRequestList{}
+	if err := client.List(ctx, list); err != nil {
+		return nil, err
+	}
+	var signed []string
+	var errs []error
+	for i := range list.Items {
+		csr := &list.Items[i]
+		if csr.Spec.SignerName != opts.SignerName || len(csr.Status.Certificate) > 0 ||
+			csrCondition(csr, certificatesv1.CertificateDenied) != nil || csrCondition(csr, certificatesv1.CertificateFailed) != nil {
+			continue
+		}
+		req, parseErr := tls.PemToCSR(csr.Spec.Request)
+		if csrCondition(csr, certificatesv1.CertificateApproved) == nil {
+			if !opts.AutoApprove {
+				continue
+			}
+			if parseErr != nil {
+				log.Info("CSR %s is not approved: invalid certificate request: %v\n", csr.Name, parseErr)
+				continue
+			}
+			if err := s.policy.check(csr, req); err != nil {
+				log.Info("CSR %s is not approved: %v\n", csr.Name, err)
+				continue
+			}
+			csr.Status.Conditions = append(csr.Status.Conditions, certificatesv1.CertificateSigningRequestCondition{
+				Type:           certificatesv1.CertificateApproved,
+				Status:         corev1.ConditionTrue,
+				Reason:         "AutoApproved",
+				Message:        "approved by the openqe csr-signer policy",
+				LastUpdateTime: metav1.Now(),
+			})
+			if err := client.SubResource("approval").Update(ctx, csr); err != nil {
+				errs = append(errs, fmt.Errorf("failed to approve CSR %s: %w", csr.Name, err))
+				continue
+			}
+			log.Info("CSR %s approved\n", csr.Name)
+		}
+
+		certPEM, signErr := s.signCSR(csr, req, parseErr)
+		if signErr != nil {
+			csr.Status.Conditions = append(csr.Status.Conditions, certificatesv1.CertificateSigningRequestCondition{
+				Type:           certificatesv1.CertificateFailed,
+				Status:         corev1.ConditionTrue,
+				Reason:         "SigningError",
+				Message:        signErr.Error(),
+				LastUpdateTime: metav1.Now(),
+			})
+		} else {
+			csr.Status.Certificate = certPEM
+		}
+		if err := client.Status().Update(ctx, csr); err != nil {
+			errs = append(errs, fmt.Errorf("failed to update the status of CSR %s: %w", csr.Name, err))
+			continue
+		}
+		if signErr != nil {
+			log.Info("CSR %s failed to be signed: %v\n", csr.Name, signErr)
+			continue
+		}
+		log.Info("CSR %s signed\n", csr.Name)
+		signed = append(signed, csr.Name)
+	}
+	return signed, utilerrors.NewAggregate(errs)
+}
+
+// signCSR signs the certificate request of the CSR with the CA for the usages of the CSR, and returns the certificate
+// followed by the intermediate CAs in PEM format. The certificate is recorded in the CA database when it is set.
+func (s *csrSigner) signCSR(csr *certificatesv1.CertificateSigningRequest, req *x509.CertificateRequest, parseErr error) ([]byte, error) {
+	if parseErr != nil {
+		return nil, fmt.Errorf("invalid certificate request: %w", parseErr)
+	}
+	keyUsages, extKeyUsages, err := csrUsages(csr.Spec.Usages)
+	if err != nil {
+		return nil, err
+	}
+	validity := s.opts.Validity
+	if csr.Spec.ExpirationSeconds != nil {
+		if requested := time.Duration(*csr.Spec.ExpirationSeconds) * time.Second; requested < validity {
+			validity = requested
+		}
+	}
+	cfg := &tls.CertCfg{
+		KeyUsages:    keyUsages,
+		ExtKeyUsages: extKeyUsages,
+		Validity:     validity,
+	}
+	cert, err := tls.SignCSR(req, s.caKey, s.caChain[0], cfg)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.opts.CaOpts.RecordIssued(cert); err != nil {
+		return nil, err
+	}
+	certPEM := tls.CertToPem(cert)
+	for _, c := range s.caChain {
+		if !tls.IsSelfSigned(c) {
+			certPEM = append(certPEM, tls.CertToPem(c)...)
+		}
+	}
+	return certPEM, nil
+}
+
+// RunCSRSigner runs SignCSRs every opts.Interval until it is stopped, or only once when opts.Once is set.
+// The CA is loaded once. The errors of a scan are logged and the next scan goes on, unless it runs once.
+func RunCSRSigner(opts *CSRSignerOptions) error {
+	_, _, log, err := GetOrCreateOCClient(opts.OcpOpts.KUBECONFIG)
+	if err != nil {
+		return err
+	}
+	signer, err := newCSRSigner(opts)
+	if err != nil {
+		return err
+	}
+	for {
+		signed, err := signer.signCSRs()
+		if opts.Once {
+			if err == nil {
+				log.Info("%d CSR(s) of signer %s signed\n", len(signed), opts.SignerName)
+			}
+			return err
+		}
+		if err != nil {
+			log.Error(err, "failed to sign the CSRs", "signer", opts.SignerName)
+		}
+		time.Sleep(opts.Interval)
+	}
+}
@@ -0,0 +1,107 @@
+package openshift
+
+import (
+	"context"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"path/filepath"
+	"testing"
+
+	"github.com/openqe/openqe/pkg/tls"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	certificatesv1 "k8s.io/api/certificates/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	occlient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestCSRUsages(t *testing.T) {
+	keyUsages, extKeyUsages, err := csrUsages([]certificatesv1.KeyUsage{
+		certificatesv1.UsageDigitalSignature, certificatesv1.UsageKeyEncipherment,
+		certificatesv1.UsageClientAuth, certificatesv1.UsageEmailProtection, certificatesv1.UsageSMIME,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, x509.KeyUsageDigitalSignature|x509.KeyUsageKeyEncipherment, keyUsages)
+	assert.Equal(t, []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth, x509.ExtKeyUsageEmailProtection}, extKeyUsages)
+
+	_, _, err = csrUsages([]certificatesv1.KeyUsage{"not a usage"})
+	assert.Error(t, err)
+}
+
+func TestCSRPolicy(t *testing.T) {
+	opts := DefaultCSRSignerOptions()
+	opts.AllowedUsages = []string{"digital signature", "client auth"}
+	opts.SubjectPatterns = []string{"^CN=system:node:.*"}
+	policy, err := opts.policy()
+	require.NoError(t, err)
+
+	csr := &certificatesv1.CertificateSigningRequest{Spec: certificatesv1.CertificateSigningRequestSpec{
+		Usages: []certificatesv1.KeyUsage{certificatesv1.UsageDigitalSignature, certificatesv1.UsageClientAuth},
+	}}
+	req := &x509.CertificateRequest{Subject: pkix.Name{CommonName: "system:node:worker-0", Organization: []string{"system:nodes"}}}
+	assert.NoError(t, policy.check(csr, req))
+
+	req.Subject = pkix.Name{CommonName: "admin"}
+	assert.ErrorContains(t, policy.check(csr, req), "matches none of the subject patterns")
+
+	req.Subject = pkix.Name{CommonName: "system:node:worker-0"}
+	csr.Spec.Usages = append(csr.Spec.Usages, certificatesv1.UsageServerAuth)
+	assert.ErrorContains(t, policy.check(csr, req), "server auth")
+
+	opts.AllowedUsages = []string{"not a usage"}
+	_, err = opts.policy()
+	assert.Error(t, err)
+	opts.AllowedUsages = nil
+	opts.SubjectPatterns = []string{"("}
+	_, err = opts.policy()
+	assert.Error(t, err)
+}
+
+func TestSignCSRs(t *testing.T) {
+	dir := t.TempDir()
+	opts := DefaultCSRSignerOptions()
+	opts.OcpOpts.KUBECONFIG = "sign-csrs"
+	opts.SignerName = "example.com/foo"
+	opts.CaOpts.CaKeyFile = filepath.Join(dir, "ca.key")
+	opts.CaOpts.CaCertFile = filepath.Join(dir, "ca.crt")
+	require.NoError(t, tls.GenerateCAToFiles(opts.CaOpts))
+
+	newCSR := func(name, signerName string, approved bool) *certificatesv1.CertificateSigningRequest {
+		_, req, err := tls.GenerateCSR(&tls.CertCfg{Subject: pkix.Name{CommonName: name}})
+		require.NoError(t, err)
+		csr := &certificatesv1.CertificateSigningRequest{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec: certificatesv1.CertificateSigningRequestSpec{
+				Request:    tls.CSRToPem(req),
+				SignerName: signerName,
+				Usages:     []certificatesv1.KeyUsage{certificatesv1.UsageDigitalSignature, certificatesv1.UsageClientAuth},
+			},
+		}
+		if approved {
+			csr.Status.Conditions = []certificatesv1.CertificateSigningRequestCondition{{Type: certificatesv1.CertificateApproved, Status: corev1.ConditionTrue}}
+		}
+		return csr
+	}
+	client := newFakeClient(t, opts.OcpOpts.KUBECONFIG,
+		newCSR("approved", opts.SignerName, true),
+		newCSR("pending", opts.SignerName, false),
+		newCSR("other-signer", "example.com/bar", true))
+
+	signed, err := SignCSRs(opts)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"approved"}, signed)
+	assert.NoFileExists(t, opts.CaOpts.DBFile(), "the certificates are recorded only when the CA database file is set")
+
+	csr := &certificatesv1.CertificateSigningRequest{}
+	require.NoError(t, client.Get(context.TODO(), occlient.ObjectKey{Name: "approved"}, csr))
+	certs, err := tls.PemToCertificates(csr.Status.Certificate)
+	require.NoError(t, err)
+	assert.Equal(t, "approved", certs[0].Subject.CommonName)
+	assert.Equal(t, []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}, certs[0].ExtKeyUsage)
+
+	// the signed requests are skipped in the next scan
+	signed, err = SignCSRs(opts)
+	require.NoError(t, err)
+	assert.Empty(t, signed)
+}
@@ -2,6 +2,7 @@ package openshift
 
 import (
 	"fmt"
+	"slices"
 
 	"github.com/openqe/openqe/pkg/tls"
 	"github.com/openqe/openqe/pkg/utils"
@@ -40,15 +41,15 @@ func SetupImageRegistry(opts *ImageRegistryOptions) (string, error) {
 		if opts.GlobalOpts != nil && opts.GlobalOpts.Verbose {
 			log.Info("TLS key/cert pair: key: %s, cert: %s are not ready, create key/cert pairs\n", opts.PkiOpts.CaGenOpt.CaKeyFile, opts.PkiOpts.CaGenOpt.CaCertFile)
 		}
-		if opts.PkiOpts.DNSName == tls.DefaultPKIOptions().DNSName {
+		if slices.Equal(opts.PkiOpts.DNSNames, tls.DefaultPKIOptions().DNSNames) {
 			// set it according to *.apps.<base-domain>
 			_, baseDomain, err := BaseDomain(opts.OcpOpts.KUBECONFIG)
 			if err != nil {
 				return "", err
 			}
-			opts.PkiOpts.DNSName = "*." + "apps." + baseDomain
+			opts.PkiOpts.DNSNames = []string{"*." + "apps." + baseDomain}
 			if opts.GlobalOpts != nil && opts.GlobalOpts.Verbose {
-				log.Info("Set the TLS cert DNSName to %s\n", opts.PkiOpts.DNSName)
+				log.Info("Set the TLS cert DNSNames to %v\n", opts.PkiOpts.DNSNames)
 			}
 		}
 		if err := tls.GenerateTLSKeyCertPairToFiles(opts.PkiOpts); err != nil {
@@ -19,6 +19,7 @@ import (
 	configv1 "github.com/openshift/api/config/v1"
 	routev1 "github.com/openshift/api/route/v1"
 	appsv1 "k8s.io/api/apps/v1"
+	certificatesv1 "k8s.io/api/certificates/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -64,6 +65,9 @@ func GetOrCreateOCClient(kubeconfig string) (occlient.Client, context.Context, l
 	if err := routev1.Install(scheme); err != nil {
 		return nil, ctx, log, err
 	}
+	if err := certificatesv1.AddToScheme(scheme); err != nil {
+		return nil, ctx, log, err
+	}
 	client, err := occlient.New(restConfig, occlient.Options{Scheme: scheme})
 	if err != nil {
 		return nil, ctx, log, err
@@ -120,36 +124,31 @@ func ConfigureAdditionalCA(kubeconfig, caCertFile string, verbose bool) error {
 	}
 
 	shouldRolling := false
-	cm := &corev1.ConfigMap{}
 	caCertData, err := os.ReadFile(caCertFile)
 	if err != nil {
 		return err
 	}
 	if existing != nil {
-		if err = client.Get(ctx, occlient.ObjectKey{Name: existing.Name, Namespace: "openshift-config"}, cm); err != nil {
+		caCerts, err := tls.PemToCertificates(caCertData)
+		if err != nil {
 			return err
 		}
-		caCert := string(caCertData)
-		existingBundle := cm.Data["ca-bundle.crt"]
-		alreadyAdd, err := tls.CertInConfigMap(cm, caCert)
+		shouldRolling, _, err = EditCABundleConfigMap(kubeconfig, "openshift-config", existing.Name, "", func(bundle *tls.CABundle) error {
+			bundle.Add(caCerts...)
+			return nil
+		})
 		if err != nil {
 			return err
 		}
-		if !alreadyAdd {
-			log.Info("Updating existing trusted CA config map %s\n", existing.Name)
-			cm.Data["ca-bundle.crt"] = existingBundle + "\n" + caCert
-			if err := client.Update(ctx, cm); err != nil {
-				return err
-			}
-			shouldRolling = true
+		if shouldRolling {
+			log.Info("Updated existing trusted CA config map %s\n", existing.Name)
 		} else {
 			log.Info("The CA certificate is already in the existing trusted CA config map %s, no need to update\n", existing.Name)
-			shouldRolling = false
 		}
 	} else {
 		// create a new config map with a ca-bundle.crt key from file: caCertFile
 		cmName := "openqe-trusted-ca"
-		cm = &corev1.ConfigMap{
+		cm := &corev1.ConfigMap{
 			ObjectMeta: metav1.ObjectMeta{
 				Name:      cmName,
 				Namespace: "openshift-config",
@@ -251,6 +250,41 @@ func CreateTLSSecretIfNotExists(kubeconfig, namespace, secretName, tlsKeyFile, t
 	return secret, nil
 }
 
+// ApplyObjects creates the objects in the cluster, or updates them if they exist already.
+// The namespaces of the namespaced objects are created if they do not exist.
+func ApplyObjects(kubeconfig string, objs ...occlient.Object) error {
+	client, ctx, log, err := GetOrCreateOCClient(kubeconfig)
+	if err != nil {
+		return err
+	}
+	for _, obj := range objs {
+		if obj.GetNamespace() != "" {
+			if _, err := CreateNamespaceIfNotExists(kubeconfig, obj.GetNamespace()); err != nil {
+				return err
+			}
+		}
+		kind := obj.GetObjectKind().GroupVersionKind().Kind
+		existing := obj.DeepCopyObject().(occlient.Object)
+		err := client.Get(ctx, occlient.ObjectKeyFromObject(obj), existing)
+		if err == nil {
+			obj.SetResourceVersion(existing.GetResourceVersion())
+			if err := client.Update(ctx, obj); err != nil {
+				return fmt.Errorf("failed to update %s %s/%s: %w", kind, obj.GetNamespace(), obj.GetName(), err)
+			}
+			log.Info("%s %s updated in namespace %s\n", kind, obj.GetName(), obj.GetNamespace())
+			continue
+		}
+		if !errors.IsNotFound(err) {
+			return err
+		}
+		if err := client.Create(ctx, obj); err != nil {
+			return fmt.Errorf("failed to create %s %s/%s: %w", kind, obj.GetNamespace(), obj.GetName(), err)
+		}
+		log.Info("%s %s created in namespace %s\n", kind, obj.GetName(), obj.GetNamespace())
+	}
+	return nil
+}
+
 // CreateHTPasswdSecret creates a htpasswd style user+Bcrypt(hash(password))
 func CreateHTPasswdSecret(kubeconfig, namespace, secretName, user, password string) (*corev1.Secret, error) {
 	client, ctx, log, err := GetOrCreateOCClient(kubeconfig)
@@ -6,6 +6,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/openqe/openqe/pkg/common"
 	"github.com/openqe/openqe/pkg/tls"
@@ -145,3 +146,127 @@ func MergeDockerConfig(target, cfg *DockerConfig) *DockerConfig {
 	}
 	return finalCfg
 }
+
+// CertManagerOptions contains the options to set up a cert-manager CA issuer from an openqe CA
+type CertManagerOptions struct {
+	OcpOpts *OcpOptions
+	CaOpts  *tls.CAOptions
+	// Namespace is the namespace of the Issuer and the Certificates
+	Namespace  string
+	SecretName string
+	IssuerName string
+	// ClusterIssuer creates a ClusterIssuer instead of an Issuer, its CA Secret is in the ClusterResourceNamespace
+	ClusterIssuer            bool
+	ClusterResourceNamespace string
+	// Certificates are the Certificates to create, in form of <name>:<dns-name>[,<dns-name>...]
+	Certificates []string
+	// Wait waits for the issuer and the Certificates to become Ready within Timeout
+	Wait       bool
+	Timeout    time.Duration
+	GlobalOpts *common.GlobalOptions
+}
+
+func DefaultCertManagerOptions() *CertManagerOptions {
+	return &CertManagerOptions{
+		OcpOpts:                  DefaultOcpOptions(),
+		CaOpts:                   tls.DefaultCAOptions(),
+		Namespace:                "default",
+		SecretName:               "openqe-ca",
+		IssuerName:               "openqe-ca-issuer",
+		ClusterResourceNamespace: "cert-manager",
+		Timeout:                  utils.DefaultTimeout,
+	}
+}
+
+// WebhookCertsOptions contains the options to set up the serving certificate of a webhook or an aggregated API service
+type WebhookCertsOptions struct {
+	OcpOpts *OcpOptions
+	CaOpts  *tls.CAOptions
+	// Service and Namespace are the service of the webhook, the certificate is issued for <service>.<namespace>.svc
+	Service   string
+	Namespace string
+	// SecretName is the TLS Secret of the serving certificate in Namespace, defaults to <service>-tls
+	SecretName string
+	// Targets are the resources referencing the service whose caBundle is patched, in form of <kind>/<name>
+	Targets  []string
+	Validity time.Duration
+	// Rotate re-signs the serving certificate when the Secret exists already, and adds the CA to the caBundle
+	Rotate     bool
+	GlobalOpts *common.GlobalOptions
+}
+
+func DefaultWebhookCertsOptions() *WebhookCertsOptions {
+	return &WebhookCertsOptions{
+		OcpOpts:  DefaultOcpOptions(),
+		CaOpts:   tls.DefaultCAOptions(),
+		Validity: 365 * 24 * time.Hour,
+	}
+}
+
+// CSRSignerOptions contains the options to sign the certificates.k8s.io/v1 CertificateSigningRequests of a signer
+type CSRSignerOptions struct {
+	OcpOpts    *OcpOptions
+	CaOpts     *tls.CAOptions
+	SignerName string
+	// AutoApprove approves the pending requests which pass the policy of AllowedUsages and SubjectPatterns
+	AutoApprove bool
+	// AllowedUsages are the usages a request may ask for to be approved, any usage is allowed when it is empty
+	AllowedUsages []string
+	// SubjectPatterns are regular expressions, one of them must match the RFC 4514 subject of a request to be approved
+	SubjectPatterns []string
+	// Validity of the certificates, unless the request asks for a shorter one with its expirationSeconds
+	Validity time.Duration
+	// Interval between the scans of the requests, the requests are scanned only once when Once is set
+	Interval   time.Duration
+	Once       bool
+	GlobalOpts *common.GlobalOptions
+}
+
+func DefaultCSRSignerOptions() *CSRSignerOptions {
+	return &CSRSignerOptions{
+		OcpOpts:  DefaultOcpOptions(),
+		CaOpts:   tls.DefaultCAOptions(),
+		Validity: 365 * 24 * time.Hour,
+		Interval: utils.ShortInterval,
+	}
+}
+
+// CertReportOptions contains the options to report the certificates in the cluster
+type CertReportOptions struct {
+	OcpOpts *OcpOptions
+	// Namespace limits the Secrets and ConfigMaps to scan, all namespaces are scanned when it is empty
+	Namespace string
+	// CABundles scans the caBundles of the webhook configurations, the CRD conversion webhooks and the APIServices too
+	CABundles  bool
+	GlobalOpts *common.GlobalOptions
+}
+
+func DefaultCertReportOptions() *CertReportOptions {
+	return &CertReportOptions{
+		OcpOpts: DefaultOcpOptions(),
+	}
+}
+
+// EnsureSignedSecretOptions contains the options to keep a TLS Secret signed by a CA Secret in the cluster
+type EnsureSignedSecretOptions struct {
+	OcpOpts *OcpOptions
+	// CASecret is the CA Secret in form of <namespace>/<name>
+	CASecret string
+	// CACertKey and CAKeyKey are the keys of the CA in the CA Secret, they default to ca.crt and ca.key
+	CACertKey  string
+	CAKeyKey   string
+	Namespace  string
+	SecretName string
+	SignedOpts *tls.SignedSecretOptions
+	GlobalOpts *common.GlobalOptions
+}
+
+func DefaultEnsureSignedSecretOptions() *EnsureSignedSecretOptions {
+	return &EnsureSignedSecretOptions{
+		OcpOpts:    DefaultOcpOptions(),
+		CACertKey:  tls.CASignerCertMapKey,
+		CAKeyKey:   tls.CASignerKeyMapKey,
+		Namespace:  "default",
+		SignedOpts: tls.DefaultSignedSecretOptions(),
+	}
+}
@@ -0,0 +1,58 @@
+package openshift
+
+import (
+	"github.com/openqe/openqe/pkg/tls"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	occlient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// EnsureSignedSecret creates or updates the TLS Secret of the options with a certificate signed by the CA Secret,
+// like tls.ReconcileSignedSecret. The Secret is only re-signed and updated when the CA hash changes, or when the
+// certificate does not match the options or its remaining validity falls below the threshold.
+// It returns the Secret and whether it is changed.
+func EnsureSignedSecret(opts *EnsureSignedSecretOptions) (*corev1.Secret, bool, error) {
+	client, ctx, _, err := GetOrCreateOCClient(opts.OcpOpts.KUBECONFIG)
+	if err != nil {
+		return nil, false, err
+	}
+	caNamespace, caName, err := ParseObjectReference(opts.CASecret)
+	if err != nil {
+		return nil, false, err
+	}
+	ca := &corev1.Secret{}
+	if err := client.Get(ctx, occlient.ObjectKey{Name: caName, Namespace: caNamespace}, ca); err != nil {
+		return nil, false, err
+	}
+	secret := &corev1.Secret{}
+	err = client.Get(ctx, occlient.ObjectKey{Name: opts.SecretName, Namespace: opts.Namespace}, secret)
+	if errors.IsNotFound(err) {
+		secret = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      opts.SecretName,
+				Namespace: opts.Namespace,
+			},
+		}
+	} else if err != nil {
+		return nil, false, err
+	}
+	changed, err := tls.ReconcileSignedSecret(secret, ca, opts.SignedOpts, func(o *tls.CAOpts) {
+		if opts.CACertKey != "" {
+			o.CASignerCertMapKey = opts.CACertKey
+		}
+		if opts.CAKeyKey != "" {
+			o.CASignerKeyMapKey = opts.CAKeyKey
+		}
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	if !changed {
+		return secret, false, nil
+	}
+	if err := ApplyObjects(opts.OcpOpts.KUBECONFIG, secret); err != nil {
+		return nil, false, err
+	}
+	return secret, true, nil
+}
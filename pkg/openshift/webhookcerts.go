@@ -0,0 +1,284 @@
+package openshift
+
+import (
+	"context"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/openqe/openqe/pkg/tls"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	occlient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// The lower case kinds of the caBundle targets in the <kind>/<name> form, they are handled as unstructured objects
+const (
+	targetValidatingWebhookConfiguration = "validatingwebhookconfiguration"
+	targetMutatingWebhookConfiguration   = "mutatingwebhookconfiguration"
+	targetCustomResourceDefinition       = "customresourcedefinition"
+	targetAPIService                     = "apiservice"
+)
+
+// caBundleTargetKinds are the GroupVersionKinds of the caBundle targets by their lower case kinds and short names
+var caBundleTargetKinds = map[string]schema.GroupVersionKind{
+	targetValidatingWebhookConfiguration: {Group: "admissionregistration.k8s.io", Version: "v1", Kind: "ValidatingWebhookConfiguration"},
+	targetMutatingWebhookConfiguration:   {Group: "admissionregistration.k8s.io", Version: "v1", Kind: "MutatingWebhookConfiguration"},
+	targetCustomResourceDefinition:       {Group: "apiextensions.k8s.io", Version: "v1", Kind: "CustomResourceDefinition"},
+	"crd":                                {Group: "apiextensions.k8s.io", Version: "v1", Kind: "CustomResourceDefinition"},
+	targetAPIService:                     {Group: "apiregistration.k8s.io", Version: "v1", Kind: "APIService"},
+}
+
+// ParseCABundleTarget parses a caBundle target in form of <kind>/<name>, the kind is one of
+// validatingwebhookconfiguration, mutatingwebhookconfiguration, customresourcedefinition (crd) or apiservice
+func ParseCABundleTarget(target string) (schema.GroupVersionKind, string, error) {
+	kind, name, found := strings.Cut(target, "/")
+	if !found || kind == "" || name == "" {
+		return schema.GroupVersionKind{}, "", fmt.Errorf("invalid target %q, expected <kind>/<name>", target)
+	}
+	gvk, ok := caBundleTargetKinds[strings.ToLower(kind)]
+	if !ok {
+		return schema.GroupVersionKind{}, "", fmt.Errorf("unsupported target kind: %s, supported: %s, %s, %s, %s", kind,
+			targetValidatingWebhookConfiguration, targetMutatingWebhookConfiguration, targetCustomResourceDefinition, targetAPIService)
+	}
+	return gvk, name, nil
+}
+
+// ServiceDNSNames returns the DNS names of a service inside the cluster
+func ServiceDNSNames(service, namespace string) []string {
+	return []string{
+		fmt.Sprintf("%s.%s.svc", service, namespace),
+		fmt.Sprintf("%s.%s.svc.cluster.local", service, namespace),
+	}
+}
+
+// SetupWebhookCerts signs a serving certificate for <service>.<namespace>.svc with the CA of the options, patches the
+// CA certificates as the caBundle of every webhook, CRD conversion webhook or APIService of the targets which
+// references the service, and then stores the certificate in a TLS Secret. The Secret must not exist unless
+// opts.Rotate is set, in which case the certificate is re-signed and the CA certificates are added to the caBundles,
+// so the clients trust both the old and the new CA before the new certificate is served.
+func SetupWebhookCerts(opts *WebhookCertsOptions) (*corev1.Secret, error) {
+	client, ctx, log, err := GetOrCreateOCClient(opts.OcpOpts.KUBECONFIG)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Service == "" || opts.Namespace == "" {
+		return nil, fmt.Errorf("both service and namespace need to be specified")
+	}
+	type target struct {
+		gvk  schema.GroupVersionKind
+		name string
+	}
+	var targets []target
+	for _, t := range opts.Targets {
+		gvk, name, err := ParseCABundleTarget(t)
+		if err != nil {
+			return nil, err
+		}
+		targets = append(targets, target{gvk: gvk, name: name})
+	}
+	secretName := opts.SecretName
+	if secretName == "" {
+		secretName = opts.Service + "-tls"
+	}
+	err = client.Get(ctx, occlient.ObjectKey{Name: secretName, Namespace: opts.Namespace}, &corev1.Secret{})
+	if err == nil && !opts.Rotate {
+		return nil, fmt.Errorf("Secret %s already exists in namespace %s, use rotate to re-sign it", secretName, opts.Namespace)
+	}
+	if err != nil && !errors.IsNotFound(err) {
+		return nil, err
+	}
+
+	caKey, caChain, err := opts.CaOpts.LoadCA()
+	if err != nil {
+		return nil, err
+	}
+	dnsNames := ServiceDNSNames(opts.Service, opts.Namespace)
+	cfg := &tls.CertCfg{
+		KeySize:      tls.DefaultKeySize,
+		DNSNames:     dnsNames,
+		ExtKeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		KeyUsages:    x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		Subject:      pkix.Name{CommonName: dnsNames[0]},
+		Validity:     opts.Validity,
+	}
+	key, cert, err := tls.GenerateSignedCertificate(caKey, caChain[0], cfg)
+	if err != nil {
+		return nil, err
+	}
+	keyPEM, err := tls.PrivateKeyToPem(key)
+	if err != nil {
+		return nil, err
+	}
+	caPEM := tls.CertsToPem(caChain)
+	// the serving certificate is followed by the intermediate CAs, the roots are only in the caBundle
+	certPEM := tls.CertToPem(cert)
+	for _, c := range caChain {
+		if !tls.IsSelfSigned(c) {
+			certPEM = append(certPEM, tls.CertToPem(c)...)
+		}
+	}
+
+	// every target is checked before anything is changed, the Secret is applied after the caBundles trust the CA
+	var injections []*caBundleInjection
+	for _, t := range targets {
+		injection, err := newCABundleInjection(ctx, client, t.gvk, t.name, opts.Service, opts.Namespace, caPEM, opts.Rotate)
+		if err != nil {
+			return nil, err
+		}
+		injections = append(injections, injection)
+	}
+	for _, injection := range injections {
+		if err := injection.patch(ctx, client); err != nil {
+			return nil, err
+		}
+		log.Info("Patched caBundle of %d client config(s) in %s %s\n", injection.patched, injection.obj.GetKind(), injection.obj.GetName())
+	}
+
+	secret := tls.NewTLSSecret(metav1.ObjectMeta{Name: secretName, Namespace: opts.Namespace}, keyPEM, certPEM, caPEM)
+	if err := ApplyObjects(opts.OcpOpts.KUBECONFIG, secret); err != nil {
+		return nil, err
+	}
+	log.Info("Serving certificate for %s is ready in Secret %s/%s\n", dnsNames[0], opts.Namespace, secretName)
+	return secret, nil
+}
+
+// InjectCABundle patches caBundle into the client configs of the resource which reference the service.
+// For a webhook configuration, every webhook referencing the service is patched. For a CustomResourceDefinition,
+// the conversion webhook is patched, and for an APIService its caBundle is patched.
+// When merge is set, the certificates of caBundle are added to the existing caBundle and the expired ones are removed,
+// otherwise the existing caBundle is replaced.
+// It returns the number of patched client configs, it is an error when none of them references the service.
+func InjectCABundle(kubeconfig string, gvk schema.GroupVersionKind, name, service, namespace string, caBundle []byte, merge bool) (int, error) {
+	client, ctx, _, err := GetOrCreateOCClient(kubeconfig)
+	if err != nil {
+		return 0, err
+	}
+	injection, err := newCABundleInjection(ctx, client, gvk, name, service, namespace, caBundle, merge)
+	if err != nil {
+		return 0, err
+	}
+	if err := injection.patch(ctx, client); err != nil {
+		return 0, err
+	}
+	return injection.patched, nil
+}
+
+// caBundleInjection is a caBundle target with its caBundles set but not patched yet
+type caBundleInjection struct {
+	original *unstructured.Unstructured
+	obj      *unstructured.Unstructured
+	// patched is the number of the client configs with the caBundle set
+	patched int
+}
+
+// newCABundleInjection gets the target resource and sets caBundle in its client configs which reference the service,
+// the resource is not changed in the cluster
+func newCABundleInjection(ctx context.Context, client occlient.Client, gvk schema.GroupVersionKind, name, service, namespace string, caBundle []byte, merge bool) (*caBundleInjection, error) {
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(gvk)
+	if err := client.Get(ctx, occlient.ObjectKey{Name: name}, obj); err != nil {
+		return nil, err
+	}
+	original := obj.DeepCopy()
+	var caCerts []*x509.Certificate
+	if merge {
+		var err error
+		if caCerts, err = tls.PemToCertificates(caBundle); err != nil {
+			return nil, err
+		}
+	}
+	// setCABundle sets the caBundle at fields, []byte fields are base64 encoded strings in the unstructured content
+	setCABundle := func(obj map[string]any, fields ...string) error {
+		data := caBundle
+		if existing, _, _ := unstructured.NestedString(obj, fields...); merge && existing != "" {
+			merged, err := mergeCABundle(existing, caCerts)
+			if err != nil {
+				return fmt.Errorf("failed to merge caBundle of %s %s: %w", gvk.Kind, name, err)
+			}
+			data = merged
+		}
+		return unstructured.SetNestedField(obj, base64.StdEncoding.EncodeToString(data), fields...)
+	}
+	patched := 0
+	switch gvk.Kind {
+	case "ValidatingWebhookConfiguration", "MutatingWebhookConfiguration":
+		webhooks, _, err := unstructured.NestedSlice(obj.Object, "webhooks")
+		if err != nil {
+			return nil, err
+		}
+		for _, w := range webhooks {
+			webhook, ok := w.(map[string]any)
+			if !ok || !referencesService(webhook, service, namespace, "clientConfig", "service") {
+				continue
+			}
+			if err := setCABundle(webhook, "clientConfig", "caBundle"); err != nil {
+				return nil, err
+			}
+			patched++
+		}
+		if err := unstructured.SetNestedSlice(obj.Object, webhooks, "webhooks"); err != nil {
+			return nil, err
+		}
+	case "CustomResourceDefinition":
+		if strategy, _, _ := unstructured.NestedString(obj.Object, "spec", "conversion", "strategy"); strategy != "Webhook" {
+			return nil, fmt.Errorf("%s %s has no conversion webhook, its conversion strategy is %q", gvk.Kind, name, strategy)
+		}
+		if referencesService(obj.Object, service, namespace, "spec", "conversion", "webhook", "clientConfig", "service") {
+			if err := setCABundle(obj.Object, "spec", "conversion", "webhook", "clientConfig", "caBundle"); err != nil {
+				return nil, err
+			}
+			patched++
+		}
+	case "APIService":
+		if referencesService(obj.Object, service, namespace, "spec", "service") {
+			if err := setCABundle(obj.Object, "spec", "caBundle"); err != nil {
+				return nil, err
+			}
+			patched++
+		}
+	default:
+		return nil, fmt.Errorf("unsupported kind: %s", gvk.Kind)
+	}
+	if patched == 0 {
+		return nil, fmt.Errorf("%s %s does not reference service %s/%s", gvk.Kind, name, namespace, service)
+	}
+	return &caBundleInjection{original: original, obj: obj, patched: patched}, nil
+}
+
+// patch patches the caBundles into the target resource, it fails when the resource is changed in the meantime
+func (i *caBundleInjection) patch(ctx context.Context, client occlient.Client) error {
+	patch := occlient.MergeFromWithOptions(i.original, occlient.MergeFromWithOptimisticLock{})
+	if err := client.Patch(ctx, i.obj, patch); err != nil {
+		return fmt.Errorf("failed to patch caBundle of %s %s: %w", i.obj.GetKind(), i.obj.GetName(), err)
+	}
+	return nil
+}
+
+// mergeCABundle adds the certificates to the base64 encoded caBundle and removes the expired ones from it
+func mergeCABundle(existing string, certs []*x509.Certificate) ([]byte, error) {
+	data, err := base64.StdEncoding.DecodeString(existing)
+	if err != nil {
+		return nil, err
+	}
+	bundle, err := tls.ParseCABundle(data)
+	if err != nil {
+		return nil, err
+	}
+	bundle.Add(certs...)
+	bundle.PruneExpired(time.Now())
+	return bundle.Bytes(), nil
+}
+
+// referencesService tells whether the service reference at fields of obj is the service in the namespace
+func referencesService(obj map[string]any, service, namespace string, fields ...string) bool {
+	name, _, _ := unstructured.NestedString(obj, append(fields, "name")...)
+	ns, _, _ := unstructured.NestedString(obj, append(fields, "namespace")...)
+	return name == service && ns == namespace
+}
@@ -0,0 +1,162 @@
+package openshift
+
+import (
+	"context"
+	"encoding/base64"
+	"path/filepath"
+	"testing"
+
+	"github.com/openqe/openqe/pkg/tls"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	occlient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// newFakeClient caches a fake client with the objects for the kubeconfig, GetOrCreateOCClient returns it
+func newFakeClient(t *testing.T, kubeconfig string, objs ...occlient.Object) occlient.Client {
+	t.Helper()
+	return cacheClient(t, kubeconfig, fake.NewClientBuilder().WithObjects(objs...).Build())
+}
+
+// cacheClient caches the client for the kubeconfig during the test
+func cacheClient(t *testing.T, kubeconfig string, client occlient.Client) occlient.Client {
+	t.Helper()
+	cacheMutex.Lock()
+	clientCache[kubeconfig] = client
+	cacheMutex.Unlock()
+	t.Cleanup(func() {
+		cacheMutex.Lock()
+		delete(clientCache, kubeconfig)
+		cacheMutex.Unlock()
+	})
+	return client
+}
+
+// newTestCAPEM generates a CA and returns its certificate in PEM
+func newTestCAPEM(t *testing.T, subject string) []byte {
+	t.Helper()
+	dir := t.TempDir()
+	ca := tls.DefaultCAOptions()
+	ca.Subject = subject
+	ca.CaKeyFile = filepath.Join(dir, "ca.key")
+	ca.CaCertFile = filepath.Join(dir, "ca.crt")
+	_, chain, err := tls.GenerateCAChain(ca)
+	require.NoError(t, err)
+	return tls.CertsToPem(chain)
+}
+
+func TestParseCABundleTarget(t *testing.T) {
+	gvk, name, err := ParseCABundleTarget("ValidatingWebhookConfiguration/my-webhook")
+	require.NoError(t, err)
+	assert.Equal(t, "ValidatingWebhookConfiguration", gvk.Kind)
+	assert.Equal(t, "admissionregistration.k8s.io", gvk.Group)
+	assert.Equal(t, "my-webhook", name)
+
+	gvk, name, err = ParseCABundleTarget("crd/foos.example.com")
+	require.NoError(t, err)
+	assert.Equal(t, "CustomResourceDefinition", gvk.Kind)
+	assert.Equal(t, "foos.example.com", name)
+
+	for _, target := range []string{"apiservice", "apiservice/", "/name", "secret/name"} {
+		_, _, err := ParseCABundleTarget(target)
+		assert.Error(t, err, target)
+	}
+}
+
+func TestInjectCABundle(t *testing.T) {
+	webhook := func(service string) map[string]any {
+		return map[string]any{
+			"name":         service + ".example.com",
+			"clientConfig": map[string]any{"service": map[string]any{"name": service, "namespace": "test"}},
+		}
+	}
+	config := &unstructured.Unstructured{}
+	gvk, _, err := ParseCABundleTarget("validatingwebhookconfiguration/my-webhook")
+	require.NoError(t, err)
+	config.SetGroupVersionKind(gvk)
+	config.SetName("my-webhook")
+	require.NoError(t, unstructured.SetNestedSlice(config.Object, []any{webhook("my-svc"), webhook("other-svc")}, "webhooks"))
+	client := newFakeClient(t, "inject-ca-bundle", config)
+
+	caBundle := func() []string {
+		obj := &unstructured.Unstructured{}
+		obj.SetGroupVersionKind(gvk)
+		require.NoError(t, client.Get(context.TODO(), occlient.ObjectKey{Name: "my-webhook"}, obj))
+		webhooks, _, err := unstructured.NestedSlice(obj.Object, "webhooks")
+		require.NoError(t, err)
+		var bundles []string
+		for _, w := range webhooks {
+			encoded, _, _ := unstructured.NestedString(w.(map[string]any), "clientConfig", "caBundle")
+			data, err := base64.StdEncoding.DecodeString(encoded)
+			require.NoError(t, err)
+			bundles = append(bundles, string(data))
+		}
+		return bundles
+	}
+
+	oldCA := newTestCAPEM(t, "CN=old, OU=openqe")
+	patched, err := InjectCABundle("inject-ca-bundle", gvk, "my-webhook", "my-svc", "test", oldCA, false)
+	require.NoError(t, err)
+	assert.Equal(t, 1, patched)
+	assert.Equal(t, []string{string(oldCA), ""}, caBundle(), "only the webhook referencing the service is patched")
+
+	// merging keeps the old CA, replacing does not
+	newCA := newTestCAPEM(t, "CN=new, OU=openqe")
+	_, err = InjectCABundle("inject-ca-bundle", gvk, "my-webhook", "my-svc", "test", newCA, true)
+	require.NoError(t, err)
+	assert.Equal(t, string(oldCA)+string(newCA), caBundle()[0])
+	_, err = InjectCABundle("inject-ca-bundle", gvk, "my-webhook", "my-svc", "test", newCA, false)
+	require.NoError(t, err)
+	assert.Equal(t, string(newCA), caBundle()[0])
+
+	_, err = InjectCABundle("inject-ca-bundle", gvk, "my-webhook", "missing-svc", "test", newCA, false)
+	assert.ErrorContains(t, err, "does not reference service")
+}
+
+func TestSetupWebhookCerts(t *testing.T) {
+	apiService := func(name, service string) *unstructured.Unstructured {
+		obj := &unstructured.Unstructured{}
+		gvk, _, err := ParseCABundleTarget("apiservice/" + name)
+		require.NoError(t, err)
+		obj.SetGroupVersionKind(gvk)
+		obj.SetName(name)
+		require.NoError(t, unstructured.SetNestedMap(obj.Object, map[string]any{"name": service, "namespace": "test"}, "spec", "service"))
+		return obj
+	}
+	client := newFakeClient(t, "setup-webhook-certs", apiService("v1.a.example.com", "my-svc"), apiService("v1.b.example.com", "other-svc"))
+	dir := t.TempDir()
+	opts := DefaultWebhookCertsOptions()
+	opts.OcpOpts.KUBECONFIG = "setup-webhook-certs"
+	opts.CaOpts.CaKeyFile = filepath.Join(dir, "ca.key")
+	opts.CaOpts.CaCertFile = filepath.Join(dir, "ca.crt")
+	require.NoError(t, tls.GenerateCAToFiles(opts.CaOpts))
+	opts.Service = "my-svc"
+	opts.Namespace = "test"
+	secretKey := occlient.ObjectKey{Name: "my-svc-tls", Namespace: "test"}
+	caBundle := func(name string) string {
+		obj := apiService(name, "")
+		require.NoError(t, client.Get(context.TODO(), occlient.ObjectKey{Name: name}, obj))
+		encoded, _, _ := unstructured.NestedString(obj.Object, "spec", "caBundle")
+		return encoded
+	}
+
+	// nothing is changed when a target does not reference the service
+	opts.Targets = []string{"apiservice/v1.a.example.com", "apiservice/v1.b.example.com"}
+	_, err := SetupWebhookCerts(opts)
+	assert.ErrorContains(t, err, "does not reference service")
+	assert.True(t, errors.IsNotFound(client.Get(context.TODO(), secretKey, &corev1.Secret{})))
+	assert.Empty(t, caBundle("v1.a.example.com"))
+
+	opts.Targets = []string{"apiservice/v1.a.example.com"}
+	secret, err := SetupWebhookCerts(opts)
+	require.NoError(t, err)
+	require.NoError(t, client.Get(context.TODO(), secretKey, &corev1.Secret{}))
+	assert.Equal(t, base64.StdEncoding.EncodeToString(secret.Data[tls.CASignerCertMapKey]), caBundle("v1.a.example.com"))
+
+	_, err = SetupWebhookCerts(opts)
+	assert.ErrorContains(t, err, "already exists")
+}
@@ -0,0 +1,355 @@
+package tls
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/openqe/openqe/pkg/common"
+	"github.com/openqe/openqe/pkg/utils"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// ApplyCreated means the key/cert pair did not exist and was generated
+	ApplyCreated = "created"
+	// ApplyRegenerated means the key/cert pair existed but was invalid, expiring or outdated, and was generated again
+	ApplyRegenerated = "regenerated"
+	// ApplyUnchanged means the key/cert pair existed and was still valid
+	ApplyUnchanged = "unchanged"
+)
+
+// PKISpec describes a CA hierarchy and the leaf certificates issued by the CAs
+type PKISpec struct {
+	// OutputDir is the directory of the relative file paths in the spec, defaults to the current directory
+	OutputDir string `yaml:"output_dir"`
+	// MinRemaining is the minimum remaining validity of an existing certificate to keep it
+	MinRemaining time.Duration `yaml:"min_remaining"`
+	CAs          []CASpec      `yaml:"cas"`
+	Certs        []CertSpec    `yaml:"certs"`
+}
+
+// CASpec describes a CA, which is self-signed unless it has a parent
+type CASpec struct {
+	Name         string        `yaml:"name"`
+	Parent       string        `yaml:"parent"`
+	Subject      string        `yaml:"subject"`
+	SANs         SANSpec       `yaml:",inline"`
+	KeyAlgorithm string        `yaml:"key_algorithm"`
+	KeySize      int           `yaml:"key_size"`
+	Validity     time.Duration `yaml:"validity"`
+	// PathLen is the maximum number of intermediate CAs below the CA, unlimited when not set
+	PathLen  *int   `yaml:"path_len"`
+	KeyFile  string `yaml:"key_file"`
+	CertFile string `yaml:"cert_file"`
+	// KeyPassphraseFile contains the passphrase to read and write the private key as encrypted PKCS#8
+	KeyPassphraseFile string `yaml:"key_passphrase_file"`
+}
+
+// CertSpec describes a leaf certificate issued by a CA of the spec
+type CertSpec struct {
+	Name         string        `yaml:"name"`
+	CA           string        `yaml:"ca"`
+	Subject      string        `yaml:"subject"`
+	SANs         SANSpec       `yaml:",inline"`
+	Profile      string        `yaml:"profile"`
+	ExtKeyUsages []string      `yaml:"ext_key_usages"`
+	KeyAlgorithm string        `yaml:"key_algorithm"`
+	KeySize      int           `yaml:"key_size"`
+	Validity     time.Duration `yaml:"validity"`
+	KeyFile      string        `yaml:"key_file"`
+	CertFile     string        `yaml:"cert_file"`
+	ChainFile    string        `yaml:"chain_file"`
+	// KeyPassphraseFile contains the passphrase to read and write the private key as encrypted PKCS#8
+	KeyPassphraseFile string `yaml:"key_passphrase_file"`
+}
+
+// SANSpec contains the subject alternative names of a CA or a certificate in the spec
+type SANSpec struct {
+	DNSNames       []string `yaml:"dns_names"`
+	IPAddresses    []string `yaml:"ip_addresses"`
+	URIs           []string `yaml:"uris"`
+	EmailAddresses []string `yaml:"email_addresses"`
+}
+
+// ApplyResult is the outcome of applying a CA or a certificate of the spec
+type ApplyResult struct {
+	Kind   string
+	Name   string
+	Action string
+	// Reason explains why the key/cert pair was regenerated
+	Reason string
+}
+
+// LoadPKISpec loads a PKI spec from a YAML file, rendered as a template which can use the env variables
+func LoadPKISpec(specFile string) (*PKISpec, error) {
+	content, err := os.ReadFile(specFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read PKI spec file: %w", err)
+	}
+	rendered, err := common.NewTemplateRenderer().Render(string(content), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render PKI spec template: %w", err)
+	}
+	spec := &PKISpec{}
+	if err := yaml.Unmarshal([]byte(rendered), spec); err != nil {
+		return nil, fmt.Errorf("failed to parse PKI spec: %w", err)
+	}
+	return spec, nil
+}
+
+func (s SANSpec) options() SANOptions {
+	return SANOptions{
+		DNSNames:       s.DNSNames,
+		IPAddresses:    s.IPAddresses,
+		URIs:           s.URIs,
+		EmailAddresses: s.EmailAddresses,
+	}
+}
+
+// path resolves a file path of the spec against the output directory
+func (s *PKISpec) path(file string) string {
+	if file == "" || filepath.IsAbs(file) || s.OutputDir == "" {
+		return file
+	}
+	return filepath.Join(s.OutputDir, file)
+}
+
+// caOptions converts a CA of the spec to CAOptions, the files default to <name>.key and <name>.crt
+func (s *PKISpec) caOptions(ca *CASpec, parent *CAOptions) *CAOptions {
+	opts := DefaultCAOptions()
+	opts.SANOptions = ca.SANs.options()
+	if ca.Subject != "" {
+		opts.Subject = ca.Subject
+	}
+	if ca.KeyAlgorithm != "" {
+		opts.KeyAlgorithm = ca.KeyAlgorithm
+	}
+	opts.KeySize = ca.KeySize
+	opts.Validity = ca.Validity
+	if ca.PathLen != nil {
+		opts.PathLen = *ca.PathLen
+	}
+	opts.CaKeyFile = s.path(defaultString(ca.KeyFile, ca.Name+".key"))
+	opts.CaCertFile = s.path(defaultString(ca.CertFile, ca.Name+".crt"))
+	opts.KeyPassphraseFile = ca.KeyPassphraseFile
+	if parent != nil {
+		opts.ParentCaKeyFile = parent.CaKeyFile
+		opts.ParentCaCertFile = parent.CaCertFile
+		opts.ParentKeyPassphraseFile = parent.KeyPassphraseFile
+	}
+	return opts
+}
+
+// pkiOptions converts a certificate of the spec to PKIOptions, the files default to <name>.key and <name>.crt
+func (s *PKISpec) pkiOptions(cert *CertSpec, ca *CAOptions) *PKIOptions {
+	opts := DefaultPKIOptions()
+	opts.CaGenOpt = ca
+	opts.SANOptions = cert.SANs.options()
+	if cert.Subject != "" {
+		opts.Subject = cert.Subject
+	}
+	if cert.KeyAlgorithm != "" {
+		opts.KeyAlgorithm = cert.KeyAlgorithm
+	}
+	opts.KeySize = cert.KeySize
+	opts.Validity = cert.Validity
+	opts.Profile = cert.Profile
+	opts.ExtKeyUsages = cert.ExtKeyUsages
+	opts.KeyFile = s.path(defaultString(cert.KeyFile, cert.Name+".key"))
+	opts.CertFile = s.path(defaultString(cert.CertFile, cert.Name+".crt"))
+	opts.ChainFile = s.path(cert.ChainFile)
+	opts.KeyPassphraseFile = cert.KeyPassphraseFile
+	return opts
+}
+
+func defaultString(value, defaultValue string) string {
+	if value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+// ApplyPKISpec generates the CAs and the certificates of the spec which are missing, invalid or expiring.
+// The CAs are generated before the CAs and the certificates they sign, and a key/cert pair is regenerated
+// when its issuer is regenerated. The key/cert pairs which are still valid are kept as they are.
+func ApplyPKISpec(spec *PKISpec) ([]ApplyResult, error) {
+	var results []ApplyResult
+	cas := map[string]*CAOptions{}
+	pending := spec.CAs
+	for len(pending) > 0 {
+		var next []CASpec
+		for i := range pending {
+			ca := &pending[i]
+			if ca.Name == "" {
+				return results, errors.New("every CA in the PKI spec needs a name")
+			}
+			if _, ok := cas[ca.Name]; ok {
+				return results, fmt.Errorf("duplicated CA %s in the PKI spec", ca.Name)
+			}
+			var parent *CAOptions
+			if ca.Parent != "" {
+				if parent = cas[ca.Parent]; parent == nil {
+					next = append(next, *ca)
+					continue
+				}
+			}
+			opts := spec.caOptions(ca, parent)
+			if err := mkdirs(opts.CaKeyFile, opts.CaCertFile); err != nil {
+				return results, err
+			}
+			cfg, err := opts.certCfg()
+			if err != nil {
+				return results, fmt.Errorf("invalid CA %s: %w", ca.Name, err)
+			}
+			result := ApplyResult{Kind: "ca", Name: ca.Name}
+			result.Action, result.Reason = checkKeyPairFiles(opts.CaKeyFile, opts.CaCertFile, opts.KeyPassphraseFile, opts.ParentCaCertFile, cfg, spec.MinRemaining)
+			if result.Action != ApplyUnchanged {
+				if err := GenerateCAToFiles(opts); err != nil {
+					return results, fmt.Errorf("failed to generate CA %s: %w", ca.Name, err)
+				}
+			}
+			cas[ca.Name] = opts
+			results = append(results, result)
+		}
+		if len(next) == len(pending) {
+			return results, fmt.Errorf("the parent CA %s of the CA %s is not defined in the PKI spec, or the parents are circular", next[0].Parent, next[0].Name)
+		}
+		pending = next
+	}
+
+	for i := range spec.Certs {
+		cert := &spec.Certs[i]
+		if cert.Name == "" {
+			return results, errors.New("every certificate in the PKI spec needs a name")
+		}
+		ca, ok := cas[cert.CA]
+		if !ok {
+			return results, fmt.Errorf("the CA %q of the certificate %s is not defined in the PKI spec", cert.CA, cert.Name)
+		}
+		opts := spec.pkiOptions(cert, ca)
+		if err := mkdirs(opts.KeyFile, opts.CertFile, opts.ChainFile); err != nil {
+			return results, err
+		}
+		cfg, err := opts.certCfg()
+		if err != nil {
+			return results, fmt.Errorf("invalid certificate %s: %w", cert.Name, err)
+		}
+		result := ApplyResult{Kind: "cert", Name: cert.Name}
+		result.Action, result.Reason = checkKeyPairFiles(opts.KeyFile, opts.CertFile, opts.KeyPassphraseFile, ca.CaCertFile, cfg, spec.MinRemaining)
+		if result.Action != ApplyUnchanged {
+			if err := GenerateTLSKeyCertPairToFiles(opts); err != nil {
+				return results, fmt.Errorf("failed to generate certificate %s: %w", cert.Name, err)
+			}
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// checkKeyPairFiles decides whether the key/cert pair files need to be generated, and why.
+// The private key is decrypted with the passphrase in passphraseFile when it is set. The certificate must be valid
+// like ValidateKeyPair, must not drift from the cfg with certificateDrift, and must be signed by the current issuer
+// certificate, if any.
+func checkKeyPairFiles(keyFile, certFile, passphraseFile, issuerCertFile string, cfg *CertCfg, minRemaining time.Duration) (string, string) {
+	if !utils.FileExists(keyFile) || !utils.FileExists(certFile) {
+		return ApplyCreated, ""
+	}
+	keyBytes, err := os.ReadFile(keyFile)
+	if err != nil {
+		return ApplyRegenerated, err.Error()
+	}
+	certBytes, err := os.ReadFile(certFile)
+	if err != nil {
+		return ApplyRegenerated, err.Error()
+	}
+	passphrase, err := readPassphrase(passphraseFile)
+	if err != nil {
+		return ApplyRegenerated, err.Error()
+	}
+	_, cert, err := parsePemKeypair(keyBytes, certBytes, passphrase)
+	if err != nil {
+		return ApplyRegenerated, fmt.Sprintf("failed to parse keypair: %v", err)
+	}
+	if err := validateCertificate(cert, cfg, minRemaining); err != nil {
+		return ApplyRegenerated, err.Error()
+	}
+	if reason := certificateDrift(cert, cfg); reason != "" {
+		return ApplyRegenerated, reason
+	}
+	if issuerCertFile == "" {
+		return ApplyUnchanged, ""
+	}
+	issuerBytes, err := os.ReadFile(issuerCertFile)
+	if err != nil {
+		return ApplyRegenerated, err.Error()
+	}
+	issuer, err := PemToCertificate(issuerBytes)
+	if err != nil {
+		return ApplyRegenerated, err.Error()
+	}
+	if err := cert.CheckSignatureFrom(issuer); err != nil {
+		return ApplyRegenerated, "the certificate is not signed by the current issuer"
+	}
+	return ApplyUnchanged, ""
+}
+
+// certificateDrift checks the properties of the certificate which are not checked by ValidateKeyPair: the key algorithm
+// and size, the path length constraint, the CRL and OCSP URLs and must-staple. It returns why the certificate differs
+// from the cfg, or an empty string when it does not.
+func certificateDrift(cert *x509.Certificate, cfg *CertCfg) string {
+	alg := cfg.KeyAlgorithm
+	if alg == "" {
+		alg = KeyAlgorithmRSA
+	}
+	if actual := keyAlgorithmOf(cert.PublicKeyAlgorithm); actual != alg {
+		return fmt.Sprintf("the key algorithm %s differs from the expected %s", actual, alg)
+	}
+	size := cfg.KeySize
+	if size == 0 && alg == KeyAlgorithmRSA {
+		size = DefaultKeySize
+	} else if size == 0 && alg == KeyAlgorithmECDSA {
+		size = DefaultECDSAKeySize
+	}
+	if _, actual := PublicKeyInfo(cert.PublicKey); alg != KeyAlgorithmEd25519 && actual != size {
+		return fmt.Sprintf("the key size %d differs from the expected %d", actual, size)
+	}
+	if cfg.IsCA {
+		maxPathLen, maxPathLenZero := -1, false
+		if cfg.MaxPathLen != nil {
+			maxPathLen, maxPathLenZero = *cfg.MaxPathLen, *cfg.MaxPathLen == 0
+		}
+		if cert.MaxPathLen != maxPathLen || cert.MaxPathLenZero != maxPathLenZero {
+			return fmt.Sprintf("the path length constraint %d differs from the expected %d", cert.MaxPathLen, maxPathLen)
+		}
+	}
+	if !slices.Equal(cert.CRLDistributionPoints, cfg.CRLDistributionPoints) {
+		return fmt.Sprintf("the CRL distribution points %v differ from the expected %v", cert.CRLDistributionPoints, cfg.CRLDistributionPoints)
+	}
+	if !slices.Equal(cert.OCSPServer, cfg.OCSPServers) {
+		return fmt.Sprintf("the OCSP servers %v differ from the expected %v", cert.OCSPServer, cfg.OCSPServers)
+	}
+	mustStaple := slices.ContainsFunc(cert.Extensions, func(ext pkix.Extension) bool { return ext.Id.Equal(oidTLSFeature) })
+	if mustStaple != cfg.MustStaple {
+		return fmt.Sprintf("must-staple %t differs from the expected %t", mustStaple, cfg.MustStaple)
+	}
+	return ""
+}
+
+// mkdirs creates the parent directories of the files
+func mkdirs(files ...string) error {
+	for _, file := range files {
+		if file == "" {
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			return err
+		}
+	}
+	return nil
+}
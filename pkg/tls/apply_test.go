@@ -0,0 +1,101 @@
+package tls
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyPKISpec(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("OPENQE_PKI_DIR", dir)
+	specFile := filepath.Join(dir, "pki.yaml")
+	spec := `
+output_dir: {{ env.OPENQE_PKI_DIR }}/out
+cas:
+  - name: intermediate
+    parent: root
+    subject: CN=intermediate, OU=openqe
+  - name: root
+    subject: CN={{ root_cn|default:"root" }}, OU=openqe
+certs:
+  - name: server
+    ca: intermediate
+    subject: CN=server, OU=openqe
+    dns_names: [server.openqe.github.io]
+    ip_addresses: [127.0.0.1]
+    profile: server
+`
+	require.NoError(t, os.WriteFile(specFile, []byte(spec), 0644))
+	actions := func(results []ApplyResult) []string {
+		var actions []string
+		for _, r := range results {
+			actions = append(actions, r.Kind+"/"+r.Name+"="+r.Action)
+		}
+		return actions
+	}
+
+	pki, err := LoadPKISpec(specFile)
+	require.NoError(t, err)
+	results, err := ApplyPKISpec(pki)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ca/root=created", "ca/intermediate=created", "cert/server=created"}, actions(results))
+
+	results, err = ApplyPKISpec(pki)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ca/root=unchanged", "ca/intermediate=unchanged", "cert/server=unchanged"}, actions(results))
+
+	pki.CAs[1].Subject = "CN=new-root, OU=openqe"
+	results, err = ApplyPKISpec(pki)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ca/root=regenerated", "ca/intermediate=regenerated", "cert/server=regenerated"}, actions(results))
+
+	pathLen := 0
+	pki.CAs[0].PathLen = &pathLen
+	pki.Certs[0].KeyAlgorithm = string(KeyAlgorithmECDSA)
+	results, err = ApplyPKISpec(pki)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ca/root=unchanged", "ca/intermediate=regenerated", "cert/server=regenerated"}, actions(results))
+	assert.Contains(t, results[1].Reason, "path length")
+
+	pki.Certs[0].KeySize = 384
+	results, err = ApplyPKISpec(pki)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ca/root=unchanged", "ca/intermediate=unchanged", "cert/server=regenerated"}, actions(results))
+	assert.Contains(t, results[2].Reason, "key size")
+
+	_, err = VerifyCertificateFile(&VerifyOptions{
+		CertFile: filepath.Join(dir, "out", "server.crt"),
+		CAFile:   filepath.Join(dir, "out", "root.crt"),
+		Hostname: "127.0.0.1",
+	})
+	assert.NoError(t, err)
+
+	pki.Certs[0].CA = "missing"
+	_, err = ApplyPKISpec(pki)
+	assert.ErrorContains(t, err, "missing")
+}
+
+func TestApplyPKISpec_EncryptedKeys(t *testing.T) {
+	dir := t.TempDir()
+	passFile := filepath.Join(dir, "pass.txt")
+	require.NoError(t, os.WriteFile(passFile, []byte("secret\n"), 0600))
+	pki := &PKISpec{
+		OutputDir: dir,
+		CAs: []CASpec{
+			{Name: "root", Subject: "CN=root, OU=openqe", KeyPassphraseFile: passFile},
+			{Name: "intermediate", Parent: "root", Subject: "CN=intermediate, OU=openqe", KeyPassphraseFile: passFile},
+		},
+		Certs: []CertSpec{{Name: "server", CA: "intermediate", Subject: "CN=server, OU=openqe", KeyPassphraseFile: passFile}},
+	}
+	_, err := ApplyPKISpec(pki)
+	require.NoError(t, err)
+	results, err := ApplyPKISpec(pki)
+	require.NoError(t, err)
+	for _, r := range results {
+		assert.Equal(t, ApplyUnchanged, r.Action, "%s/%s: %s", r.Kind, r.Name, r.Reason)
+	}
+}
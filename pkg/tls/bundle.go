@@ -0,0 +1,238 @@
+package tls
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+	"time"
+)
+
+// CABundle is a bundle of CA certificates in PEM format, like /etc/pki/tls/certs/ca-bundle.crt or the
+// ca-bundle.crt key of a ConfigMap. The text between the certificates, like comments, is kept when it is edited.
+type CABundle struct {
+	entries []bundleEntry
+	// trailer is the text after the last certificate
+	trailer []byte
+}
+
+// bundleEntry is a certificate of a CABundle with the text in front of it, which is considered to describe it
+type bundleEntry struct {
+	header []byte
+	block  []byte
+	cert   *x509.Certificate
+}
+
+// ParseCABundle parses the PEM data of a CA bundle, the blocks which are not certificates are kept as text
+func ParseCABundle(data []byte) (*CABundle, error) {
+	bundle := &CABundle{}
+	var header []byte
+	rest := data
+	for {
+		block, next := pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		consumed := rest[:len(rest)-len(next)]
+		rest = next
+		if block.Type != "CERTIFICATE" {
+			header = append(header, consumed...)
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse certificate #%d of the bundle: %w", len(bundle.entries)+1, err)
+		}
+		begin := bytes.Index(consumed, []byte("-----BEGIN"))
+		bundle.entries = append(bundle.entries, bundleEntry{
+			header: append(header, consumed[:begin]...),
+			block:  consumed[begin:],
+			cert:   cert,
+		})
+		header = nil
+	}
+	bundle.trailer = append(header, rest...)
+	return bundle, nil
+}
+
+// Bytes returns the PEM data of the bundle
+func (b *CABundle) Bytes() []byte {
+	var buf bytes.Buffer
+	for _, e := range b.entries {
+		buf.Write(e.header)
+		buf.Write(e.block)
+		if !bytes.HasSuffix(e.block, []byte("\n")) {
+			buf.WriteString("\n")
+		}
+	}
+	buf.Write(b.trailer)
+	return buf.Bytes()
+}
+
+// Certificates returns the certificates of the bundle in order
+func (b *CABundle) Certificates() []*x509.Certificate {
+	certs := make([]*x509.Certificate, 0, len(b.entries))
+	for _, e := range b.entries {
+		certs = append(certs, e.cert)
+	}
+	return certs
+}
+
+// Contains checks whether a certificate with the same fingerprint is in the bundle
+func (b *CABundle) Contains(cert *x509.Certificate) bool {
+	for _, e := range b.entries {
+		if e.cert.Equal(cert) {
+			return true
+		}
+	}
+	return false
+}
+
+// Add appends the certificates which are not in the bundle yet, before the text after the last certificate,
+// and returns the added ones
+func (b *CABundle) Add(certs ...*x509.Certificate) []*x509.Certificate {
+	var added []*x509.Certificate
+	for _, cert := range certs {
+		if b.Contains(cert) {
+			continue
+		}
+		var header []byte
+		if len(b.entries) == 0 && len(b.trailer) > 0 {
+			// the text of a bundle without certificates is kept in front of the first one
+			header = b.trailer
+			if !bytes.HasSuffix(header, []byte("\n")) {
+				header = append(header, '\n')
+			}
+			b.trailer = nil
+		}
+		b.entries = append(b.entries, bundleEntry{
+			header: header,
+			block:  pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw}),
+			cert:   cert,
+		})
+		added = append(added, cert)
+	}
+	return added
+}
+
+// Remove removes the certificates with the SHA-256 fingerprints, with or without colons, and returns the removed ones
+func (b *CABundle) Remove(fingerprints ...string) []*x509.Certificate {
+	remove := map[string]bool{}
+	for _, fp := range fingerprints {
+		remove[normalizeFingerprint(fp)] = true
+	}
+	return b.removeIf(func(cert *x509.Certificate) bool {
+		return remove[normalizeFingerprint(Fingerprint(cert))]
+	})
+}
+
+// Dedupe removes the certificates which are already earlier in the bundle, and returns the removed ones
+func (b *CABundle) Dedupe() []*x509.Certificate {
+	seen := map[string]bool{}
+	return b.removeIf(func(cert *x509.Certificate) bool {
+		fp := Fingerprint(cert)
+		if seen[fp] {
+			return true
+		}
+		seen[fp] = true
+		return false
+	})
+}
+
+// PruneExpired removes the certificates which are expired at the time, and returns the removed ones
+func (b *CABundle) PruneExpired(at time.Time) []*x509.Certificate {
+	return b.removeIf(func(cert *x509.Certificate) bool {
+		return at.After(cert.NotAfter)
+	})
+}
+
+// removeIf removes the certificates matching fn together with the text in front of them, except the text
+// up to the last blank line, which describes the bundle or the previous certificates rather than the removed one
+func (b *CABundle) removeIf(fn func(cert *x509.Certificate) bool) []*x509.Certificate {
+	var removed []*x509.Certificate
+	var detached []byte
+	kept := b.entries[:0]
+	for _, e := range b.entries {
+		if fn(e.cert) {
+			removed = append(removed, e.cert)
+			detached = append(detached, detachedText(e.header)...)
+			continue
+		}
+		if len(detached) > 0 {
+			e.header = append(detached, e.header...)
+			detached = nil
+		}
+		kept = append(kept, e)
+	}
+	b.entries = kept
+	b.trailer = append(detached, b.trailer...)
+	return removed
+}
+
+// detachedText returns the text up to the last blank line
+func detachedText(text []byte) []byte {
+	end := 0
+	for i := 0; i < len(text); {
+		n := bytes.IndexByte(text[i:], '\n')
+		if n < 0 {
+			break
+		}
+		if len(bytes.TrimSpace(text[i:i+n])) == 0 {
+			end = i + n + 1
+		}
+		i += n + 1
+	}
+	return text[:end]
+}
+
+// Fingerprint returns the SHA-256 fingerprint of a certificate in colon separated hex, like in the CertificateSummary
+func Fingerprint(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.Raw)
+	return colonHex(sum[:])
+}
+
+func normalizeFingerprint(fp string) string {
+	return strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(fp), ":", ""))
+}
+
+// EditCABundleFile edits the CA bundle file with edit and writes it back if it is changed.
+// The original file is copied to backupFile before it is overwritten, unless backupFile is empty.
+// A file which does not exist is edited as an empty bundle. It returns whether the file is changed
+// and whether the backup is written.
+func EditCABundleFile(file, backupFile string, edit func(*CABundle) error) (bool, bool, error) {
+	mode := fs.FileMode(0644)
+	data, err := os.ReadFile(file)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return false, false, fmt.Errorf("failed to read CA bundle file: %w", err)
+	}
+	if info, err := os.Stat(file); err == nil {
+		mode = info.Mode().Perm()
+	}
+	bundle, err := ParseCABundle(data)
+	if err != nil {
+		return false, false, err
+	}
+	if err := edit(bundle); err != nil {
+		return false, false, err
+	}
+	edited := bundle.Bytes()
+	if bytes.Equal(data, edited) {
+		return false, false, nil
+	}
+	backedUp := false
+	if backupFile != "" && data != nil {
+		if err := os.WriteFile(backupFile, data, mode); err != nil {
+			return false, false, fmt.Errorf("failed to write the backup of CA bundle file: %w", err)
+		}
+		backedUp = true
+	}
+	if err := os.WriteFile(file, edited, mode); err != nil {
+		return false, backedUp, fmt.Errorf("failed to write CA bundle file: %w", err)
+	}
+	return true, backedUp, nil
+}
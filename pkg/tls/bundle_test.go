@@ -0,0 +1,83 @@
+package tls
+
+import (
+	"crypto/x509"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCABundle(t *testing.T) {
+	_, caA, err := GenerateCAWith("CN=a, OU=openqe", "")
+	require.NoError(t, err)
+	_, caB, err := GenerateCAWith("CN=b, OU=openqe", "")
+	require.NoError(t, err)
+	cfg := defaultCertCfg()
+	cfg.IsCA = true
+	cfg.NotBefore = time.Now().Add(-2 * ValidityOneDay)
+	cfg.NotAfter = time.Now().Add(-ValidityOneDay)
+	_, expired, err := GenerateSelfSignedCertificate(&cfg)
+	require.NoError(t, err)
+
+	original := "# test bundle\n\n# CA a\n" + string(CertToPem(caA)) + "# end\n"
+	dir := t.TempDir()
+	file := filepath.Join(dir, "ca-bundle.crt")
+	backup := file + ".bak"
+	require.NoError(t, os.WriteFile(file, []byte(original), 0600))
+
+	changed, backedUp, err := EditCABundleFile(file, backup, func(b *CABundle) error {
+		assert.Len(t, b.Add(caA, caB, expired, caB), 2)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.True(t, changed)
+	assert.True(t, backedUp)
+	data, err := os.ReadFile(backup)
+	require.NoError(t, err)
+	assert.Equal(t, original, string(data))
+	data, err = os.ReadFile(file)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(data), original[:len(original)-len("# end\n")]))
+	assert.True(t, strings.HasSuffix(string(data), "# end\n"))
+	info, err := os.Stat(file)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	// a re-run changes nothing
+	changed, backedUp, err = EditCABundleFile(file, backup, func(b *CABundle) error {
+		assert.Empty(t, b.Add(caA, caB))
+		return nil
+	})
+	require.NoError(t, err)
+	assert.False(t, changed)
+	assert.False(t, backedUp)
+
+	// a new file has no backup
+	newFile := filepath.Join(dir, "new-bundle.crt")
+	changed, backedUp, err = EditCABundleFile(newFile, newFile+".bak", func(b *CABundle) error {
+		assert.Len(t, b.Add(caA), 1)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.True(t, changed)
+	assert.False(t, backedUp)
+	assert.NoFileExists(t, newFile+".bak")
+
+	// the text up to the blank line in front of a removed certificate is kept
+	bundle, err := ParseCABundle(append(append(data, '\n'), CertToPem(caB)...))
+	require.NoError(t, err)
+	assert.Len(t, bundle.Certificates(), 4)
+	assert.Equal(t, []*x509.Certificate{caB}, bundle.Dedupe())
+	assert.Equal(t, []*x509.Certificate{expired}, bundle.PruneExpired(time.Now()))
+	assert.Equal(t, []*x509.Certificate{caA}, bundle.Remove(strings.ToLower(strings.ReplaceAll(Fingerprint(caA), ":", ""))))
+	assert.Equal(t, []*x509.Certificate{caB}, bundle.Certificates())
+	out := string(bundle.Bytes())
+	assert.True(t, strings.HasPrefix(out, "# test bundle\n\n-----BEGIN CERTIFICATE-----"))
+	assert.NotContains(t, out, "# CA a")
+	assert.True(t, strings.HasSuffix(out, "# end\n\n"))
+}
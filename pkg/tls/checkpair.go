@@ -0,0 +1,162 @@
+package tls
+
+import (
+	"crypto"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+)
+
+// CheckPairResult is the result of checking a key/cert pair, the pair passes the check when there is no problem
+type CheckPairResult struct {
+	Source      string             `json:"source"`
+	Certificate CertificateSummary `json:"certificate"`
+	KeyMatches  bool               `json:"keyMatches"`
+	Remaining   string             `json:"remaining"`
+	Problems    []string           `json:"problems,omitempty"`
+}
+
+// OK tells whether the key/cert pair passes the check
+func (r *CheckPairResult) OK() bool {
+	return len(r.Problems) == 0
+}
+
+// CheckPairFiles checks the key/cert pair files of the options like CheckKeyPair
+func CheckPairFiles(opts *CheckPairOptions) (*CheckPairResult, error) {
+	if opts.KeyFile == "" || opts.CertFile == "" {
+		return nil, errors.New("both keyFile and certFile need to be specified to check a key/cert pair")
+	}
+	keyBytes, err := os.ReadFile(opts.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read private key file: %w", err)
+	}
+	certBytes, err := os.ReadFile(opts.CertFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read certificate file: %w", err)
+	}
+	return CheckKeyPair(keyBytes, certBytes, fmt.Sprintf("%s, %s", opts.KeyFile, opts.CertFile), opts)
+}
+
+// CheckKeyPair checks that the private key matches the first certificate of the PEM data, and validates the
+// certificate with the expected subject and SANs of the options and the minimum remaining validity like
+// ValidateKeyPair. The properties which are not set in the options are expected to be the ones of the certificate.
+// An error is returned only when the key or the certificate can not be parsed, the failed checks are the problems
+// of the result.
+func CheckKeyPair(keyPEM, certPEM []byte, source string, opts *CheckPairOptions) (*CheckPairResult, error) {
+	passphrase, err := readPassphrase(opts.KeyPassphraseFile)
+	if err != nil {
+		return nil, err
+	}
+	key, err := ParsePrivateKey(keyPEM, passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load private key: %w", err)
+	}
+	certs, err := PemToCertificates(certPEM)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load certificate: %w", err)
+	}
+	cert := certs[0]
+	result := &CheckPairResult{
+		Source:      source,
+		Certificate: SummarizeCertificate(cert),
+		Remaining:   time.Until(cert.NotAfter).Round(time.Second).String(),
+	}
+	if pub, ok := key.Public().(interface{ Equal(crypto.PublicKey) bool }); ok && pub.Equal(cert.PublicKey) {
+		result.KeyMatches = true
+	} else {
+		result.Problems = append(result.Problems, "private key does not match certificate")
+	}
+
+	cfg, err := opts.expectedCertCfg(cert)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateCertificate(cert, cfg, opts.MinRemaining); err != nil {
+		for _, e := range flattenErrors(err) {
+			result.Problems = append(result.Problems, e.Error())
+		}
+	}
+	return result, nil
+}
+
+// expectedCertCfg returns the CertCfg of the certificate, with the expected subject and SANs of the options
+func (o *CheckPairOptions) expectedCertCfg(cert *x509.Certificate) (*CertCfg, error) {
+	cfg := &CertCfg{
+		DNSNames:       cert.DNSNames,
+		IPAddresses:    cert.IPAddresses,
+		URIs:           cert.URIs,
+		EmailAddresses: cert.EmailAddresses,
+		ExtKeyUsages:   cert.ExtKeyUsage,
+		KeyUsages:      cert.KeyUsage,
+		Subject:        cert.Subject,
+		Validity:       cert.NotAfter.Sub(cert.NotBefore),
+		IsCA:           cert.IsCA,
+	}
+	if o.Subject != "" {
+		subject, err := ParseSubject(o.Subject)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Subject = subject
+	}
+	expected := &CertCfg{}
+	if err := o.SANOptions.apply(expected); err != nil {
+		return nil, err
+	}
+	if len(o.DNSNames) > 0 {
+		cfg.DNSNames = expected.DNSNames
+	}
+	if len(o.IPAddresses) > 0 {
+		cfg.IPAddresses = expected.IPAddresses
+	}
+	if len(o.URIs) > 0 {
+		cfg.URIs = expected.URIs
+	}
+	if len(o.EmailAddresses) > 0 {
+		cfg.EmailAddresses = expected.EmailAddresses
+	}
+	return cfg, nil
+}
+
+// flattenErrors returns the errors of an aggregate error, or err itself
+func flattenErrors(err error) []error {
+	var aggregate utilerrors.Aggregate
+	if errors.As(err, &aggregate) {
+		return aggregate.Errors()
+	}
+	return []error{err}
+}
+
+// WriteCheckPairResult writes the check result to w in the output format: text or json
+func WriteCheckPairResult(w io.Writer, result *CheckPairResult, format string) error {
+	switch format {
+	case OutputFormatJSON:
+		data, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(data))
+		return err
+	case "", OutputFormatText:
+		fmt.Fprintf(w, "Source:           %s\n", result.Source)
+		fmt.Fprintf(w, "Key Matches:      %t\n", result.KeyMatches)
+		fmt.Fprintf(w, "Remaining:        %s\n", result.Remaining)
+		if result.OK() {
+			fmt.Fprintf(w, "Check:            OK\n")
+		} else {
+			fmt.Fprintf(w, "Check:            FAILED\n")
+			for _, problem := range result.Problems {
+				fmt.Fprintf(w, "  - %s\n", problem)
+			}
+		}
+		fmt.Fprintln(w)
+		return WriteCertificateSummaries(w, []CertificateSummary{result.Certificate}, format)
+	}
+	return fmt.Errorf("unsupported output format: %s, supported: %s, %s", format, OutputFormatText, OutputFormatJSON)
+}
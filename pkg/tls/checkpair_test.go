@@ -0,0 +1,49 @@
+package tls
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckKeyPair(t *testing.T) {
+	caOpts, dir := newTestCA(t)
+	opts := DefaultPKIOptions()
+	opts.CaGenOpt = caOpts
+	opts.KeyFile = filepath.Join(dir, "tls.key")
+	opts.CertFile = filepath.Join(dir, "tls.crt")
+	opts.DNSNames = []string{"a.openqe.github.io"}
+	opts.IPAddresses = []string{"10.0.0.1"}
+	opts.Validity = 10 * ValidityOneDay
+	require.NoError(t, GenerateTLSKeyCertPairToFiles(opts))
+
+	checkOpts := DefaultCheckPairOptions()
+	checkOpts.KeyFile = opts.KeyFile
+	checkOpts.CertFile = opts.CertFile
+	checkOpts.DNSNames = opts.DNSNames
+	checkOpts.Subject = opts.Subject
+	checkOpts.MinRemaining = 9 * ValidityOneDay
+	result, err := CheckPairFiles(checkOpts)
+	require.NoError(t, err)
+	assert.True(t, result.OK(), result.Problems)
+	assert.True(t, result.KeyMatches)
+
+	// the IP addresses are not checked unless they are expected
+	checkOpts.DNSNames = []string{"b.openqe.github.io"}
+	checkOpts.MinRemaining = 30 * ValidityOneDay
+	checkOpts.KeyFile = caOpts.CaKeyFile
+	result, err = CheckPairFiles(checkOpts)
+	require.NoError(t, err)
+	assert.False(t, result.KeyMatches)
+	require.Len(t, result.Problems, 3)
+	assert.Contains(t, result.Problems[0], "does not match")
+	assert.Contains(t, result.Problems[1], "dns names differ")
+	assert.Contains(t, result.Problems[2], "remaining validity")
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteCheckPairResult(&buf, result, OutputFormatText))
+	assert.Contains(t, buf.String(), "Check:            FAILED")
+}
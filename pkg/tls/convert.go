@@ -0,0 +1,402 @@
+package tls
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/openqe/openqe/pkg/common"
+	"github.com/youmark/pkcs8"
+)
+
+const (
+	// KeyFormatPKCS1 is the RSA PRIVATE KEY or RSA PUBLIC KEY format, for RSA keys only
+	KeyFormatPKCS1 = "pkcs1"
+	// KeyFormatPKCS8 is the PRIVATE KEY format, or ENCRYPTED PRIVATE KEY when a passphrase is given
+	KeyFormatPKCS8 = "pkcs8"
+	// KeyFormatSEC1 is the EC PRIVATE KEY format, for ECDSA keys only
+	KeyFormatSEC1 = "sec1"
+	// KeyFormatPKIX is the PUBLIC KEY format of a SubjectPublicKeyInfo
+	KeyFormatPKIX = "pkix"
+	// KeyFormatJWK is the JSON Web Key of a public key
+	KeyFormatJWK = "jwk"
+
+	EncodingPEM = "pem"
+	EncodingDER = "der"
+)
+
+// ErrEncryptedKey is returned when an encrypted private key is parsed without a passphrase
+var ErrEncryptedKey = errors.New("the private key is encrypted, a passphrase is required")
+
+// pkcs8Opts encrypts PKCS#8 private keys with AES-256-CBC and a PBKDF2-SHA256 derived key
+var pkcs8Opts = &pkcs8.Opts{
+	Cipher: pkcs8.AES256CBC,
+	KDFOpts: pkcs8.PBKDF2Opts{
+		SaltSize:       16,
+		IterationCount: 100000,
+		HMACHash:       crypto.SHA256,
+	},
+}
+
+// readPassphrase reads the passphrase in the file, it returns nil when the file is not set
+func readPassphrase(file string) ([]byte, error) {
+	if file == "" {
+		return nil, nil
+	}
+	passphrase, err := common.ReadSecret(file, "")
+	if err != nil {
+		return nil, err
+	}
+	if passphrase == "" {
+		return nil, fmt.Errorf("the passphrase file %s is empty", file)
+	}
+	return []byte(passphrase), nil
+}
+
+// encodePrivateKey converts the private key to PEM like PrivateKeyToPem, or to an encrypted PKCS#8 PEM block
+// with the passphrase in the passphrase file when it is set
+func encodePrivateKey(key crypto.Signer, passphraseFile string) ([]byte, error) {
+	passphrase, err := readPassphrase(passphraseFile)
+	if err != nil {
+		return nil, err
+	}
+	if passphrase == nil {
+		return PrivateKeyToPem(key)
+	}
+	block, err := MarshalPrivateKey(key, KeyFormatPKCS8, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(block), nil
+}
+
+// ParsePrivateKey parses a RSA, ECDSA or Ed25519 private key in PEM or DER, as PKCS#1, SEC1, PKCS#8 or
+// encrypted PKCS#8 with the passphrase. ErrEncryptedKey is returned for an encrypted key without a passphrase.
+func ParsePrivateKey(data, passphrase []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return parseDERPrivateKey(data, passphrase)
+	}
+	if _, ok := block.Headers["Proc-Type"]; ok {
+		return nil, errors.New("legacy encrypted PEM private keys are not supported, convert it to encrypted PKCS#8")
+	}
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		return x509.ParseECPrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		return toSigner(key)
+	case "ENCRYPTED PRIVATE KEY":
+		return parseEncryptedPKCS8(block.Bytes, passphrase)
+	}
+	return nil, fmt.Errorf("unsupported PEM block type %q in the private key", block.Type)
+}
+
+// parseDERPrivateKey tries the DER private key formats in turn
+func parseDERPrivateKey(der, passphrase []byte) (crypto.Signer, error) {
+	if key, err := x509.ParsePKCS8PrivateKey(der); err == nil {
+		return toSigner(key)
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(der); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParseECPrivateKey(der); err == nil {
+		return key, nil
+	}
+	if key, err := parseEncryptedPKCS8(der, passphrase); err == nil || errors.Is(err, ErrEncryptedKey) {
+		return key, err
+	} else if passphrase != nil {
+		return nil, err
+	}
+	return nil, errors.New("could not find a PEM block or a DER encoded PKCS#1, PKCS#8 or SEC1 private key")
+}
+
+// encryptedPrivateKeyInfo is the EncryptedPrivateKeyInfo structure of RFC 5208
+type encryptedPrivateKeyInfo struct {
+	EncryptionAlgorithm pkix.AlgorithmIdentifier
+	EncryptedData       []byte
+}
+
+func parseEncryptedPKCS8(der, passphrase []byte) (crypto.Signer, error) {
+	if passphrase == nil {
+		var info encryptedPrivateKeyInfo
+		if rest, err := asn1.Unmarshal(der, &info); err != nil || len(rest) > 0 {
+			return nil, errors.New("invalid encrypted PKCS#8 private key")
+		}
+		return nil, ErrEncryptedKey
+	}
+	key, err := pkcs8.ParsePKCS8PrivateKey(der, passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt the private key, the passphrase may be wrong: %w", err)
+	}
+	return toSigner(key)
+}
+
+func toSigner(key any) (crypto.Signer, error) {
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported private key type %T", key)
+	}
+	return signer, nil
+}
+
+// MarshalPrivateKey converts the private key to a PEM block in the format: pkcs1, pkcs8 or sec1.
+// With a passphrase, the key is encrypted and the format has to be pkcs8.
+func MarshalPrivateKey(key crypto.Signer, format string, passphrase []byte) (*pem.Block, error) {
+	if passphrase != nil && format != KeyFormatPKCS8 {
+		return nil, fmt.Errorf("only %s private keys can be encrypted", KeyFormatPKCS8)
+	}
+	switch format {
+	case KeyFormatPKCS1:
+		k, ok := key.(*rsa.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("the %s format is for RSA keys only, not %T", format, key)
+		}
+		return &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(k)}, nil
+	case KeyFormatSEC1:
+		k, ok := key.(*ecdsa.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("the %s format is for ECDSA keys only, not %T", format, key)
+		}
+		der, err := x509.MarshalECPrivateKey(k)
+		if err != nil {
+			return nil, err
+		}
+		return &pem.Block{Type: "EC PRIVATE KEY", Bytes: der}, nil
+	case KeyFormatPKCS8:
+		if passphrase != nil {
+			der, err := pkcs8.MarshalPrivateKey(key, passphrase, pkcs8Opts)
+			if err != nil {
+				return nil, fmt.Errorf("failed to encrypt the private key: %w", err)
+			}
+			return &pem.Block{Type: "ENCRYPTED PRIVATE KEY", Bytes: der}, nil
+		}
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+		if err != nil {
+			return nil, err
+		}
+		return &pem.Block{Type: "PRIVATE KEY", Bytes: der}, nil
+	}
+	return nil, fmt.Errorf("unsupported private key format: %s, supported: %s, %s, %s", format, KeyFormatPKCS1, KeyFormatPKCS8, KeyFormatSEC1)
+}
+
+// ParsePublicKey parses a public key in PEM or DER as PKIX or PKCS#1. The public key of a certificate, or of a
+// private key in any of the formats accepted by ParsePrivateKey, is returned too.
+func ParsePublicKey(data, passphrase []byte) (crypto.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		if pub, err := x509.ParsePKIXPublicKey(data); err == nil {
+			return pub, nil
+		}
+		if pub, err := x509.ParsePKCS1PublicKey(data); err == nil {
+			return pub, nil
+		}
+		if cert, err := x509.ParseCertificate(data); err == nil {
+			return cert.PublicKey, nil
+		}
+	} else {
+		switch block.Type {
+		case "PUBLIC KEY":
+			return x509.ParsePKIXPublicKey(block.Bytes)
+		case "RSA PUBLIC KEY":
+			return x509.ParsePKCS1PublicKey(block.Bytes)
+		case "CERTIFICATE":
+			cert, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				return nil, err
+			}
+			return cert.PublicKey, nil
+		}
+	}
+	key, err := ParsePrivateKey(data, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return key.Public(), nil
+}
+
+// MarshalPublicKey converts the public key to a PEM block in the format: pkix or pkcs1
+func MarshalPublicKey(pub crypto.PublicKey, format string) (*pem.Block, error) {
+	switch format {
+	case KeyFormatPKIX:
+		der, err := x509.MarshalPKIXPublicKey(pub)
+		if err != nil {
+			return nil, err
+		}
+		return &pem.Block{Type: "PUBLIC KEY", Bytes: der}, nil
+	case KeyFormatPKCS1:
+		k, ok := pub.(*rsa.PublicKey)
+		if !ok {
+			return nil, fmt.Errorf("the %s format is for RSA keys only, not %T", format, pub)
+		}
+		return &pem.Block{Type: "RSA PUBLIC KEY", Bytes: x509.MarshalPKCS1PublicKey(k)}, nil
+	}
+	return nil, fmt.Errorf("unsupported public key format: %s, supported: %s, %s, %s", format, KeyFormatPKIX, KeyFormatPKCS1, KeyFormatJWK)
+}
+
+// JWK is a JSON Web Key (RFC 7517) of a RSA, ECDSA or Ed25519 public key
+type JWK struct {
+	Kty string `json:"kty"`
+	// Kid is the RFC 7638 thumbprint of the key
+	Kid string `json:"kid,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// NewJWK converts the public key to a JWK identified by its thumbprint
+func NewJWK(pub crypto.PublicKey) (*JWK, error) {
+	b64 := base64.RawURLEncoding.EncodeToString
+	var jwk *JWK
+	switch k := pub.(type) {
+	case *rsa.PublicKey:
+		jwk = &JWK{Kty: "RSA", N: b64(k.N.Bytes()), E: b64(big.NewInt(int64(k.E)).Bytes())}
+	case *ecdsa.PublicKey:
+		ecdh, err := k.ECDH()
+		if err != nil {
+			return nil, err
+		}
+		// the uncompressed point is 0x04 || x || y, with coordinates of the curve size
+		point := ecdh.Bytes()[1:]
+		size := len(point) / 2
+		jwk = &JWK{Kty: "EC", Crv: k.Curve.Params().Name, X: b64(point[:size]), Y: b64(point[size:])}
+	case ed25519.PublicKey:
+		jwk = &JWK{Kty: "OKP", Crv: "Ed25519", X: b64(k)}
+	default:
+		return nil, fmt.Errorf("unsupported public key type %T", pub)
+	}
+	thumbprint, err := jwk.Thumbprint()
+	if err != nil {
+		return nil, err
+	}
+	jwk.Kid = thumbprint
+	return jwk, nil
+}
+
+// Thumbprint returns the base64url SHA-256 thumbprint of the JWK as defined by RFC 7638
+func (k *JWK) Thumbprint() (string, error) {
+	// the required members of the key type in lexicographic order
+	required := struct {
+		Crv string `json:"crv,omitempty"`
+		E   string `json:"e,omitempty"`
+		Kty string `json:"kty"`
+		N   string `json:"n,omitempty"`
+		X   string `json:"x,omitempty"`
+		Y   string `json:"y,omitempty"`
+	}{Crv: k.Crv, E: k.E, Kty: k.Kty, N: k.N, X: k.X, Y: k.Y}
+	data, err := json.Marshal(required)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return base64.RawURLEncoding.EncodeToString(sum[:]), nil
+}
+
+// ConvertKey converts the key in the input file as defined by the options and returns the converted data
+func ConvertKey(opts *ConvertOptions) ([]byte, error) {
+	if opts.InFile == "" {
+		return nil, errors.New("inFile needs to be specified to convert a key")
+	}
+	data, err := os.ReadFile(opts.InFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key file: %w", err)
+	}
+	passphrase, err := readPassphrase(opts.PassphraseFile)
+	if err != nil {
+		return nil, err
+	}
+	outPassphrase, err := readPassphrase(opts.OutPassphraseFile)
+	if err != nil {
+		return nil, err
+	}
+	encoding := opts.Encoding
+	if encoding == "" {
+		encoding = EncodingPEM
+	}
+	if encoding != EncodingPEM && encoding != EncodingDER {
+		return nil, fmt.Errorf("unsupported encoding: %s, supported: %s, %s", encoding, EncodingPEM, EncodingDER)
+	}
+
+	var block *pem.Block
+	format := opts.Format
+	if opts.Public || format == KeyFormatPKIX || format == KeyFormatJWK {
+		if outPassphrase != nil {
+			return nil, errors.New("a public key can not be encrypted")
+		}
+		pub, err := ParsePublicKey(data, passphrase)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load public key from %s: %w", opts.InFile, err)
+		}
+		if format == KeyFormatJWK {
+			if encoding != EncodingPEM {
+				return nil, fmt.Errorf("a JWK can not be %s encoded", encoding)
+			}
+			jwk, err := NewJWK(pub)
+			if err != nil {
+				return nil, err
+			}
+			out, err := json.MarshalIndent(jwk, "", "  ")
+			if err != nil {
+				return nil, err
+			}
+			return append(out, '\n'), nil
+		}
+		if format == "" {
+			format = KeyFormatPKIX
+		}
+		if block, err = MarshalPublicKey(pub, format); err != nil {
+			return nil, err
+		}
+	} else {
+		key, err := ParsePrivateKey(data, passphrase)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load private key from %s: %w", opts.InFile, err)
+		}
+		if format == "" {
+			format = KeyFormatPKCS8
+		}
+		if block, err = MarshalPrivateKey(key, format, outPassphrase); err != nil {
+			return nil, err
+		}
+	}
+	if encoding == EncodingDER {
+		return block.Bytes, nil
+	}
+	return pem.EncodeToMemory(block), nil
+}
+
+// ConvertKeyToFile converts the key like ConvertKey and writes it to the output file, private keys are
+// readable by the owner only
+func ConvertKeyToFile(opts *ConvertOptions) error {
+	if opts.OutFile == "" {
+		return errors.New("outFile needs to be specified to save the converted key")
+	}
+	out, err := ConvertKey(opts)
+	if err != nil {
+		return err
+	}
+	mode := os.FileMode(0600)
+	if opts.Public || opts.Format == KeyFormatPKIX || opts.Format == KeyFormatJWK {
+		mode = 0644
+	}
+	return os.WriteFile(opts.OutFile, out, mode)
+}
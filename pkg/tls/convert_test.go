@@ -0,0 +1,149 @@
+package tls
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvertKey(t *testing.T) {
+	passFile := filepath.Join(t.TempDir(), "pass.txt")
+	require.NoError(t, os.WriteFile(passFile, []byte("secret\n"), 0600))
+	caOpts, dir := newTestCA(t, func(o *CAOptions) {
+		o.Subject = "CN=encrypted, OU=openqe"
+		o.KeyPassphraseFile = passFile
+	})
+	caKeyBytes, err := os.ReadFile(caOpts.CaKeyFile)
+	require.NoError(t, err)
+	_, err = PemToPrivateKey(caKeyBytes)
+	assert.ErrorIs(t, err, ErrEncryptedKey)
+	_, err = ParsePrivateKey(caKeyBytes, []byte("wrong"))
+	assert.Error(t, err)
+
+	// the encrypted CA key signs with its passphrase only
+	opts := DefaultPKIOptions()
+	opts.CaGenOpt = &CAOptions{CaKeyFile: caOpts.CaKeyFile, CaCertFile: caOpts.CaCertFile}
+	opts.KeyFile = filepath.Join(dir, "tls.key")
+	opts.CertFile = filepath.Join(dir, "tls.crt")
+	opts.KeyAlgorithm = string(KeyAlgorithmECDSA)
+	assert.ErrorIs(t, GenerateTLSKeyCertPairToFiles(opts), ErrEncryptedKey)
+	opts.CaGenOpt.KeyPassphraseFile = passFile
+	require.NoError(t, GenerateTLSKeyCertPairToFiles(opts))
+	cert := loadCertificate(t, opts.CertFile)
+
+	for _, format := range []string{KeyFormatPKCS8, KeyFormatSEC1} {
+		for _, encoding := range []string{EncodingPEM, EncodingDER} {
+			convertOpts := DefaultConvertOptions()
+			convertOpts.InFile = opts.KeyFile
+			convertOpts.Format = format
+			convertOpts.Encoding = encoding
+			convertOpts.OutFile = filepath.Join(dir, "tls-"+format+"."+encoding)
+			require.NoError(t, ConvertKeyToFile(convertOpts), "%s %s", format, encoding)
+
+			// the converted key is read back by PemToPrivateKey in any format and encoding
+			data, err := os.ReadFile(convertOpts.OutFile)
+			require.NoError(t, err)
+			key, err := PemToPrivateKey(data)
+			require.NoError(t, err, "%s %s", format, encoding)
+			assert.True(t, key.Public().(*ecdsa.PublicKey).Equal(cert.PublicKey))
+		}
+	}
+
+	convertOpts := DefaultConvertOptions()
+	convertOpts.InFile = filepath.Join(dir, "tls-sec1.der")
+	convertOpts.Format = KeyFormatPKCS1
+	_, err = ConvertKey(convertOpts)
+	assert.Error(t, err, "pkcs1 is for RSA keys only")
+	convertOpts.Format = KeyFormatSEC1
+	convertOpts.OutPassphraseFile = passFile
+	_, err = ConvertKey(convertOpts)
+	assert.Error(t, err, "only pkcs8 keys can be encrypted")
+
+	convertOpts.Format = KeyFormatPKCS8
+	encrypted, err := ConvertKey(convertOpts)
+	require.NoError(t, err)
+	block, _ := pem.Decode(encrypted)
+	require.NotNil(t, block)
+	assert.Equal(t, "ENCRYPTED PRIVATE KEY", block.Type)
+	key, err := ParsePrivateKey(block.Bytes, []byte("secret"))
+	require.NoError(t, err, "encrypted DER is accepted too")
+	assert.True(t, key.Public().(*ecdsa.PublicKey).Equal(cert.PublicKey))
+
+	// the public key of the certificate as a JWK
+	jwkOpts := &ConvertOptions{InFile: opts.CertFile, Format: KeyFormatJWK}
+	data, err := ConvertKey(jwkOpts)
+	require.NoError(t, err)
+	var jwk JWK
+	require.NoError(t, json.Unmarshal(data, &jwk))
+	assert.Equal(t, "EC", jwk.Kty)
+	assert.Equal(t, "P-256", jwk.Crv)
+	assert.Len(t, jwk.X, 43)
+	assert.Len(t, jwk.Y, 43)
+
+	// the RFC 7638 example
+	rfcKey := &JWK{
+		Kty: "RSA",
+		N:   "0vx7agoebGcQSuuPiLJXZptN9nndrQmbXEps2aiAFbWhM78LhWx4cbbfAAtVT86zwu1RK7aPFFxuhDR1L6tSoc_BJECPebWKRXjBZCiFV4n3oknjhMstn64tZ_2W-5JsGY4Hc5n9yBXArwl93lqt7_RN5w6Cf0h4QyQ5v-65YGjQR0_FDW2QvzqY368QQMicAtaSqzs8KJZgnYb9c7d0zgdAZHzu6qMQvRL5hajrn1n91CbOpbISD08qNLyrdkt-bFTWhAI4vMQFh6WeZu0fM4lFd2NcRwr3XPksINHaQ-G_xBniIqbw0Ls1jF44-csFCur-kEgU8awapJzKnqDKgw",
+		E:   "AQAB",
+	}
+	thumbprint, err := rfcKey.Thumbprint()
+	require.NoError(t, err)
+	assert.Equal(t, "NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs", thumbprint)
+}
+
+func TestEncryptedKeyInputs(t *testing.T) {
+	passFile := filepath.Join(t.TempDir(), "pass.txt")
+	require.NoError(t, os.WriteFile(passFile, []byte("secret\n"), 0600))
+	root, dir := newTestCA(t, func(o *CAOptions) { o.KeyPassphraseFile = passFile })
+
+	// an encrypted intermediate CA signed by the encrypted root CA
+	intermediate := DefaultCAOptions()
+	intermediate.Subject = "CN=intermediate, OU=openqe"
+	intermediate.ParentCaKeyFile = root.CaKeyFile
+	intermediate.ParentCaCertFile = root.CaCertFile
+	intermediate.CaKeyFile = filepath.Join(dir, "intermediate.key")
+	intermediate.CaCertFile = filepath.Join(dir, "intermediate.crt")
+	intermediate.KeyPassphraseFile = passFile
+	assert.ErrorIs(t, GenerateCAToFiles(intermediate), ErrEncryptedKey)
+	intermediate.ParentKeyPassphraseFile = passFile
+	require.NoError(t, GenerateCAToFiles(intermediate))
+
+	opts := DefaultPKIOptions()
+	opts.CaGenOpt = intermediate
+	opts.KeyFile = filepath.Join(dir, "tls.key")
+	opts.CertFile = filepath.Join(dir, "tls.crt")
+	opts.KeyPassphraseFile = passFile
+	require.NoError(t, GenerateTLSKeyCertPairToFiles(opts))
+
+	checkOpts := &CheckPairOptions{KeyFile: opts.KeyFile, CertFile: opts.CertFile}
+	_, err := CheckPairFiles(checkOpts)
+	assert.ErrorIs(t, err, ErrEncryptedKey)
+	checkOpts.KeyPassphraseFile = passFile
+	result, err := CheckPairFiles(checkOpts)
+	require.NoError(t, err)
+	assert.True(t, result.KeyMatches)
+
+	renewOpts := DefaultRenewOptions()
+	renewOpts.KeyFile = opts.KeyFile
+	renewOpts.CertFile = opts.CertFile
+	renewOpts.CaGenOpt = intermediate
+	renewOpts.OutFile = filepath.Join(dir, "renewed.crt")
+	assert.ErrorIs(t, RenewToFile(renewOpts), ErrEncryptedKey)
+	renewOpts.KeyPassphraseFile = passFile
+	require.NoError(t, RenewToFile(renewOpts))
+
+	exportOpts := DefaultExportOptions()
+	exportOpts.KeyFile = opts.KeyFile
+	exportOpts.CertFile = opts.CertFile
+	exportOpts.OutFile = filepath.Join(dir, "tls.p12")
+	exportOpts.PasswordFile = passFile
+	assert.ErrorIs(t, ExportToFile(exportOpts), ErrEncryptedKey)
+	exportOpts.KeyPassphraseFile = passFile
+	require.NoError(t, ExportToFile(exportOpts))
+}
@@ -0,0 +1,318 @@
+package tls
+
+import (
+	"crypto"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/openqe/openqe/pkg/utils"
+)
+
+// RevocationReasons maps the names of the CRL reason codes defined in RFC 5280 to their values
+var RevocationReasons = map[string]int{
+	"unspecified":          0,
+	"keyCompromise":        1,
+	"cACompromise":         2,
+	"affiliationChanged":   3,
+	"superseded":           4,
+	"cessationOfOperation": 5,
+	"certificateHold":      6,
+	"removeFromCRL":        8,
+	"privilegeWithdrawn":   9,
+	"aACompromise":         10,
+}
+
+// CertificateRecord is an entry of the CA database, a certificate is revoked when RevokedAt is set
+type CertificateRecord struct {
+	SerialNumber string     `json:"serialNumber"`
+	Subject      string     `json:"subject,omitempty"`
+	NotAfter     time.Time  `json:"notAfter,omitzero"`
+	RevokedAt    *time.Time `json:"revokedAt,omitempty"`
+	ReasonCode   int        `json:"reasonCode,omitempty"`
+}
+
+// CADatabase keeps the records of the certificates issued and revoked by a CA, it is saved as JSON alongside the CA files
+type CADatabase struct {
+	// CRLNumber is the number of the last generated CRL
+	CRLNumber    int64               `json:"crlNumber"`
+	Certificates []CertificateRecord `json:"certificates"`
+}
+
+// CADatabaseFile returns the default CA database file of a CA certificate file, like ca.db.json for ca.crt
+func CADatabaseFile(caCertFile string) string {
+	return strings.TrimSuffix(caCertFile, filepath.Ext(caCertFile)) + ".db.json"
+}
+
+// LoadCADatabase loads a CA database from a file, an empty database is returned if the file does not exist
+func LoadCADatabase(dbFile string) (*CADatabase, error) {
+	db := &CADatabase{}
+	if !utils.FileExists(dbFile) {
+		return db, nil
+	}
+	data, err := os.ReadFile(dbFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA database file: %w", err)
+	}
+	if err := json.Unmarshal(data, db); err != nil {
+		return nil, fmt.Errorf("failed to parse CA database file %s: %w", dbFile, err)
+	}
+	return db, nil
+}
+
+// Save saves the CA database to a file. The file is replaced at once, so its readers never see a partial file.
+func (db *CADatabase) Save(dbFile string) error {
+	data, err := json.MarshalIndent(db, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(dbFile), filepath.Base(dbFile)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), dbFile)
+}
+
+// caDatabaseLockTimeout is how long to wait for the lock of a CA database held by another process
+const caDatabaseLockTimeout = 10 * time.Second
+
+// updateCADatabase loads the CA database file, changes it by fn and saves it, while holding a lock file
+// alongside the CA database, so that the concurrent updates do not lose the changes of each other.
+func updateCADatabase(dbFile string, fn func(db *CADatabase) error) error {
+	lockFile := dbFile + ".lock"
+	deadline := time.Now().Add(caDatabaseLockTimeout)
+	for {
+		f, err := os.OpenFile(lockFile, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if err == nil {
+			f.Close()
+			break
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return fmt.Errorf("failed to lock the CA database file: %w", err)
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("timed out waiting for the lock file %s of the CA database, remove it if no other process holds it", lockFile)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	defer os.Remove(lockFile)
+	db, err := LoadCADatabase(dbFile)
+	if err != nil {
+		return err
+	}
+	if err := fn(db); err != nil {
+		return err
+	}
+	return db.Save(dbFile)
+}
+
+// Find returns the record of the serial number, or nil if there is no such record
+func (db *CADatabase) Find(serial *big.Int) *CertificateRecord {
+	hex := serial.Text(16)
+	for i := range db.Certificates {
+		if db.Certificates[i].SerialNumber == hex {
+			return &db.Certificates[i]
+		}
+	}
+	return nil
+}
+
+// Issue records an issued certificate, nothing changes if the serial number is known already
+func (db *CADatabase) Issue(cert *x509.Certificate) {
+	if db.Find(cert.SerialNumber) != nil {
+		return
+	}
+	db.Certificates = append(db.Certificates, CertificateRecord{
+		SerialNumber: cert.SerialNumber.Text(16),
+		Subject:      cert.Subject.String(),
+		NotAfter:     cert.NotAfter.UTC(),
+	})
+}
+
+// RecordIssued records the issued certificates in the CA database file
+func RecordIssued(dbFile string, certs ...*x509.Certificate) error {
+	return updateCADatabase(dbFile, func(db *CADatabase) error {
+		for _, cert := range certs {
+			db.Issue(cert)
+		}
+		return nil
+	})
+}
+
+// Revoke marks the certificate with the serial number revoked, a record is added if the serial number is unknown.
+// Revoking an already revoked certificate keeps the original revocation time.
+func (db *CADatabase) Revoke(serial *big.Int, subject string, reasonCode int, revokedAt time.Time) {
+	record := db.Find(serial)
+	if record == nil {
+		db.Certificates = append(db.Certificates, CertificateRecord{SerialNumber: serial.Text(16), Subject: subject})
+		record = &db.Certificates[len(db.Certificates)-1]
+	}
+	if record.RevokedAt != nil {
+		return
+	}
+	revokedAt = revokedAt.UTC()
+	record.RevokedAt = &revokedAt
+	record.ReasonCode = reasonCode
+}
+
+// Revoked returns the records of the revoked certificates, sorted by revocation time
+func (db *CADatabase) Revoked() []CertificateRecord {
+	var revoked []CertificateRecord
+	for _, record := range db.Certificates {
+		if record.RevokedAt != nil {
+			revoked = append(revoked, record)
+		}
+	}
+	sort.SliceStable(revoked, func(i, j int) bool { return revoked[i].RevokedAt.Before(*revoked[j].RevokedAt) })
+	return revoked
+}
+
+// ParseSerialNumber parses a certificate serial number in hex, colons and an optional 0x prefix are allowed,
+// which is the format printed by 'tls inspect' and 'openssl x509 -serial'
+func ParseSerialNumber(serial string) (*big.Int, error) {
+	s := strings.ToLower(strings.TrimSpace(serial))
+	s = strings.TrimPrefix(s, "0x")
+	s = strings.ReplaceAll(s, ":", "")
+	n, ok := new(big.Int).SetString(s, 16)
+	if !ok || s == "" {
+		return nil, fmt.Errorf("invalid serial number: %s, a hex number is expected", serial)
+	}
+	return n, nil
+}
+
+// ParseRevocationReason converts the name of a reason code to its value, an empty name means unspecified
+func ParseRevocationReason(reason string) (int, error) {
+	if reason == "" {
+		return 0, nil
+	}
+	for name, code := range RevocationReasons {
+		if strings.EqualFold(name, reason) {
+			return code, nil
+		}
+	}
+	return 0, fmt.Errorf("unsupported revocation reason: %s", reason)
+}
+
+// CreateCRL creates a CRL signed by the CA containing all revoked certificates of the database.
+// The CRL number of the database is increased, the caller needs to save the database.
+// A non-positive nextUpdate creates a stale CRL whose next update is in the past.
+func CreateCRL(caKey crypto.Signer, caCert *x509.Certificate, db *CADatabase, nextUpdate time.Duration) (*x509.RevocationList, error) {
+	var entries []x509.RevocationListEntry
+	for _, record := range db.Revoked() {
+		serial, err := ParseSerialNumber(record.SerialNumber)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, x509.RevocationListEntry{
+			SerialNumber:   serial,
+			RevocationTime: *record.RevokedAt,
+			ReasonCode:     record.ReasonCode,
+		})
+	}
+	db.CRLNumber++
+	now := time.Now()
+	tmpl := &x509.RevocationList{
+		RevokedCertificateEntries: entries,
+		Number:                    big.NewInt(db.CRLNumber),
+		ThisUpdate:                now,
+		NextUpdate:                now.Add(nextUpdate),
+	}
+	if nextUpdate <= 0 {
+		// a stale CRL, this update still needs to be before the next update
+		tmpl.ThisUpdate = tmpl.NextUpdate.Add(-ValidityOneDay)
+	}
+	crlBytes, err := x509.CreateRevocationList(Reader(), tmpl, caCert, caKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create CRL: %w", err)
+	}
+	return x509.ParseRevocationList(crlBytes)
+}
+
+// CRLToPem converts an x509.RevocationList object to a pem string
+func CRLToPem(crl *x509.RevocationList) []byte {
+	return pem.EncodeToMemory(
+		&pem.Block{
+			Type:  "X509 CRL",
+			Bytes: crl.Raw,
+		},
+	)
+}
+
+// RevokeCertificate records the certificate specified by a serial number or a certificate file as revoked in the CA database
+func RevokeCertificate(opts *RevokeOptions) (*big.Int, error) {
+	var serial *big.Int
+	subject := ""
+	switch {
+	case opts.Serial != "" && opts.CertFile != "":
+		return nil, errors.New("only one of serial and certFile can be specified")
+	case opts.Serial != "":
+		n, err := ParseSerialNumber(opts.Serial)
+		if err != nil {
+			return nil, err
+		}
+		serial = n
+	case opts.CertFile != "":
+		certBytes, err := os.ReadFile(opts.CertFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read certificate file: %w", err)
+		}
+		cert, err := PemToCertificate(certBytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load certificate from file: %w", err)
+		}
+		serial = cert.SerialNumber
+		subject = cert.Subject.String()
+	default:
+		return nil, errors.New("either serial or certFile needs to be specified to revoke a certificate")
+	}
+	reasonCode, err := ParseRevocationReason(opts.Reason)
+	if err != nil {
+		return nil, err
+	}
+	err = updateCADatabase(opts.CaGenOpt.DBFile(), func(db *CADatabase) error {
+		db.Revoke(serial, subject, reasonCode, time.Now())
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return serial, nil
+}
+
+// GenerateCRLToFile generates a CRL signed by the CA files with the revoked certificates of the CA database
+func GenerateCRLToFile(opts *CRLOptions) error {
+	if opts.CRLFile == "" {
+		return errors.New("crlFile needs to be specified to save for the CRL")
+	}
+	caKey, caChain, err := opts.CaGenOpt.LoadCA()
+	if err != nil {
+		return err
+	}
+	return updateCADatabase(opts.CaGenOpt.DBFile(), func(db *CADatabase) error {
+		crl, err := CreateCRL(caKey, caChain[0], db, opts.NextUpdate)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(opts.CRLFile, CRLToPem(crl), 0644)
+	})
+}
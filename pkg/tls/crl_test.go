@@ -0,0 +1,103 @@
+package tls
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRevokeCertificateAndGenerateCRL(t *testing.T) {
+	caOpts, dir := newTestCA(t)
+	opts := DefaultPKIOptions()
+	opts.CaGenOpt = caOpts
+	opts.KeyFile = filepath.Join(dir, "tls.key")
+	opts.CertFile = filepath.Join(dir, "tls.crt")
+	opts.CRLDistributionPoints = []string{"http://127.0.0.1/ca.crl"}
+	require.NoError(t, GenerateTLSKeyCertPairToFiles(opts))
+	assert.NoFileExists(t, opts.CaGenOpt.DBFile(), "the issuance is only recorded with a CA database file")
+
+	revokeOpts := DefaultRevokeOptions()
+	revokeOpts.CaGenOpt = opts.CaGenOpt
+	revokeOpts.CertFile = opts.CertFile
+	revokeOpts.Reason = "keycompromise"
+	serial, err := RevokeCertificate(revokeOpts)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "ca.db.json"), opts.CaGenOpt.DBFile())
+
+	crlOpts := DefaultCRLOptions()
+	crlOpts.CaGenOpt = opts.CaGenOpt
+	crlOpts.CRLFile = filepath.Join(dir, "ca.crl")
+	require.NoError(t, GenerateCRLToFile(crlOpts))
+	require.NoError(t, GenerateCRLToFile(crlOpts))
+
+	crlBytes, err := os.ReadFile(crlOpts.CRLFile)
+	require.NoError(t, err)
+	block, _ := pem.Decode(crlBytes)
+	require.NotNil(t, block)
+	crl, err := x509.ParseRevocationList(block.Bytes)
+	require.NoError(t, err)
+	_, caCert, err := LoadCAFromFiles(opts.CaGenOpt.CaKeyFile, opts.CaGenOpt.CaCertFile)
+	require.NoError(t, err)
+	assert.NoError(t, crl.CheckSignatureFrom(caCert[0]))
+	assert.Equal(t, int64(2), crl.Number.Int64())
+	require.Len(t, crl.RevokedCertificateEntries, 1)
+	assert.Equal(t, serial, crl.RevokedCertificateEntries[0].SerialNumber)
+	assert.Equal(t, 1, crl.RevokedCertificateEntries[0].ReasonCode)
+
+	_, cert, err := parsePemKeypairFiles(t, opts.KeyFile, opts.CertFile)
+	require.NoError(t, err)
+	assert.Equal(t, opts.CRLDistributionPoints, cert.CRLDistributionPoints)
+}
+
+func TestGenerateCRL_Stale(t *testing.T) {
+	caOpts, dir := newTestCA(t)
+	opts := DefaultCRLOptions()
+	opts.CaGenOpt = caOpts
+	opts.CRLFile = filepath.Join(dir, "ca.crl")
+	opts.NextUpdate = -time.Hour
+	require.NoError(t, GenerateCRLToFile(opts))
+
+	crlBytes, err := os.ReadFile(opts.CRLFile)
+	require.NoError(t, err)
+	block, _ := pem.Decode(crlBytes)
+	require.NotNil(t, block)
+	crl, err := x509.ParseRevocationList(block.Bytes)
+	require.NoError(t, err)
+	assert.True(t, crl.NextUpdate.Before(time.Now()), "the next update of a stale CRL is in the past")
+	assert.True(t, crl.ThisUpdate.Before(crl.NextUpdate))
+}
+
+func TestRecordIssued_Concurrent(t *testing.T) {
+	dbFile := filepath.Join(t.TempDir(), "ca.db.json")
+	var wg sync.WaitGroup
+	for i := 1; i <= 20; i++ {
+		wg.Add(1)
+		go func(serial int64) {
+			defer wg.Done()
+			assert.NoError(t, RecordIssued(dbFile, &x509.Certificate{SerialNumber: big.NewInt(serial)}))
+		}(int64(i))
+	}
+	wg.Wait()
+	db, err := LoadCADatabase(dbFile)
+	require.NoError(t, err)
+	assert.Len(t, db.Certificates, 20)
+	assert.NoFileExists(t, dbFile+".lock")
+}
+
+func TestParseSerialNumber(t *testing.T) {
+	for _, serial := range []string{"0abc", "0x0ABC", "0a:bc", " abc "} {
+		n, err := ParseSerialNumber(serial)
+		require.NoError(t, err, serial)
+		assert.Equal(t, int64(0xabc), n.Int64())
+	}
+	_, err := ParseSerialNumber("xyz")
+	assert.Error(t, err)
+}
@@ -0,0 +1,133 @@
+package tls
+
+import (
+	"crypto"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// GenerateCSR generates a private key and a PKCS#10 certificate request with the subject and SANs defined by CertCfg.
+func GenerateCSR(cfg *CertCfg) (crypto.Signer, *x509.CertificateRequest, error) {
+	key, err := GeneratePrivateKey(cfg.KeyAlgorithm, cfg.KeySize)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate private key: %w", err)
+	}
+	csrTmpl := x509.CertificateRequest{
+		Subject:        cfg.Subject,
+		RawSubject:     cfg.RawSubject,
+		DNSNames:       cfg.DNSNames,
+		IPAddresses:    cfg.IPAddresses,
+		URIs:           cfg.URIs,
+		EmailAddresses: cfg.EmailAddresses,
+	}
+	csrBytes, err := x509.CreateCertificateRequest(Reader(), &csrTmpl, key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create certificate request: %w", err)
+	}
+	csr, err := x509.ParseCertificateRequest(csrBytes)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error parsing x509 certificate request: %w", err)
+	}
+	return key, csr, nil
+}
+
+// SignCSR issues a certificate for a certificate request signed by the CA.
+// The subject, the SANs and the public key come from the request, the validity and usages come from the cfg.
+func SignCSR(csr *x509.CertificateRequest, caKey crypto.Signer, caCert *x509.Certificate, cfg *CertCfg) (*x509.Certificate, error) {
+	if err := csr.CheckSignature(); err != nil {
+		return nil, fmt.Errorf("invalid certificate request signature: %w", err)
+	}
+	cert, err := signedCertificate(cfg, csr, caCert, caKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create a signed certificate: %w", err)
+	}
+	return cert, nil
+}
+
+// CSRToPem converts an x509.CertificateRequest object to a pem string
+func CSRToPem(csr *x509.CertificateRequest) []byte {
+	return pem.EncodeToMemory(
+		&pem.Block{
+			Type:  "CERTIFICATE REQUEST",
+			Bytes: csr.Raw,
+		},
+	)
+}
+
+// PemToCSR converts a data block to x509.CertificateRequest, the legacy NEW CERTIFICATE REQUEST type is accepted too.
+func PemToCSR(data []byte) (*x509.CertificateRequest, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("could not find a PEM block in the certificate request")
+	}
+	if block.Type != "CERTIFICATE REQUEST" && block.Type != "NEW CERTIFICATE REQUEST" {
+		return nil, fmt.Errorf("unexpected PEM block type %q in the certificate request", block.Type)
+	}
+	return x509.ParseCertificateRequest(block.Bytes)
+}
+
+// GenerateCSRToFiles generates a private key and a certificate request and saves them into different files
+func GenerateCSRToFiles(opts *CSROptions) error {
+	if opts.KeyFile == "" {
+		return errors.New("keyFile needs to be specified to save for the private key")
+	}
+	if opts.CSRFile == "" {
+		return errors.New("csrFile needs to be specified to save for the certificate request")
+	}
+	cfg, err := opts.certCfg()
+	if err != nil {
+		return err
+	}
+	key, csr, err := GenerateCSR(cfg)
+	if err != nil {
+		return err
+	}
+	keyInPem, err := encodePrivateKey(key, opts.KeyPassphraseFile)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(opts.KeyFile, keyInPem, 0600); err != nil {
+		return err
+	}
+	return os.WriteFile(opts.CSRFile, CSRToPem(csr), 0644)
+}
+
+// SignCSRToFile signs the certificate request file with the CA files and saves the certificate into a file.
+// Like GenerateTLSKeyCertPairToFiles, the certificate file contains the certificate followed by the intermediate CAs.
+func SignCSRToFile(opts *SignCSROptions) error {
+	if opts.CSRFile == "" || opts.CertFile == "" {
+		return errors.New("both csrFile and certFile need to be specified to sign a certificate request")
+	}
+	csrBytes, err := os.ReadFile(opts.CSRFile)
+	if err != nil {
+		return fmt.Errorf("failed to read certificate request file: %w", err)
+	}
+	csr, err := PemToCSR(csrBytes)
+	if err != nil {
+		return fmt.Errorf("failed to load certificate request from file: %w", err)
+	}
+	caKey, caChain, err := opts.CaGenOpt.LoadCA()
+	if err != nil {
+		return err
+	}
+	cfg := defaultCertCfg()
+	if opts.Validity > 0 {
+		cfg.Validity = opts.Validity
+	}
+	cfg.KeyAlgorithm = keyAlgorithmOf(csr.PublicKeyAlgorithm)
+	if err := applyProfileOptions(&cfg, opts.Profile, opts.ExtKeyUsages); err != nil {
+		return err
+	}
+	cert, err := SignCSR(csr, caKey, caChain[0], &cfg)
+	if err != nil {
+		return err
+	}
+	if err := opts.CaGenOpt.RecordIssued(cert); err != nil {
+		return err
+	}
+	chain := append([]*x509.Certificate{cert}, intermediates(caChain)...)
+	return os.WriteFile(opts.CertFile, CertsToPem(chain), 0644)
+}
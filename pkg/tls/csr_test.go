@@ -0,0 +1,57 @@
+package tls
+
+import (
+	"crypto"
+	"crypto/x509"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignCSR(t *testing.T) {
+	caCfg := defaultCertCfg()
+	caCfg.IsCA = true
+	caCfg.KeyUsages = caKeyUsages
+	caKey, caCert, err := GenerateSelfSignedCertificate(&caCfg)
+	require.NoError(t, err)
+
+	cfg := defaultCertCfg()
+	cfg.KeyAlgorithm, cfg.KeySize = KeyAlgorithmECDSA, DefaultECDSAKeySize
+	cfg.DNSNames = []string{"csr.openqe.github.io"}
+	key, csr, err := GenerateCSR(&cfg)
+	require.NoError(t, err)
+	csr, err = PemToCSR(CSRToPem(csr))
+	require.NoError(t, err)
+
+	signCfg := defaultCertCfg()
+	signCfg.Validity = ValidityOneDay
+	cert, err := SignCSR(csr, caKey, caCert, &signCfg)
+	require.NoError(t, err)
+	assert.NoError(t, cert.CheckSignatureFrom(caCert))
+	assert.Equal(t, []string{"csr.openqe.github.io"}, cert.DNSNames)
+	assert.True(t, key.Public().(interface{ Equal(crypto.PublicKey) bool }).Equal(cert.PublicKey))
+	assert.Equal(t, ValidityOneDay, cert.NotAfter.Sub(cert.NotBefore))
+
+	csr.Signature[0] ^= 0xff
+	_, err = SignCSR(csr, caKey, caCert, &signCfg)
+	assert.Error(t, err)
+}
+
+func TestSignCSRToFile(t *testing.T) {
+	caOpts, dir := newTestCA(t)
+	csrOpts := DefaultCSROptions()
+	csrOpts.KeyFile = filepath.Join(dir, "tls.key")
+	csrOpts.CSRFile = filepath.Join(dir, "tls.csr")
+	require.NoError(t, GenerateCSRToFiles(csrOpts))
+
+	opts := DefaultSignCSROptions()
+	opts.CaGenOpt = caOpts
+	opts.CSRFile = csrOpts.CSRFile
+	opts.CertFile = filepath.Join(dir, "tls.crt")
+	require.NoError(t, SignCSRToFile(opts))
+	cert := loadCertificate(t, opts.CertFile)
+	assert.Equal(t, x509.KeyUsageDigitalSignature|x509.KeyUsageKeyEncipherment, cert.KeyUsage, "the server profile is the default")
+	assert.Equal(t, []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}, cert.ExtKeyUsage)
+}
@@ -0,0 +1,105 @@
+package tls
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/x509"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Defect is a deliberate defect of a generated TLS certificate for negative testing
+type Defect string
+
+const (
+	// DefectExpired generates a certificate which expired yesterday
+	DefectExpired Defect = "expired"
+	// DefectNotYetValid generates a certificate which is valid from tomorrow
+	DefectNotYetValid Defect = "not-yet-valid"
+	// DefectWrongHostname replaces the SANs with a hostname nobody uses
+	DefectWrongHostname Defect = "wrong-hostname"
+	// DefectSHA1 signs the certificate with a SHA-1 signature
+	DefectSHA1 Defect = "sha1"
+	// DefectWeakKey generates a 1024-bit RSA key
+	DefectWeakKey Defect = "weak-key"
+	// DefectNoServerAuth sets the clientAuth extended key usage only
+	DefectNoServerAuth Defect = "no-server-auth"
+	// DefectCALeaf sets CA:TRUE in the basic constraints of the leaf certificate
+	DefectCALeaf Defect = "ca-leaf"
+	// DefectSelfSigned generates a self-signed leaf certificate instead of one signed by the CA
+	DefectSelfSigned Defect = "self-signed"
+	// DefectMismatchedKey saves a private key which does not match the certificate
+	DefectMismatchedKey Defect = "mismatched-key"
+	// DefectTruncatedPEM saves the certificate file cut in the middle of the PEM block
+	DefectTruncatedPEM Defect = "truncated-pem"
+
+	// WrongHostname is the SAN of the certificates generated with DefectWrongHostname
+	WrongHostname = "wrong-hostname.openqe.invalid"
+	// weakKeySize is the RSA key size of DefectWeakKey, the smallest size accepted by crypto/rsa
+	weakKeySize = 1024
+)
+
+// Defects are all supported defects
+var Defects = []Defect{
+	DefectExpired, DefectNotYetValid, DefectWrongHostname, DefectSHA1, DefectWeakKey,
+	DefectNoServerAuth, DefectCALeaf, DefectSelfSigned, DefectMismatchedKey, DefectTruncatedPEM,
+}
+
+// ParseDefect validates the name of a defect, an empty name means no defect
+func ParseDefect(name string) (Defect, error) {
+	if name == "" {
+		return "", nil
+	}
+	for _, d := range Defects {
+		if string(d) == name {
+			return d, nil
+		}
+	}
+	names := make([]string, 0, len(Defects))
+	for _, d := range Defects {
+		names = append(names, string(d))
+	}
+	return "", fmt.Errorf("unsupported defect: %s, supported: %s", name, strings.Join(names, ", "))
+}
+
+// applyDefect changes the cfg of a certificate signed by signer to carry the defect.
+// The defects which are not about the certificate content are left to the caller.
+func applyDefect(cfg *CertCfg, defect Defect, signer crypto.Signer) error {
+	now := time.Now()
+	switch defect {
+	case DefectExpired:
+		cfg.NotBefore = now.Add(-2 * ValidityOneDay)
+		cfg.NotAfter = now.Add(-ValidityOneDay)
+	case DefectNotYetValid:
+		cfg.NotBefore = now.Add(ValidityOneDay)
+		cfg.NotAfter = cfg.NotBefore.Add(cfg.Validity)
+	case DefectWrongHostname:
+		cfg.DNSNames = []string{WrongHostname}
+		cfg.IPAddresses = nil
+	case DefectSHA1:
+		switch signer.Public().(type) {
+		case *rsa.PublicKey:
+			cfg.SignatureAlgorithm = x509.SHA1WithRSA
+		case *ecdsa.PublicKey:
+			cfg.SignatureAlgorithm = x509.ECDSAWithSHA1
+		default:
+			return fmt.Errorf("the %s defect requires an RSA or ECDSA signing key, got %T", defect, signer.Public())
+		}
+	case DefectWeakKey:
+		cfg.KeyAlgorithm = KeyAlgorithmRSA
+		cfg.KeySize = weakKeySize
+	case DefectNoServerAuth:
+		cfg.ExtKeyUsages = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
+	case DefectCALeaf:
+		cfg.IsCA = true
+		cfg.KeyUsages |= x509.KeyUsageCertSign
+	}
+	return nil
+}
+
+// truncatePEM cuts PEM data in the middle, so neither the END line nor the complete base64 content is kept
+func truncatePEM(data []byte) []byte {
+	return data[:len(data)/2]
+}
@@ -0,0 +1,56 @@
+package tls
+
+import (
+	"crypto/x509"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateTLSKeyCertPairToFiles_Defects(t *testing.T) {
+	caOpts, dir := newTestCA(t)
+
+	for _, defect := range Defects {
+		t.Run(string(defect), func(t *testing.T) {
+			opts := DefaultPKIOptions()
+			opts.CaGenOpt = caOpts
+			opts.KeyFile = filepath.Join(dir, string(defect)+".key")
+			opts.CertFile = filepath.Join(dir, string(defect)+".crt")
+			opts.Defect = string(defect)
+			require.NoError(t, GenerateTLSKeyCertPairToFiles(opts))
+
+			key, cert, err := parsePemKeypairFiles(t, opts.KeyFile, opts.CertFile)
+			switch defect {
+			case DefectTruncatedPEM:
+				assert.Error(t, err)
+				return
+			case DefectMismatchedKey:
+				assert.ErrorContains(t, err, "does not match")
+				return
+			}
+			require.NoError(t, err)
+			switch defect {
+			case DefectExpired:
+				assert.True(t, cert.NotAfter.Before(time.Now()))
+			case DefectNotYetValid:
+				assert.True(t, cert.NotBefore.After(time.Now()))
+			case DefectWrongHostname:
+				assert.Equal(t, []string{WrongHostname}, cert.DNSNames)
+			case DefectSHA1:
+				assert.Equal(t, x509.SHA1WithRSA, cert.SignatureAlgorithm)
+			case DefectWeakKey:
+				_, bits := PublicKeyInfo(key.Public())
+				assert.Equal(t, 1024, bits)
+			case DefectNoServerAuth:
+				assert.Equal(t, []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}, cert.ExtKeyUsage)
+			case DefectCALeaf:
+				assert.True(t, cert.IsCA)
+			case DefectSelfSigned:
+				assert.True(t, IsSelfSigned(cert))
+			}
+		})
+	}
+}
@@ -0,0 +1,136 @@
+package tls
+
+import (
+	"bytes"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/openqe/openqe/pkg/common"
+	"github.com/pavlo-v-chernykh/keystore-go/v4"
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+const (
+	// ExportFormatPKCS12 is a PKCS#12 keystore of a private key and its chain, or a PKCS#12 truststore of certificates
+	ExportFormatPKCS12 = "pkcs12"
+	// ExportFormatJKSTrustStore is a Java keystore (JKS) of trusted certificates
+	ExportFormatJKSTrustStore = "jks-truststore"
+
+	// minJKSPasswordLen is the minimum password length accepted by the Java keytool
+	minJKSPasswordLen = 6
+)
+
+// exportExtensions are the default file extensions of the export formats
+var exportExtensions = map[string]string{
+	ExportFormatPKCS12:        ".p12",
+	ExportFormatJKSTrustStore: ".jks",
+}
+
+// OutputFile returns the file the keystore is exported to
+func (o *ExportOptions) OutputFile() string {
+	if o.OutFile != "" {
+		return o.OutFile
+	}
+	return strings.TrimSuffix(o.CertFile, filepath.Ext(o.CertFile)) + exportExtensions[o.Format]
+}
+
+// EncodePKCS12 encodes a private key and its certificate chain, the leaf certificate first, into a PKCS#12 keystore
+func EncodePKCS12(key any, chain []*x509.Certificate, password string) ([]byte, error) {
+	if len(chain) == 0 {
+		return nil, errors.New("no certificate to export with the private key")
+	}
+	return pkcs12.Modern.Encode(key, chain[0], chain[1:], password)
+}
+
+// EncodePKCS12TrustStore encodes certificates into a PKCS#12 truststore which is trusted by Java
+func EncodePKCS12TrustStore(certs []*x509.Certificate, password string) ([]byte, error) {
+	if len(certs) == 0 {
+		return nil, errors.New("no certificate to export into the truststore")
+	}
+	return pkcs12.Modern.EncodeTrustStore(certs, password)
+}
+
+// EncodeJKSTrustStore encodes certificates into a JKS truststore, the entries are aliased by the prefix and their index
+func EncodeJKSTrustStore(certs []*x509.Certificate, aliasPrefix, password string) ([]byte, error) {
+	if len(certs) == 0 {
+		return nil, errors.New("no certificate to export into the truststore")
+	}
+	ks := keystore.New(keystore.WithOrderedAliases(), keystore.WithMinPasswordLen(minJKSPasswordLen))
+	now := time.Now()
+	for i, cert := range certs {
+		entry := keystore.TrustedCertificateEntry{
+			CreationTime: now,
+			Certificate:  keystore.Certificate{Type: "X509", Content: cert.Raw},
+		}
+		if err := ks.SetTrustedCertificateEntry(fmt.Sprintf("%s-%d", aliasPrefix, i), entry); err != nil {
+			return nil, err
+		}
+	}
+	var buf bytes.Buffer
+	if err := ks.Store(&buf, []byte(password)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// ExportToFile exports the certificate file, with the private key file if specified, into a keystore file
+func ExportToFile(opts *ExportOptions) error {
+	if opts.CertFile == "" {
+		return errors.New("certFile needs to be specified to export")
+	}
+	if _, ok := exportExtensions[opts.Format]; !ok {
+		return fmt.Errorf("unsupported export format: %s, supported: %s, %s", opts.Format, ExportFormatPKCS12, ExportFormatJKSTrustStore)
+	}
+	if opts.Format == ExportFormatJKSTrustStore && opts.KeyFile != "" {
+		return errors.New("a private key can not be exported into a truststore, use the pkcs12 format instead")
+	}
+	password, err := common.ReadSecret(opts.PasswordFile, opts.PasswordKeyring)
+	if err != nil {
+		return fmt.Errorf("failed to read keystore password: %w", err)
+	}
+	certBytes, err := os.ReadFile(opts.CertFile)
+	if err != nil {
+		return fmt.Errorf("failed to read certificate file: %w", err)
+	}
+	certs, err := PemToCertificates(certBytes)
+	if err != nil {
+		return fmt.Errorf("failed to load certificates from file: %w", err)
+	}
+
+	var data []byte
+	switch {
+	case opts.KeyFile != "":
+		keyBytes, err := os.ReadFile(opts.KeyFile)
+		if err != nil {
+			return fmt.Errorf("failed to read private key file: %w", err)
+		}
+		passphrase, err := readPassphrase(opts.KeyPassphraseFile)
+		if err != nil {
+			return err
+		}
+		key, _, err := parsePemKeypair(keyBytes, certBytes, passphrase)
+		if err != nil {
+			return fmt.Errorf("failed to load private key and certificate: %w", err)
+		}
+		data, err = EncodePKCS12(key, certs, password)
+		if err != nil {
+			return fmt.Errorf("failed to encode PKCS#12 keystore: %w", err)
+		}
+	case opts.Format == ExportFormatPKCS12:
+		data, err = EncodePKCS12TrustStore(certs, password)
+		if err != nil {
+			return fmt.Errorf("failed to encode PKCS#12 truststore: %w", err)
+		}
+	default:
+		data, err = EncodeJKSTrustStore(certs, opts.Alias, password)
+		if err != nil {
+			return fmt.Errorf("failed to encode JKS truststore: %w", err)
+		}
+	}
+	return os.WriteFile(opts.OutputFile(), data, 0600)
+}
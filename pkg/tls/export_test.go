@@ -0,0 +1,51 @@
+package tls
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pavlo-v-chernykh/keystore-go/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+func TestExportToFile(t *testing.T) {
+	caOpts, dir := newTestCA(t)
+	opts := DefaultPKIOptions()
+	opts.CaGenOpt = caOpts
+	opts.KeyFile = filepath.Join(dir, "tls.key")
+	opts.CertFile = filepath.Join(dir, "tls.crt")
+	require.NoError(t, GenerateTLSKeyCertPairToFiles(opts))
+	passwordFile := filepath.Join(dir, "password")
+	require.NoError(t, os.WriteFile(passwordFile, []byte("changeit\n"), 0600))
+
+	exportOpts := DefaultExportOptions()
+	exportOpts.KeyFile = opts.KeyFile
+	exportOpts.CertFile = opts.CertFile
+	exportOpts.PasswordFile = passwordFile
+	require.NoError(t, ExportToFile(exportOpts))
+	assert.Equal(t, filepath.Join(dir, "tls.p12"), exportOpts.OutputFile())
+	p12, err := os.ReadFile(exportOpts.OutputFile())
+	require.NoError(t, err)
+	key, cert, _, err := pkcs12.DecodeChain(p12, "changeit")
+	require.NoError(t, err)
+	assert.NotNil(t, key)
+	assert.Equal(t, "default-server", cert.Subject.CommonName)
+
+	exportOpts = DefaultExportOptions()
+	exportOpts.Format = ExportFormatJKSTrustStore
+	exportOpts.CertFile = opts.CaGenOpt.CaCertFile
+	exportOpts.PasswordFile = passwordFile
+	require.NoError(t, ExportToFile(exportOpts))
+	jks, err := os.Open(filepath.Join(dir, "ca.jks"))
+	require.NoError(t, err)
+	defer jks.Close()
+	ks := keystore.New()
+	require.NoError(t, ks.Load(jks, []byte("changeit")))
+	assert.Equal(t, []string{"openqe-0"}, ks.Aliases())
+
+	exportOpts.KeyFile = opts.KeyFile
+	assert.Error(t, ExportToFile(exportOpts))
+}
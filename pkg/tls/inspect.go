@@ -0,0 +1,180 @@
+package tls
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"time"
+)
+
+const (
+	// OutputFormatText is the human readable output format of the tls commands
+	OutputFormatText = "text"
+	// OutputFormatJSON is the JSON output format of the tls commands
+	OutputFormatJSON = "json"
+)
+
+// CertificateSummary contains the fields of a certificate that are interesting for testing
+type CertificateSummary struct {
+	// Source describes where the certificate comes from, like a file name or a Secret key
+	Source            string    `json:"source,omitempty"`
+	Subject           string    `json:"subject"`
+	Issuer            string    `json:"issuer"`
+	SerialNumber      string    `json:"serialNumber"`
+	DNSNames          []string  `json:"dnsNames,omitempty"`
+	IPAddresses       []string  `json:"ipAddresses,omitempty"`
+	URIs              []string  `json:"uris,omitempty"`
+	EmailAddresses    []string  `json:"emailAddresses,omitempty"`
+	KeyType           string    `json:"keyType"`
+	KeySize           int       `json:"keySize"`
+	NotBefore         time.Time `json:"notBefore"`
+	NotAfter          time.Time `json:"notAfter"`
+	DaysRemaining     int       `json:"daysRemaining"`
+	SHA256Fingerprint string    `json:"sha256Fingerprint"`
+	SPKIPin           string    `json:"spkiPin"`
+	IsCA              bool      `json:"isCA"`
+	// MaxPathLen is nil when the certificate has no path length constraint
+	MaxPathLen *int `json:"maxPathLen,omitempty"`
+}
+
+// SummarizeCertificate creates a CertificateSummary of the certificate
+func SummarizeCertificate(cert *x509.Certificate) CertificateSummary {
+	keyType, keySize := PublicKeyInfo(cert.PublicKey)
+	fingerprint := sha256.Sum256(cert.Raw)
+	spki := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	summary := CertificateSummary{
+		Subject:           cert.Subject.String(),
+		Issuer:            cert.Issuer.String(),
+		SerialNumber:      cert.SerialNumber.Text(16),
+		DNSNames:          cert.DNSNames,
+		EmailAddresses:    cert.EmailAddresses,
+		KeyType:           keyType,
+		KeySize:           keySize,
+		NotBefore:         cert.NotBefore,
+		NotAfter:          cert.NotAfter,
+		DaysRemaining:     int(math.Floor(time.Until(cert.NotAfter).Hours() / 24)),
+		SHA256Fingerprint: colonHex(fingerprint[:]),
+		SPKIPin:           "sha256/" + base64.StdEncoding.EncodeToString(spki[:]),
+		IsCA:              cert.IsCA,
+	}
+	for _, ip := range cert.IPAddresses {
+		summary.IPAddresses = append(summary.IPAddresses, ip.String())
+	}
+	for _, uri := range cert.URIs {
+		summary.URIs = append(summary.URIs, uri.String())
+	}
+	if cert.IsCA && (cert.MaxPathLen > 0 || cert.MaxPathLenZero) {
+		maxPathLen := cert.MaxPathLen
+		summary.MaxPathLen = &maxPathLen
+	}
+	return summary
+}
+
+// SummarizeCertificates creates a CertificateSummary for each certificate in the PEM data, in order.
+func SummarizeCertificates(data []byte, source string) ([]CertificateSummary, error) {
+	certs, err := PemToCertificates(data)
+	if err != nil {
+		return nil, err
+	}
+	var summaries []CertificateSummary
+	for _, cert := range certs {
+		summary := SummarizeCertificate(cert)
+		summary.Source = source
+		summaries = append(summaries, summary)
+	}
+	return summaries, nil
+}
+
+// PublicKeyInfo returns the key type and the key size in bits of a public key
+func PublicKeyInfo(pub any) (string, int) {
+	switch k := pub.(type) {
+	case *rsa.PublicKey:
+		return "RSA", k.N.BitLen()
+	case *ecdsa.PublicKey:
+		return "ECDSA", k.Curve.Params().BitSize
+	case ed25519.PublicKey:
+		return "Ed25519", 256
+	}
+	return fmt.Sprintf("%T", pub), 0
+}
+
+// WriteCertificateSummaries writes the summaries to w in the output format: text or json
+func WriteCertificateSummaries(w io.Writer, summaries []CertificateSummary, format string) error {
+	switch format {
+	case OutputFormatJSON:
+		data, err := json.MarshalIndent(summaries, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(data))
+		return err
+	case "", OutputFormatText:
+		for i, s := range summaries {
+			if i > 0 {
+				fmt.Fprintln(w)
+			}
+			writeCertificateSummary(w, i+1, s)
+		}
+		return nil
+	}
+	return fmt.Errorf("unsupported output format: %s, supported: %s, %s", format, OutputFormatText, OutputFormatJSON)
+}
+
+func writeCertificateSummary(w io.Writer, index int, s CertificateSummary) {
+	if s.Source != "" {
+		fmt.Fprintf(w, "Certificate #%d (%s)\n", index, s.Source)
+	} else {
+		fmt.Fprintf(w, "Certificate #%d\n", index)
+	}
+	fmt.Fprintf(w, "  Subject:        %s\n", s.Subject)
+	fmt.Fprintf(w, "  Issuer:         %s\n", s.Issuer)
+	fmt.Fprintf(w, "  Serial Number:  %s\n", s.SerialNumber)
+	if sans := s.SANs(); len(sans) > 0 {
+		fmt.Fprintf(w, "  SANs:           %s\n", strings.Join(sans, ", "))
+	}
+	fmt.Fprintf(w, "  Key:            %s %d\n", s.KeyType, s.KeySize)
+	fmt.Fprintf(w, "  Not Before:     %s\n", s.NotBefore.UTC().Format(time.RFC3339))
+	fmt.Fprintf(w, "  Not After:      %s (%d days remaining)\n", s.NotAfter.UTC().Format(time.RFC3339), s.DaysRemaining)
+	fmt.Fprintf(w, "  SHA-256:        %s\n", s.SHA256Fingerprint)
+	fmt.Fprintf(w, "  SPKI Pin:       %s\n", s.SPKIPin)
+	if s.MaxPathLen != nil {
+		fmt.Fprintf(w, "  Is CA:          %t (path length: %d)\n", s.IsCA, *s.MaxPathLen)
+	} else {
+		fmt.Fprintf(w, "  Is CA:          %t\n", s.IsCA)
+	}
+}
+
+// SANs returns all subject alternative names of the summary in the openssl fashion, like DNS:example.com
+func (s CertificateSummary) SANs() []string {
+	var sans []string
+	for _, v := range s.DNSNames {
+		sans = append(sans, "DNS:"+v)
+	}
+	for _, v := range s.IPAddresses {
+		sans = append(sans, "IP:"+v)
+	}
+	for _, v := range s.URIs {
+		sans = append(sans, "URI:"+v)
+	}
+	for _, v := range s.EmailAddresses {
+		sans = append(sans, "email:"+v)
+	}
+	return sans
+}
+
+// colonHex formats data as upper case hex bytes separated by colons, like openssl fingerprints
+func colonHex(data []byte) string {
+	parts := make([]string, len(data))
+	for i, b := range data {
+		parts[i] = fmt.Sprintf("%02X", b)
+	}
+	return strings.Join(parts, ":")
+}
@@ -0,0 +1,42 @@
+package tls
+
+import (
+	"crypto/x509"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSummarizeCertificates(t *testing.T) {
+	cfg := defaultCertCfg()
+	cfg.IsCA = true
+	cfg.KeyAlgorithm = KeyAlgorithmECDSA
+	cfg.KeySize = 384
+	cfg.MaxPathLen = new(int)
+	_, cert, err := GenerateSelfSignedCertificate(&cfg)
+	require.NoError(t, err)
+
+	summaries, err := SummarizeCertificates(CertsToPem([]*x509.Certificate{cert, cert}), "bundle.crt")
+	require.NoError(t, err)
+	require.Len(t, summaries, 2)
+	s := summaries[0]
+	assert.Equal(t, "bundle.crt", s.Source)
+	assert.Equal(t, "ECDSA", s.KeyType)
+	assert.Equal(t, 384, s.KeySize)
+	assert.True(t, s.IsCA)
+	require.NotNil(t, s.MaxPathLen)
+	assert.Equal(t, 0, *s.MaxPathLen)
+	assert.Equal(t, []string{"DNS:openqe.github.io"}, s.SANs())
+	assert.Len(t, s.SHA256Fingerprint, 32*3-1)
+	assert.Contains(t, s.SPKIPin, "sha256/")
+	assert.InDelta(t, 364, s.DaysRemaining, 1)
+
+	expired := *cert
+	expired.NotAfter = time.Now().Add(-20 * time.Hour)
+	assert.Equal(t, -1, SummarizeCertificate(&expired).DaysRemaining, "an expired certificate has negative days remaining")
+
+	_, err = SummarizeCertificates([]byte("not a certificate"), "")
+	assert.Error(t, err)
+}
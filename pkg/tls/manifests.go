@@ -0,0 +1,173 @@
+package tls
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// OutputFormatFiles saves the generated keys and certificates into PEM files
+	OutputFormatFiles = "files"
+	// OutputFormatK8s writes the generated keys and certificates as Kubernetes Secret and ConfigMap manifests
+	OutputFormatK8s = "k8s"
+)
+
+// Validate checks the output format, and the name and labels of the manifests
+func (o *ManifestOptions) Validate() error {
+	switch o.OutputFormat {
+	case "", OutputFormatFiles:
+		return nil
+	case OutputFormatK8s:
+		if o.Name == "" {
+			return fmt.Errorf("the name of the manifests is required by the %s output format", OutputFormatK8s)
+		}
+		_, err := o.labels()
+		return err
+	}
+	return fmt.Errorf("unsupported output format: %s, supported: %s, %s", o.OutputFormat, OutputFormatFiles, OutputFormatK8s)
+}
+
+func (o *ManifestOptions) labels() (map[string]string, error) {
+	if len(o.Labels) == 0 {
+		return nil, nil
+	}
+	labels := map[string]string{}
+	for _, l := range o.Labels {
+		k, v, ok := strings.Cut(l, "=")
+		if !ok || k == "" {
+			return nil, fmt.Errorf("invalid label %q, expected key=value", l)
+		}
+		labels[k] = v
+	}
+	return labels, nil
+}
+
+func (o *ManifestOptions) objectMeta(name string) (metav1.ObjectMeta, error) {
+	labels, err := o.labels()
+	if err != nil {
+		return metav1.ObjectMeta{}, err
+	}
+	return metav1.ObjectMeta{Name: name, Namespace: o.Namespace, Labels: labels}, nil
+}
+
+// NewTLSSecret makes a kubernetes.io/tls Secret, the ca.crt key is set only when caPEM is not empty
+func NewTLSSecret(meta metav1.ObjectMeta, keyPEM, certPEM, caPEM []byte) *corev1.Secret {
+	secret := &corev1.Secret{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Secret"},
+		ObjectMeta: meta,
+		Type:       corev1.SecretTypeTLS,
+		Data: map[string][]byte{
+			corev1.TLSCertKey:       certPEM,
+			corev1.TLSPrivateKeyKey: keyPEM,
+		},
+	}
+	if len(caPEM) > 0 {
+		secret.Data[CASignerCertMapKey] = caPEM
+	}
+	return secret
+}
+
+// NewCASecret makes an Opaque Secret with the CA key and certificate in the ca.key and ca.crt keys
+func NewCASecret(meta metav1.ObjectMeta, keyPEM, certPEM []byte) *corev1.Secret {
+	return &corev1.Secret{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Secret"},
+		ObjectMeta: meta,
+		Type:       corev1.SecretTypeOpaque,
+		Data: map[string][]byte{
+			CASignerCertMapKey: certPEM,
+			CASignerKeyMapKey:  keyPEM,
+		},
+	}
+}
+
+// NewCABundleConfigMap makes a ConfigMap with the CA bundle in the ca-bundle.crt key
+func NewCABundleConfigMap(meta metav1.ObjectMeta, bundlePEM []byte) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+		ObjectMeta: meta,
+		Data: map[string]string{
+			UserCABundleMapKey: string(bundlePEM),
+		},
+	}
+}
+
+// GenerateCAManifests generates a CA like GenerateCAToFiles, but returns it as a CA Secret named after the manifest
+// options, and a CA bundle ConfigMap with the CA certificate chain
+func GenerateCAManifests(opts *CAOptions, m *ManifestOptions) (*corev1.Secret, *corev1.ConfigMap, error) {
+	key, chain, err := GenerateCAChain(opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	keyInPem, err := encodePrivateKey(key, opts.KeyPassphraseFile)
+	if err != nil {
+		return nil, nil, err
+	}
+	certInPem := CertsToPem(chain)
+	secretMeta, err := m.objectMeta(m.Name)
+	if err != nil {
+		return nil, nil, err
+	}
+	bundleName := m.BundleName
+	if bundleName == "" {
+		bundleName = m.Name + "-bundle"
+	}
+	bundleMeta, err := m.objectMeta(bundleName)
+	if err != nil {
+		return nil, nil, err
+	}
+	return NewCASecret(secretMeta, keyInPem, certInPem), NewCABundleConfigMap(bundleMeta, certInPem), nil
+}
+
+// GenerateTLSSecret generates a TLS key/cert pair like GenerateTLSKeyCertPairToFiles, but returns it as a
+// kubernetes.io/tls Secret named after the manifest options. The ca.crt key contains the CA certificate file.
+func GenerateTLSSecret(opts *PKIOptions, m *ManifestOptions) (*corev1.Secret, error) {
+	key, chain, err := GenerateTLSKeyCertChain(opts)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to generate TLS certificate: %w", err)
+	}
+	keyInPem, err := encodePrivateKey(key, opts.KeyPassphraseFile)
+	if err != nil {
+		return nil, err
+	}
+	certInPem := CertsToPem(chain)
+	if Defect(opts.Defect) == DefectTruncatedPEM {
+		certInPem = truncatePEM(certInPem)
+	}
+	var caInPem []byte
+	if Defect(opts.Defect) != DefectSelfSigned {
+		if caInPem, err = os.ReadFile(opts.CaGenOpt.CaCertFile); err != nil {
+			return nil, fmt.Errorf("failed to read CA certificate file: %w", err)
+		}
+	}
+	meta, err := m.objectMeta(m.Name)
+	if err != nil {
+		return nil, err
+	}
+	return NewTLSSecret(meta, keyInPem, certInPem, caInPem), nil
+}
+
+// WriteManifests writes the objects to w as YAML documents separated by ---
+func WriteManifests(w io.Writer, objs ...runtime.Object) error {
+	for i, obj := range objs {
+		data, err := yaml.Marshal(obj)
+		if err != nil {
+			return err
+		}
+		if i > 0 {
+			if _, err := io.WriteString(w, "---\n"); err != nil {
+				return err
+			}
+		}
+		if _, err := w.Write(data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package tls
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestGenerateManifests(t *testing.T) {
+	dir := t.TempDir()
+	m := DefaultManifestOptions("test-ca")
+	m.OutputFormat = OutputFormatK8s
+	m.Namespace = "openqe"
+	m.Labels = []string{"app=openqe"}
+	require.NoError(t, m.Validate())
+	caOpts := DefaultCAOptions()
+	secret, bundle, err := GenerateCAManifests(caOpts, m)
+	require.NoError(t, err)
+	assert.Equal(t, "test-ca", secret.Name)
+	assert.Equal(t, "openqe", secret.Namespace)
+	assert.Equal(t, map[string]string{"app": "openqe"}, secret.Labels)
+	assert.Equal(t, "test-ca-bundle", bundle.Name)
+	assert.Equal(t, string(secret.Data[CASignerCertMapKey]), bundle.Data[UserCABundleMapKey])
+	_, _, err = parsePemKeypair(secret.Data[CASignerKeyMapKey], secret.Data[CASignerCertMapKey], nil)
+	require.NoError(t, err)
+
+	caOpts.CaKeyFile = filepath.Join(dir, "ca.key")
+	caOpts.CaCertFile = filepath.Join(dir, "ca.crt")
+	require.NoError(t, os.WriteFile(caOpts.CaKeyFile, secret.Data[CASignerKeyMapKey], 0600))
+	require.NoError(t, os.WriteFile(caOpts.CaCertFile, secret.Data[CASignerCertMapKey], 0600))
+	opts := DefaultPKIOptions()
+	opts.CaGenOpt = caOpts
+	m.Name = "test-tls"
+	tlsSecret, err := GenerateTLSSecret(opts, m)
+	require.NoError(t, err)
+	assert.Equal(t, corev1.SecretTypeTLS, tlsSecret.Type)
+	assert.Equal(t, secret.Data[CASignerCertMapKey], tlsSecret.Data[CASignerCertMapKey])
+	cert, err := PemToCertificate(tlsSecret.Data[corev1.TLSCertKey])
+	require.NoError(t, err)
+	ca, err := PemToCertificate(tlsSecret.Data[CASignerCertMapKey])
+	require.NoError(t, err)
+	assert.NoError(t, cert.CheckSignatureFrom(ca))
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteManifests(&buf, secret, bundle))
+	docs := strings.Split(buf.String(), "\n---\n")
+	require.Len(t, docs, 2)
+	assert.Contains(t, docs[0], "kind: Secret")
+	assert.Contains(t, docs[1], "kind: ConfigMap")
+	assert.Contains(t, docs[1], "ca-bundle.crt: |")
+
+	m.Labels = []string{"invalid"}
+	assert.Error(t, m.Validate())
+	m.OutputFormat = "yaml"
+	assert.Error(t, m.Validate())
+}
@@ -0,0 +1,200 @@
+package tls
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ocsp"
+)
+
+const (
+	// OCSPModeNormal answers good, revoked or unknown according to the CA database
+	OCSPModeNormal = "normal"
+	// OCSPModeMalformed answers bytes which are not a DER encoded OCSP response
+	OCSPModeMalformed = "malformed"
+	// OCSPModeStale answers signed responses whose next update is in the past
+	OCSPModeStale = "stale"
+	// OCSPModeUnauthorized answers the unauthorized OCSP error response
+	OCSPModeUnauthorized = "unauthorized"
+	// OCSPModeTryLater answers the try later OCSP error response
+	OCSPModeTryLater = "try-later"
+)
+
+// oidTLSFeature is the TLS feature extension defined in RFC 7633
+var oidTLSFeature = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 1, 24}
+
+// mustStapleExtension returns the TLS feature extension requiring the status_request feature, known as OCSP must-staple
+func mustStapleExtension() ([]byte, error) {
+	// status_request is the TLS extension 5
+	return asn1.Marshal([]int{5})
+}
+
+// OCSPResponder is a http.Handler answering OCSP requests about the certificates issued by a CA.
+// The CA database is read on every request, so revocations by 'tls revoke' take effect immediately.
+type OCSPResponder struct {
+	CAKey  crypto.Signer
+	CACert *x509.Certificate
+	DBFile string
+	// Mode is one of the OCSPMode constants, defaults to OCSPModeNormal
+	Mode string
+	// NextUpdate is the duration from now until the next update of the responses
+	NextUpdate time.Duration
+}
+
+// NewOCSPResponder creates an OCSPResponder of the CA files in the options
+func NewOCSPResponder(opts *OCSPOptions) (*OCSPResponder, error) {
+	switch opts.Mode {
+	case "", OCSPModeNormal, OCSPModeMalformed, OCSPModeStale, OCSPModeUnauthorized, OCSPModeTryLater:
+	default:
+		return nil, fmt.Errorf("unsupported OCSP mode: %s, supported: %s", opts.Mode,
+			strings.Join([]string{OCSPModeNormal, OCSPModeMalformed, OCSPModeStale, OCSPModeUnauthorized, OCSPModeTryLater}, ", "))
+	}
+	caKey, caChain, err := opts.CaGenOpt.LoadCA()
+	if err != nil {
+		return nil, err
+	}
+	return &OCSPResponder{
+		CAKey:      caKey,
+		CACert:     caChain[0],
+		DBFile:     opts.CaGenOpt.DBFile(),
+		Mode:       opts.Mode,
+		NextUpdate: opts.NextUpdate,
+	}, nil
+}
+
+// ServeHTTP answers OCSP requests sent by both GET and POST as defined in RFC 6960 appendix A
+func (r *OCSPResponder) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	var reqBytes []byte
+	switch req.Method {
+	case http.MethodGet:
+		encoded, err := url.PathUnescape(strings.TrimPrefix(req.URL.Path, "/"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		reqBytes, err = base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	case http.MethodPost:
+		var err error
+		reqBytes, err = io.ReadAll(io.LimitReader(req.Body, 64*1024))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	resp := r.Respond(reqBytes)
+	w.Header().Set("Content-Type", "application/ocsp-response")
+	w.Write(resp)
+}
+
+// Respond creates the DER encoded OCSP response of a DER encoded OCSP request
+func (r *OCSPResponder) Respond(reqBytes []byte) []byte {
+	switch r.Mode {
+	case OCSPModeMalformed:
+		return []byte("this is not an OCSP response")
+	case OCSPModeUnauthorized:
+		return ocsp.UnauthorizedErrorResponse
+	case OCSPModeTryLater:
+		return ocsp.TryLaterErrorResponse
+	}
+	ocspReq, err := ocsp.ParseRequest(reqBytes)
+	if err != nil {
+		return ocsp.MalformedRequestErrorResponse
+	}
+	if !r.isIssuer(ocspReq) {
+		return ocsp.UnauthorizedErrorResponse
+	}
+	status, revokedAt, reason, err := r.status(ocspReq)
+	if err != nil {
+		return ocsp.InternalErrorErrorResponse
+	}
+	now := time.Now()
+	tmpl := ocsp.Response{
+		Status:           status,
+		SerialNumber:     ocspReq.SerialNumber,
+		ThisUpdate:       now,
+		NextUpdate:       now.Add(r.NextUpdate),
+		RevokedAt:        revokedAt,
+		RevocationReason: reason,
+		IssuerHash:       ocspReq.HashAlgorithm,
+	}
+	if r.Mode == OCSPModeStale {
+		tmpl.ThisUpdate = now.Add(-2 * ValidityOneDay)
+		tmpl.NextUpdate = now.Add(-ValidityOneDay)
+	}
+	resp, err := ocsp.CreateResponse(r.CACert, r.CACert, tmpl, r.CAKey)
+	if err != nil {
+		return ocsp.InternalErrorErrorResponse
+	}
+	return resp
+}
+
+// isIssuer checks the request is about a certificate issued by the CA of the responder
+func (r *OCSPResponder) isIssuer(ocspReq *ocsp.Request) bool {
+	var spki struct {
+		Algorithm asn1.RawValue
+		PublicKey asn1.BitString
+	}
+	if _, err := asn1.Unmarshal(r.CACert.RawSubjectPublicKeyInfo, &spki); err != nil {
+		return false
+	}
+	if !ocspReq.HashAlgorithm.Available() {
+		return false
+	}
+	h := ocspReq.HashAlgorithm.New()
+	h.Write(spki.PublicKey.RightAlign())
+	return bytes.Equal(h.Sum(nil), ocspReq.IssuerKeyHash)
+}
+
+// status looks up the certificate of the request in the CA database
+func (r *OCSPResponder) status(ocspReq *ocsp.Request) (int, time.Time, int, error) {
+	db, err := LoadCADatabase(r.DBFile)
+	if err != nil {
+		return ocsp.Unknown, time.Time{}, 0, err
+	}
+	record := db.Find(ocspReq.SerialNumber)
+	switch {
+	case record == nil:
+		return ocsp.Unknown, time.Time{}, 0, nil
+	case record.RevokedAt != nil:
+		return ocsp.Revoked, *record.RevokedAt, record.ReasonCode, nil
+	}
+	return ocsp.Good, time.Time{}, 0, nil
+}
+
+// ocspClient is the HTTP client of CheckOCSP, a responder which does not answer in time fails the check
+var ocspClient = &http.Client{Timeout: 10 * time.Second}
+
+// CheckOCSP sends an OCSP request about the certificate to the server and returns the parsed response.
+// It can be used to check an OCSP responder, like the one started by 'tls ocsp-serve'.
+func CheckOCSP(server string, cert, issuer *x509.Certificate) (*ocsp.Response, error) {
+	reqBytes, err := ocsp.CreateRequest(cert, issuer, &ocsp.RequestOptions{Hash: crypto.SHA1})
+	if err != nil {
+		return nil, err
+	}
+	resp, err := ocspClient.Post(server, "application/ocsp-request", bytes.NewReader(reqBytes))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	respBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	return ocsp.ParseResponseForCert(respBytes, cert, issuer)
+}
@@ -0,0 +1,74 @@
+package tls
+
+import (
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ocsp"
+)
+
+func TestOCSPResponder(t *testing.T) {
+	caOpts, dir := newTestCA(t)
+	opts := DefaultPKIOptions()
+	opts.CaGenOpt = caOpts
+	opts.CaGenOpt.CaDBFile = filepath.Join(dir, "ca.db.json")
+	opts.KeyFile = filepath.Join(dir, "tls.key")
+	opts.CertFile = filepath.Join(dir, "tls.crt")
+	opts.OCSPServers = []string{"http://127.0.0.1:8888"}
+	opts.MustStaple = true
+	require.NoError(t, GenerateTLSKeyCertPairToFiles(opts))
+
+	_, cert, err := parsePemKeypairFiles(t, opts.KeyFile, opts.CertFile)
+	require.NoError(t, err)
+	assert.Equal(t, opts.OCSPServers, cert.OCSPServer)
+	hasMustStaple := false
+	for _, ext := range cert.Extensions {
+		hasMustStaple = hasMustStaple || ext.Id.Equal(oidTLSFeature)
+	}
+	assert.True(t, hasMustStaple)
+
+	ocspOpts := DefaultOCSPOptions()
+	ocspOpts.CaGenOpt = opts.CaGenOpt
+	responder, err := NewOCSPResponder(ocspOpts)
+	require.NoError(t, err)
+	server := httptest.NewServer(responder)
+	defer server.Close()
+
+	resp, err := CheckOCSP(server.URL, cert, responder.CACert)
+	require.NoError(t, err)
+	assert.Equal(t, ocsp.Good, resp.Status)
+
+	_, unknownCert, err := GenerateSignedCertificate(responder.CAKey, responder.CACert, &CertCfg{
+		Subject:   cert.Subject,
+		KeySize:   2048,
+		Validity:  ValidityOneDay,
+		KeyUsages: cert.KeyUsage,
+	})
+	require.NoError(t, err)
+	resp, err = CheckOCSP(server.URL, unknownCert, responder.CACert)
+	require.NoError(t, err)
+	assert.Equal(t, ocsp.Unknown, resp.Status)
+
+	revokeOpts := DefaultRevokeOptions()
+	revokeOpts.CaGenOpt = opts.CaGenOpt
+	revokeOpts.CertFile = opts.CertFile
+	revokeOpts.Reason = "superseded"
+	_, err = RevokeCertificate(revokeOpts)
+	require.NoError(t, err)
+	resp, err = CheckOCSP(server.URL, cert, responder.CACert)
+	require.NoError(t, err)
+	assert.Equal(t, ocsp.Revoked, resp.Status)
+	assert.Equal(t, ocsp.Superseded, resp.RevocationReason)
+
+	responder.Mode = OCSPModeStale
+	resp, err = CheckOCSP(server.URL, cert, responder.CACert)
+	require.NoError(t, err)
+	assert.True(t, resp.NextUpdate.Before(resp.ProducedAt))
+
+	responder.Mode = OCSPModeMalformed
+	_, err = CheckOCSP(server.URL, cert, responder.CACert)
+	assert.Error(t, err)
+}
@@ -1,36 +1,433 @@
 package tls
 
+import (
+	"crypto/x509"
+	"time"
+)
+
+// SANOptions contains the subject alternative names of a certificate in their command line representation
+type SANOptions struct {
+	DNSNames       []string
+	IPAddresses    []string
+	URIs           []string
+	EmailAddresses []string
+}
+
 type CAOptions struct {
-	Subject    string
-	DNSName    string
-	CaKeyFile  string
-	CaCertFile string
+	SANOptions
+	Subject      string
+	KeyAlgorithm string
+	KeySize      int
+	CaKeyFile    string
+	CaCertFile   string
+	// ParentCaKeyFile and ParentCaCertFile make the generated CA an intermediate CA signed by the parent CA
+	ParentCaKeyFile  string
+	ParentCaCertFile string
+	// PathLen is the maximum number of intermediate CAs below the CA, a negative value means unlimited
+	PathLen int
+	// CaDBFile is the CA database file recording the revoked certificates, defaults to CADatabaseFile(CaCertFile).
+	// The certificates issued by the CA are only recorded when it is set.
+	CaDBFile string
+	// ParentCaDBFile is the CA database file of the parent CA, the generated CA is recorded in it when it is set
+	ParentCaDBFile string
+	// Validity of the CA certificate, defaults to one year when not positive
+	Validity time.Duration
+	// KeyPassphraseFile contains the passphrase of the CA private key, to read it when it is encrypted,
+	// and to write it as encrypted PKCS#8 when the CA is generated
+	KeyPassphraseFile string
+	// ParentKeyPassphraseFile contains the passphrase of the parent CA private key when it is encrypted
+	ParentKeyPassphraseFile string
+}
+
+// DBFile returns the CA database file of the CA
+func (o *CAOptions) DBFile() string {
+	if o.CaDBFile != "" {
+		return o.CaDBFile
+	}
+	return CADatabaseFile(o.CaCertFile)
+}
+
+// RecordIssued records the certificates issued by the CA in CaDBFile, nothing is recorded when CaDBFile is not set
+func (o *CAOptions) RecordIssued(certs ...*x509.Certificate) error {
+	if o.CaDBFile == "" {
+		return nil
+	}
+	return RecordIssued(o.CaDBFile, certs...)
 }
 
 type PKIOptions struct {
-	CaGenOpt *CAOptions
-	Subject  string
-	DNSName  string
-	CertFile string
-	KeyFile  string
+	SANOptions
+	CaGenOpt     *CAOptions
+	Subject      string
+	KeyAlgorithm string
+	KeySize      int
+	CertFile     string
+	KeyFile      string
+	// ChainFile is the file path of the leaf certificate followed by the intermediate CAs
+	ChainFile string
+	// CRLDistributionPoints are the CRL URLs embedded in the certificate
+	CRLDistributionPoints []string
+	// OCSPServers are the OCSP responder URLs embedded in the certificate
+	OCSPServers []string
+	// MustStaple requires the TLS server to staple an OCSP response
+	MustStaple bool
+	// Validity of the certificate, defaults to one year when not positive
+	Validity time.Duration
+	// NotBefore and NotAfter override the validity window, in the formats accepted by ParseTime
+	NotBefore string
+	NotAfter  string
+	// Defect is one of the Defects deliberately put in the certificate for negative testing
+	Defect string
+	// Profile is one of the Profiles, defaults to server, no extended key usage is set when empty
+	Profile string
+	// ExtKeyUsages are added to the ones of the profile, by names like serverAuth or by dotted OIDs
+	ExtKeyUsages []string
+	// KeyPassphraseFile contains the passphrase to write the private key as encrypted PKCS#8
+	KeyPassphraseFile string
 }
 
 func DefaultCAOptions() *CAOptions {
 	return &CAOptions{
-		Subject:    "C=China, O=OpenShift, OU=Hypershift QE, CN=default-ca",
-		DNSName:    "openqe.github.io",
-		CaKeyFile:  "ca.key",
-		CaCertFile: "ca.crt",
+		SANOptions:   SANOptions{DNSNames: []string{"openqe.github.io"}},
+		Subject:      "CN=default-ca, OU=Hypershift QE, O=OpenShift, C=China",
+		KeyAlgorithm: string(KeyAlgorithmRSA),
+		CaKeyFile:    "ca.key",
+		CaCertFile:   "ca.crt",
+		PathLen:      -1,
 	}
 }
 
 func DefaultPKIOptions() *PKIOptions {
 	pkiOpts := &PKIOptions{
+		CaGenOpt:     DefaultCAOptions(),
+		CertFile:     "tls.crt",
+		KeyFile:      "tls.key",
+		SANOptions:   SANOptions{DNSNames: []string{"server.openqe.github.io"}},
+		Subject:      "CN=default-server, OU=Hypershift QE, O=OpenShift, C=China",
+		KeyAlgorithm: string(KeyAlgorithmRSA),
+		Profile:      string(ProfileServer),
+	}
+	return pkiOpts
+}
+
+// CSROptions contains the options to generate a private key and a certificate request
+type CSROptions struct {
+	SANOptions
+	Subject      string
+	KeyAlgorithm string
+	KeySize      int
+	KeyFile      string
+	CSRFile      string
+	// KeyPassphraseFile contains the passphrase to write the private key as encrypted PKCS#8
+	KeyPassphraseFile string
+}
+
+// SignCSROptions contains the options to sign a certificate request with a CA
+type SignCSROptions struct {
+	CaGenOpt     *CAOptions
+	CSRFile      string
+	CertFile     string
+	Validity     time.Duration
+	Profile      string
+	ExtKeyUsages []string
+}
+
+func DefaultCSROptions() *CSROptions {
+	return &CSROptions{
+		SANOptions:   SANOptions{DNSNames: []string{"server.openqe.github.io"}},
+		Subject:      "CN=default-server, OU=Hypershift QE, O=OpenShift, C=China",
+		KeyAlgorithm: string(KeyAlgorithmRSA),
+		KeyFile:      "tls.key",
+		CSRFile:      "tls.csr",
+	}
+}
+
+func DefaultSignCSROptions() *SignCSROptions {
+	return &SignCSROptions{
 		CaGenOpt: DefaultCAOptions(),
+		CSRFile:  "tls.csr",
+		CertFile: "tls.crt",
+		Validity: ValidityOneYear,
+		Profile:  string(ProfileServer),
+	}
+}
+
+// RevokeOptions contains the options to revoke a certificate, specified by either Serial or CertFile
+type RevokeOptions struct {
+	CaGenOpt *CAOptions
+	Serial   string
+	CertFile string
+	Reason   string
+}
+
+// CRLOptions contains the options to generate a CRL
+type CRLOptions struct {
+	CaGenOpt   *CAOptions
+	CRLFile    string
+	NextUpdate time.Duration
+}
+
+func DefaultRevokeOptions() *RevokeOptions {
+	return &RevokeOptions{
+		CaGenOpt: DefaultCAOptions(),
+		Reason:   "unspecified",
+	}
+}
+
+func DefaultCRLOptions() *CRLOptions {
+	return &CRLOptions{
+		CaGenOpt:   DefaultCAOptions(),
+		CRLFile:    "ca.crl",
+		NextUpdate: 7 * ValidityOneDay,
+	}
+}
+
+// OCSPOptions contains the options to run a local OCSP responder of a CA
+type OCSPOptions struct {
+	CaGenOpt *CAOptions
+	// Listen is the address the OCSP responder listens on
+	Listen string
+	// Mode is one of normal, malformed, stale, unauthorized and try-later
+	Mode       string
+	NextUpdate time.Duration
+}
+
+func DefaultOCSPOptions() *OCSPOptions {
+	return &OCSPOptions{
+		CaGenOpt:   DefaultCAOptions(),
+		Listen:     "127.0.0.1:8888",
+		Mode:       OCSPModeNormal,
+		NextUpdate: ValidityOneDay,
+	}
+}
+
+// ExportOptions contains the options to export PEM files into a password protected keystore
+type ExportOptions struct {
+	Format string
+	// KeyFile is the private key of the certificate file, a truststore of the certificate file is exported when not set
+	KeyFile string
+	// KeyPassphraseFile contains the passphrase of the private key when it is encrypted
+	KeyPassphraseFile string
+	// CertFile contains the certificate followed by its chain, or the CA certificates of a truststore
+	CertFile string
+	// OutFile defaults to the certificate file name with the extension of the format
+	OutFile string
+	// PasswordFile and PasswordKeyring ('service,secret') are the sources of the keystore password, only one can be set
+	PasswordFile    string
+	PasswordKeyring string
+	// Alias is the alias prefix of the JKS truststore entries
+	Alias string
+}
+
+func DefaultExportOptions() *ExportOptions {
+	return &ExportOptions{
+		Format:   ExportFormatPKCS12,
+		CertFile: "tls.crt",
+		Alias:    "openqe",
+	}
+}
+
+// VerifyOptions contains the options to verify a certificate chain against a CA or a CA bundle
+type VerifyOptions struct {
+	// CertFile contains the leaf certificate optionally followed by the intermediate CAs
+	CertFile string
+	// CAFile is the CA or the CA bundle to trust, the system roots are trusted when not set
+	CAFile string
+	// UntrustedFile contains additional intermediate CAs to build the chain
+	UntrustedFile string
+	Hostname      string
+	// AtTime is the verification time in RFC 3339, a date like 2006-01-02, or a duration relative to now like +720h
+	AtTime string
+	// Usage is one of server, client and any
+	Usage string
+}
+
+func DefaultVerifyOptions() *VerifyOptions {
+	return &VerifyOptions{
 		CertFile: "tls.crt",
+		Usage:    UsageServer,
+	}
+}
+
+// ServeOptions contains the options to run a HTTPS server echoing the request details
+type ServeOptions struct {
+	CertFile string
+	KeyFile  string
+	// KeyPassphraseFile contains the passphrase of the private key when it is encrypted
+	KeyPassphraseFile string
+	// ClientCAFile is the CA or the CA bundle to verify the client certificates
+	ClientCAFile string
+	// ClientAuth is one of auto, none, request, require, verify-if-given and require-and-verify
+	ClientAuth string
+	Listen     string
+	// MinVersion and MaxVersion restrict the protocol versions, like 1.2 or 1.3
+	MinVersion string
+	MaxVersion string
+	// CipherSuites restrict the cipher suites of TLS 1.2 and lower by their standard names
+	CipherSuites []string
+}
+
+func DefaultServeOptions() *ServeOptions {
+	return &ServeOptions{
+		CertFile:   "tls.crt",
+		KeyFile:    "tls.key",
+		ClientAuth: ClientAuthAuto,
+		Listen:     "127.0.0.1:8443",
+	}
+}
+
+// ProbeOptions contains the options to probe the TLS endpoint at an address
+type ProbeOptions struct {
+	// Address is the endpoint in form of host:port
+	Address string
+	// ServerName is sent as SNI and verified in the certificate, defaults to the host of the address
+	ServerName string
+	// CAFile is the CA or the CA bundle to verify the chain, the system roots are used when the default file does not exist
+	CAFile  string
+	Timeout time.Duration
+	// EnumerateVersions probes each TLS version separately to find the ones accepted by the server
+	EnumerateVersions bool
+}
+
+func DefaultProbeOptions() *ProbeOptions {
+	return &ProbeOptions{
+		CAFile:  DefaultCABundleFile,
+		Timeout: 10 * time.Second,
+	}
+}
+
+// ManifestOptions contains the options of the Kubernetes manifests of the generated keys and certificates
+type ManifestOptions struct {
+	// OutputFormat is either files or k8s
+	OutputFormat string
+	Name         string
+	Namespace    string
+	// Labels are in form of key=value
+	Labels []string
+	// BundleName is the name of the CA bundle ConfigMap, defaults to <name>-bundle
+	BundleName string
+	// ManifestFile is the YAML file to write the manifests to, the standard output is used when not set
+	ManifestFile string
+}
+
+func DefaultManifestOptions(name string) *ManifestOptions {
+	return &ManifestOptions{
+		OutputFormat: OutputFormatFiles,
+		Name:         name,
+		Namespace:    "default",
+	}
+}
+
+// RenewOptions contains the options to renew a certificate for its existing private key
+type RenewOptions struct {
+	KeyFile  string
+	CertFile string
+	// KeyPassphraseFile contains the passphrase of the private key when it is encrypted
+	KeyPassphraseFile string
+	// CaGenOpt is the CA signing the renewed certificate, a self-signed certificate is signed by its own key when not set
+	CaGenOpt *CAOptions
+	// OutFile is the file of the renewed certificate, defaults to CertFile
+	OutFile string
+	// Validity of the renewed certificate, defaults to the validity of the certificate
+	Validity time.Duration
+}
+
+func DefaultRenewOptions() *RenewOptions {
+	return &RenewOptions{
 		KeyFile:  "tls.key",
-		Subject:  "C=China, O=OpenShift, OU=Hypershift QE, CN=default-server",
-		DNSName:  "server.openqe.github.io",
+		CertFile: "tls.crt",
+		CaGenOpt: &CAOptions{},
+	}
+}
+
+// CrossSignOptions contains the options to issue a certificate for an existing CA under a different issuer
+type CrossSignOptions struct {
+	// CertFile is the certificate of the CA to cross-sign
+	CertFile string
+	// CaGenOpt is the issuer CA
+	CaGenOpt *CAOptions
+	OutFile  string
+	// Validity of the cross-signed certificate, defaults to the validity of the certificate
+	Validity time.Duration
+}
+
+func DefaultCrossSignOptions() *CrossSignOptions {
+	return &CrossSignOptions{
+		CaGenOpt: DefaultCAOptions(),
+		OutFile:  "cross-signed.crt",
+	}
+}
+
+// ConvertOptions contains the options to convert a private or public key between formats and encodings
+type ConvertOptions struct {
+	// InFile is a private key in any of the formats accepted by ParsePrivateKey, or a public key or certificate
+	// when only the public key is exported
+	InFile string
+	// PassphraseFile contains the passphrase of an encrypted PKCS#8 InFile
+	PassphraseFile string
+	// OutFile is the converted key file, the standard output is used when not set
+	OutFile string
+	// Format is pkcs1, pkcs8 or sec1 for a private key, and pkix, pkcs1 or jwk for a public key,
+	// defaults to pkcs8 for a private key and to pkix for a public key
+	Format string
+	// Encoding is pem or der, a JWK is always JSON
+	Encoding string
+	// Public exports the public key instead of the private key
+	Public bool
+	// OutPassphraseFile contains the passphrase to write the private key as encrypted PKCS#8
+	OutPassphraseFile string
+}
+
+func DefaultConvertOptions() *ConvertOptions {
+	return &ConvertOptions{
+		Encoding: EncodingPEM,
+	}
+}
+
+// CheckPairOptions contains the options to check a key/cert pair against the expected certificate
+type CheckPairOptions struct {
+	KeyFile  string
+	CertFile string
+	// KeyPassphraseFile contains the passphrase of the private key when it is encrypted
+	KeyPassphraseFile string
+	// SANOptions are the expected SANs, each kind of them is checked only when it is set
+	SANOptions
+	// Subject is the expected subject, it is checked only when it is set
+	Subject string
+	// MinRemaining is the minimum remaining validity of the certificate
+	MinRemaining time.Duration
+}
+
+func DefaultCheckPairOptions() *CheckPairOptions {
+	return &CheckPairOptions{
+		KeyFile:  "tls.key",
+		CertFile: "tls.crt",
+	}
+}
+
+// SignedSecretOptions contains the options of the certificate in a TLS Secret signed by a CA Secret
+type SignedSecretOptions struct {
+	SANOptions
+	Subject      string
+	KeyAlgorithm string
+	KeySize      int
+	// Validity of the certificate, defaults to one year when not positive
+	Validity time.Duration
+	// Profile is one of the Profiles, no extended key usage is set when empty
+	Profile string
+	// ExtKeyUsages are added to the ones of the profile, by names like serverAuth or by dotted OIDs
+	ExtKeyUsages []string
+	// MinRemaining is the minimum remaining validity, the certificate is re-signed when its remaining validity is shorter
+	MinRemaining time.Duration
+}
+
+func DefaultSignedSecretOptions() *SignedSecretOptions {
+	return &SignedSecretOptions{
+		SANOptions:   SANOptions{DNSNames: []string{"server.openqe.github.io"}},
+		Subject:      "CN=default-server, OU=Hypershift QE, O=OpenShift, C=China",
+		KeyAlgorithm: string(KeyAlgorithmRSA),
+		Validity:     ValidityOneYear,
+		Profile:      string(ProfileServer),
+		MinRemaining: 30 * ValidityOneDay,
 	}
-	return pkiOpts
 }
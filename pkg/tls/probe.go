@@ -0,0 +1,178 @@
+package tls
+
+import (
+	"context"
+	gotls "crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+
+	"github.com/openqe/openqe/pkg/utils"
+)
+
+// DefaultCABundleFile is the system CA bundle file on RHEL and Fedora
+const DefaultCABundleFile = "/etc/pki/tls/certs/ca-bundle.crt"
+
+// ProbeResult contains the TLS details of an endpoint
+type ProbeResult struct {
+	Address      string               `json:"address"`
+	ServerName   string               `json:"serverName"`
+	Version      string               `json:"version"`
+	CipherSuite  string               `json:"cipherSuite"`
+	Certificates []CertificateSummary `json:"certificates"`
+	Verified     bool                 `json:"verified"`
+	// VerifyError explains why the presented chain failed the verification
+	VerifyError string `json:"verifyError,omitempty"`
+	// Versions are the results of probing each TLS version when enumerated
+	Versions []VersionProbe `json:"versions,omitempty"`
+}
+
+// VersionProbe tells whether the server accepts a TLS version
+type VersionProbe struct {
+	Version  string `json:"version"`
+	Accepted bool   `json:"accepted"`
+	Error    string `json:"error,omitempty"`
+}
+
+// Probe connects to the TLS endpoint, collects the presented chain and verifies it against the CA file.
+// The handshake does not fail on an untrusted chain, the verification result is part of the ProbeResult.
+func Probe(ctx context.Context, opts *ProbeOptions) (*ProbeResult, error) {
+	host, _, err := net.SplitHostPort(opts.Address)
+	if err != nil {
+		return nil, fmt.Errorf("invalid address %s, host:port is expected: %w", opts.Address, err)
+	}
+	serverName := opts.ServerName
+	if serverName == "" {
+		serverName = host
+	}
+	roots, err := probeRoots(opts.CAFile)
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := probeHandshake(ctx, opts, serverName, 0)
+	if err != nil {
+		return nil, fmt.Errorf("TLS handshake with %s failed: %w", opts.Address, err)
+	}
+	result := &ProbeResult{
+		Address:     opts.Address,
+		ServerName:  serverName,
+		Version:     gotls.VersionName(state.Version),
+		CipherSuite: gotls.CipherSuiteName(state.CipherSuite),
+	}
+	for _, cert := range state.PeerCertificates {
+		result.Certificates = append(result.Certificates, SummarizeCertificate(cert))
+	}
+	if len(state.PeerCertificates) == 0 {
+		result.VerifyError = "no certificate presented"
+	} else {
+		verifyOpts := x509.VerifyOptions{
+			Roots:         roots,
+			DNSName:       serverName,
+			Intermediates: x509.NewCertPool(),
+		}
+		for _, cert := range state.PeerCertificates[1:] {
+			verifyOpts.Intermediates.AddCert(cert)
+		}
+		if _, err := VerifyCertificate(state.PeerCertificates[0], verifyOpts); err != nil {
+			result.VerifyError = err.Error()
+		} else {
+			result.Verified = true
+		}
+	}
+
+	if opts.EnumerateVersions {
+		for _, name := range []string{"1.0", "1.1", "1.2", "1.3"} {
+			probe := VersionProbe{Version: gotls.VersionName(TLSVersions[name])}
+			if _, err := probeHandshake(ctx, opts, serverName, TLSVersions[name]); err != nil {
+				probe.Error = err.Error()
+			} else {
+				probe.Accepted = true
+			}
+			result.Versions = append(result.Versions, probe)
+		}
+	}
+	return result, nil
+}
+
+// probeRoots loads the CA file, or the system roots if the default CA bundle file does not exist
+func probeRoots(caFile string) (*x509.CertPool, error) {
+	if caFile == "" || (caFile == DefaultCABundleFile && !utils.FileExists(caFile)) {
+		return x509.SystemCertPool()
+	}
+	cas, err := certificatesFromFile(caFile)
+	if err != nil {
+		return nil, err
+	}
+	roots := x509.NewCertPool()
+	for _, ca := range cas {
+		roots.AddCert(ca)
+	}
+	return roots, nil
+}
+
+// probeHandshake runs a TLS handshake without verification, limited to a single version when version is not 0
+func probeHandshake(ctx context.Context, opts *ProbeOptions, serverName string, version uint16) (*gotls.ConnectionState, error) {
+	config := &gotls.Config{
+		ServerName: serverName,
+		// the chain is verified after the handshake, so an untrusted chain can still be reported
+		InsecureSkipVerify: true,
+		MinVersion:         version,
+		MaxVersion:         version,
+	}
+	if version != 0 && version < gotls.VersionTLS13 {
+		// allow all cipher suites to find out whether the server accepts the version at all
+		for _, suite := range append(gotls.CipherSuites(), gotls.InsecureCipherSuites()...) {
+			config.CipherSuites = append(config.CipherSuites, suite.ID)
+		}
+	}
+	dialer := &gotls.Dialer{NetDialer: &net.Dialer{Timeout: opts.Timeout}, Config: config}
+	conn, err := dialer.DialContext(ctx, "tcp", opts.Address)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	state := conn.(*gotls.Conn).ConnectionState()
+	return &state, nil
+}
+
+// WriteProbeResult writes the probe result to w in the output format: text or json
+func WriteProbeResult(w io.Writer, result *ProbeResult, format string) error {
+	switch format {
+	case OutputFormatJSON:
+		data, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(data))
+		return err
+	case "", OutputFormatText:
+		fmt.Fprintf(w, "Address:          %s\n", result.Address)
+		fmt.Fprintf(w, "Server Name:      %s\n", result.ServerName)
+		fmt.Fprintf(w, "Version:          %s\n", result.Version)
+		fmt.Fprintf(w, "Cipher Suite:     %s\n", result.CipherSuite)
+		if result.Verified {
+			fmt.Fprintf(w, "Verification:     OK\n")
+		} else {
+			fmt.Fprintf(w, "Verification:     FAILED, %s\n", result.VerifyError)
+		}
+		if len(result.Versions) > 0 {
+			var accepted, rejected []string
+			for _, v := range result.Versions {
+				if v.Accepted {
+					accepted = append(accepted, v.Version)
+				} else {
+					rejected = append(rejected, v.Version)
+				}
+			}
+			fmt.Fprintf(w, "Accepted:         %s\n", strings.Join(accepted, ", "))
+			fmt.Fprintf(w, "Rejected:         %s\n", strings.Join(rejected, ", "))
+		}
+		fmt.Fprintln(w)
+		return WriteCertificateSummaries(w, result.Certificates, format)
+	}
+	return fmt.Errorf("unsupported output format: %s, supported: %s, %s", format, OutputFormatText, OutputFormatJSON)
+}
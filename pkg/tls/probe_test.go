@@ -0,0 +1,63 @@
+package tls
+
+import (
+	"bytes"
+	"context"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProbe(t *testing.T) {
+	caOpts, dir := newTestCA(t)
+	opts := DefaultPKIOptions()
+	opts.CaGenOpt = caOpts
+	opts.KeyFile = filepath.Join(dir, "server.key")
+	opts.CertFile = filepath.Join(dir, "server.crt")
+	require.NoError(t, GenerateTLSKeyCertPairToFiles(opts))
+
+	serveOpts := DefaultServeOptions()
+	serveOpts.CertFile = opts.CertFile
+	serveOpts.KeyFile = opts.KeyFile
+	serveOpts.ClientAuth = ClientAuthNone
+	serveOpts.MinVersion = "1.2"
+	serveOpts.MaxVersion = "1.2"
+	serverConfig, err := ServerTLSConfig(serveOpts)
+	require.NoError(t, err)
+	server := httptest.NewUnstartedServer(EchoHandler{})
+	server.TLS = serverConfig
+	server.StartTLS()
+	defer server.Close()
+
+	probeOpts := DefaultProbeOptions()
+	probeOpts.Address = server.Listener.Addr().String()
+	probeOpts.ServerName = "server.openqe.github.io"
+	probeOpts.CAFile = caOpts.CaCertFile
+	probeOpts.EnumerateVersions = true
+	result, err := Probe(context.Background(), probeOpts)
+	require.NoError(t, err)
+	assert.True(t, result.Verified, result.VerifyError)
+	assert.Equal(t, "TLS 1.2", result.Version)
+	assert.NotEmpty(t, result.CipherSuite)
+	require.Len(t, result.Certificates, 1)
+	assert.Equal(t, []string{"server.openqe.github.io"}, result.Certificates[0].DNSNames)
+	accepted := map[string]bool{}
+	for _, v := range result.Versions {
+		accepted[v.Version] = v.Accepted
+	}
+	assert.Equal(t, map[string]bool{"TLS 1.0": false, "TLS 1.1": false, "TLS 1.2": true, "TLS 1.3": false}, accepted)
+
+	probeOpts.ServerName = "other.openqe.github.io"
+	probeOpts.EnumerateVersions = false
+	result, err = Probe(context.Background(), probeOpts)
+	require.NoError(t, err)
+	assert.False(t, result.Verified)
+	assert.Contains(t, result.VerifyError, string(VerifyNameMismatch))
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteProbeResult(&buf, result, OutputFormatText))
+	assert.Contains(t, buf.String(), "Verification:     FAILED")
+}
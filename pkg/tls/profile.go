@@ -0,0 +1,156 @@
+package tls
+
+import (
+	"crypto/x509"
+	"encoding/asn1"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Profile is a named set of key usages, extended key usages and basic constraints of a certificate
+type Profile string
+
+const (
+	// ProfileServer is a TLS server certificate
+	ProfileServer Profile = "server"
+	// ProfileClient is a TLS client certificate
+	ProfileClient Profile = "client"
+	// ProfilePeer is a certificate for both TLS server and client, like the etcd peer certificates
+	ProfilePeer Profile = "peer"
+	// ProfileCA is a CA certificate able to sign certificates and CRLs
+	ProfileCA Profile = "ca"
+	// ProfileCodeSigning is a code signing certificate
+	ProfileCodeSigning Profile = "code-signing"
+)
+
+// Profiles are all supported profiles
+var Profiles = []Profile{ProfileServer, ProfileClient, ProfilePeer, ProfileCA, ProfileCodeSigning}
+
+// ExtKeyUsages maps the OpenSSL names of the common extended key usages to their values
+var ExtKeyUsages = map[string]x509.ExtKeyUsage{
+	"any":             x509.ExtKeyUsageAny,
+	"serverAuth":      x509.ExtKeyUsageServerAuth,
+	"clientAuth":      x509.ExtKeyUsageClientAuth,
+	"codeSigning":     x509.ExtKeyUsageCodeSigning,
+	"emailProtection": x509.ExtKeyUsageEmailProtection,
+	"timeStamping":    x509.ExtKeyUsageTimeStamping,
+	"OCSPSigning":     x509.ExtKeyUsageOCSPSigning,
+}
+
+// ParseProfile validates the name of a profile, an empty name means no profile
+func ParseProfile(name string) (Profile, error) {
+	if name == "" {
+		return "", nil
+	}
+	for _, p := range Profiles {
+		if string(p) == name {
+			return p, nil
+		}
+	}
+	names := make([]string, 0, len(Profiles))
+	for _, p := range Profiles {
+		names = append(names, string(p))
+	}
+	return "", fmt.Errorf("unsupported profile: %s, supported: %s", name, strings.Join(names, ", "))
+}
+
+// ApplyProfile sets the key usages, the extended key usages and the basic constraints of the profile.
+// The key encipherment usage is only set for the RSA keys, as the other keys can not encipher.
+func (cfg *CertCfg) ApplyProfile(profile Profile) error {
+	leafKeyUsages := x509.KeyUsageDigitalSignature
+	if cfg.KeyAlgorithm == "" || cfg.KeyAlgorithm == KeyAlgorithmRSA {
+		leafKeyUsages |= x509.KeyUsageKeyEncipherment
+	}
+	switch profile {
+	case "":
+		return nil
+	case ProfileServer:
+		cfg.IsCA = false
+		cfg.KeyUsages = leafKeyUsages
+		cfg.ExtKeyUsages = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+	case ProfileClient:
+		cfg.IsCA = false
+		cfg.KeyUsages = leafKeyUsages
+		cfg.ExtKeyUsages = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
+	case ProfilePeer:
+		cfg.IsCA = false
+		cfg.KeyUsages = leafKeyUsages
+		cfg.ExtKeyUsages = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth}
+	case ProfileCA:
+		cfg.IsCA = true
+		cfg.KeyUsages = caKeyUsages
+		cfg.ExtKeyUsages = nil
+	case ProfileCodeSigning:
+		cfg.IsCA = false
+		cfg.KeyUsages = x509.KeyUsageDigitalSignature
+		cfg.ExtKeyUsages = []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning}
+	default:
+		return fmt.Errorf("unsupported profile: %s", profile)
+	}
+	return nil
+}
+
+// AddExtKeyUsages adds extended key usages to the cfg, specified by their OpenSSL names like serverAuth,
+// or by their dotted OIDs like 1.3.6.1.5.5.7.3.1. The OIDs unknown to crypto/x509 are kept as they are.
+func (cfg *CertCfg) AddExtKeyUsages(usages ...string) error {
+	for _, usage := range usages {
+		if eku, ok := ExtKeyUsages[usage]; ok {
+			cfg.ExtKeyUsages = append(cfg.ExtKeyUsages, eku)
+			continue
+		}
+		if usage == "" || usage[0] < '0' || usage[0] > '9' {
+			return fmt.Errorf("invalid extended key usage: %s, a name like serverAuth or a dotted OID is expected", usage)
+		}
+		oid, err := parseOID(usage)
+		if err != nil {
+			return fmt.Errorf("invalid extended key usage: %w", err)
+		}
+		cfg.UnknownExtKeyUsages = append(cfg.UnknownExtKeyUsages, oid)
+	}
+	return nil
+}
+
+// parseOID parses a dotted object identifier like 1.3.6.1.5.5.7.3.1, the arcs have no leading zeros,
+// the first arc is 0, 1 or 2 and the second arc is at most 39 under 0 and 1 as required by X.660
+func parseOID(s string) (asn1.ObjectIdentifier, error) {
+	arcs := strings.Split(s, ".")
+	if len(arcs) < 2 {
+		return nil, fmt.Errorf("invalid OID %q, at least two arcs are required", s)
+	}
+	oid := make(asn1.ObjectIdentifier, 0, len(arcs))
+	for _, arc := range arcs {
+		n, err := strconv.Atoi(arc)
+		if err != nil || n < 0 || (len(arc) > 1 && arc[0] == '0') {
+			return nil, fmt.Errorf("invalid OID %q, the arcs need to be decimal numbers without leading zeros", s)
+		}
+		oid = append(oid, n)
+	}
+	if oid[0] > 2 || (oid[0] < 2 && oid[1] > 39) {
+		return nil, fmt.Errorf("invalid OID %q, the first arc needs to be 0, 1 or 2 and the second arc at most 39 under 0 and 1", s)
+	}
+	return oid, nil
+}
+
+// applyProfileOptions applies the profile name and adds the extended key usages given by options to the cfg
+func applyProfileOptions(cfg *CertCfg, profileName string, extKeyUsages []string) error {
+	profile, err := ParseProfile(profileName)
+	if err != nil {
+		return err
+	}
+	if err := cfg.ApplyProfile(profile); err != nil {
+		return err
+	}
+	return cfg.AddExtKeyUsages(extKeyUsages...)
+}
+
+// keyAlgorithmOf returns the KeyAlgorithm of a public key algorithm
+func keyAlgorithmOf(alg x509.PublicKeyAlgorithm) KeyAlgorithm {
+	switch alg {
+	case x509.ECDSA:
+		return KeyAlgorithmECDSA
+	case x509.Ed25519:
+		return KeyAlgorithmEd25519
+	}
+	return KeyAlgorithmRSA
+}
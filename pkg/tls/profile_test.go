@@ -0,0 +1,79 @@
+package tls
+
+import (
+	"crypto/x509"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateTLSKeyCertPair_Profiles(t *testing.T) {
+	caOpts, _ := newTestCA(t)
+
+	tests := []struct {
+		profile   Profile
+		keyUsages x509.KeyUsage
+		extUsages []x509.ExtKeyUsage
+		isCA      bool
+	}{
+		{ProfileServer, x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment, []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}, false},
+		{ProfileClient, x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment, []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}, false},
+		{ProfilePeer, x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment, []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth}, false},
+		{ProfileCA, caKeyUsages, nil, true},
+		{ProfileCodeSigning, x509.KeyUsageDigitalSignature, []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning}, false},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.profile), func(t *testing.T) {
+			opts := DefaultPKIOptions()
+			opts.CaGenOpt = caOpts
+			opts.Profile = string(tt.profile)
+			_, cert, err := GenerateTLSKeyCertPair(opts)
+			require.NoError(t, err)
+			assert.Equal(t, tt.keyUsages, cert.KeyUsage)
+			assert.Equal(t, tt.extUsages, cert.ExtKeyUsage)
+			assert.Equal(t, tt.isCA, cert.IsCA)
+		})
+	}
+
+	// the server profile is the default, an empty profile sets no extended key usage
+	opts := DefaultPKIOptions()
+	opts.CaGenOpt = caOpts
+	_, cert, err := GenerateTLSKeyCertPair(opts)
+	require.NoError(t, err)
+	assert.Equal(t, []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}, cert.ExtKeyUsage)
+	opts.Profile = ""
+	_, cert, err = GenerateTLSKeyCertPair(opts)
+	require.NoError(t, err)
+	assert.Empty(t, cert.ExtKeyUsage)
+
+	opts.KeyAlgorithm = string(KeyAlgorithmECDSA)
+	opts.Profile = string(ProfileClient)
+	opts.ExtKeyUsages = []string{"emailProtection", "1.3.6.1.4.1.311.20.2.2"}
+	_, cert, err = GenerateTLSKeyCertPair(opts)
+	require.NoError(t, err)
+	assert.Equal(t, x509.KeyUsageDigitalSignature, cert.KeyUsage)
+	assert.Equal(t, []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth, x509.ExtKeyUsageEmailProtection}, cert.ExtKeyUsage)
+	require.Len(t, cert.UnknownExtKeyUsage, 1)
+	assert.Equal(t, "1.3.6.1.4.1.311.20.2.2", cert.UnknownExtKeyUsage[0].String())
+
+	opts.ExtKeyUsages = []string{"notAnUsage"}
+	_, _, err = GenerateTLSKeyCertPair(opts)
+	assert.Error(t, err)
+	opts.ExtKeyUsages = []string{"3.1"}
+	_, _, err = GenerateTLSKeyCertPair(opts)
+	assert.ErrorContains(t, err, "the first arc needs to be 0, 1 or 2")
+}
+
+func TestParseOID(t *testing.T) {
+	oid, err := parseOID("1.3.6.1.5.5.7.3.1")
+	require.NoError(t, err)
+	assert.Equal(t, "1.3.6.1.5.5.7.3.1", oid.String())
+	for _, s := range []string{"", "1", "3.1", "1.40", "0.40.1", "1.03", "1..2", "1.-2", "1.2.x"} {
+		_, err := parseOID(s)
+		assert.Error(t, err, s)
+	}
+	oid, err = parseOID("2.999.1")
+	require.NoError(t, err)
+	assert.Equal(t, "2.999.1", oid.String())
+}
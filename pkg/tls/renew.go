@@ -0,0 +1,177 @@
+package tls
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"os"
+	"time"
+)
+
+// ReissueCertificate issues a new certificate for the public key with the subject, the SANs and the extensions of cert,
+// valid from now for the validity, or for the validity of cert when it is not positive. The certificate is signed by
+// issuerKey and issuerCert, or self-signed by issuerKey when issuerCert is nil. The CRL, OCSP and issuer URLs of cert
+// are dropped when issuerCert is not the issuer of cert.
+func ReissueCertificate(cert *x509.Certificate, pub crypto.PublicKey, issuerCert *x509.Certificate, issuerKey crypto.Signer, validity time.Duration) (*x509.Certificate, error) {
+	serial, err := rand.Int(Reader(), new(big.Int).SetInt64(math.MaxInt64))
+	if err != nil {
+		return nil, err
+	}
+	if validity <= 0 {
+		validity = cert.NotAfter.Sub(cert.NotBefore)
+	}
+	now := time.Now()
+	tmpl := &x509.Certificate{
+		SerialNumber:                serial,
+		RawSubject:                  cert.RawSubject,
+		NotBefore:                   now,
+		NotAfter:                    now.Add(validity),
+		KeyUsage:                    cert.KeyUsage,
+		ExtKeyUsage:                 cert.ExtKeyUsage,
+		UnknownExtKeyUsage:          cert.UnknownExtKeyUsage,
+		BasicConstraintsValid:       cert.BasicConstraintsValid,
+		IsCA:                        cert.IsCA,
+		MaxPathLen:                  cert.MaxPathLen,
+		MaxPathLenZero:              cert.MaxPathLenZero,
+		SubjectKeyId:                cert.SubjectKeyId,
+		DNSNames:                    cert.DNSNames,
+		EmailAddresses:              cert.EmailAddresses,
+		IPAddresses:                 cert.IPAddresses,
+		URIs:                        cert.URIs,
+		PermittedDNSDomainsCritical: cert.PermittedDNSDomainsCritical,
+		PermittedDNSDomains:         cert.PermittedDNSDomains,
+		ExcludedDNSDomains:          cert.ExcludedDNSDomains,
+		PermittedIPRanges:           cert.PermittedIPRanges,
+		ExcludedIPRanges:            cert.ExcludedIPRanges,
+		PermittedEmailAddresses:     cert.PermittedEmailAddresses,
+		ExcludedEmailAddresses:      cert.ExcludedEmailAddresses,
+		PermittedURIDomains:         cert.PermittedURIDomains,
+		ExcludedURIDomains:          cert.ExcludedURIDomains,
+		Policies:                    cert.Policies,
+	}
+	// the CRL, OCSP and issuer URLs belong to the issuer, they are only kept when the issuer does not change
+	if sameIssuer(cert, issuerCert) {
+		tmpl.CRLDistributionPoints = cert.CRLDistributionPoints
+		tmpl.OCSPServer = cert.OCSPServer
+		tmpl.IssuingCertificateURL = cert.IssuingCertificateURL
+	}
+	for _, ext := range cert.Extensions {
+		if ext.Id.Equal(oidTLSFeature) {
+			tmpl.ExtraExtensions = append(tmpl.ExtraExtensions, pkix.Extension{Id: ext.Id, Critical: ext.Critical, Value: ext.Value})
+		}
+	}
+	parent := issuerCert
+	if parent == nil {
+		parent = tmpl
+	}
+	certBytes, err := x509.CreateCertificate(Reader(), tmpl, parent, pub, issuerKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create certificate: %w", err)
+	}
+	return x509.ParseCertificate(certBytes)
+}
+
+// sameIssuer reports whether issuerCert is the issuer of cert, a nil issuerCert is cert itself when it is self-signed
+func sameIssuer(cert, issuerCert *x509.Certificate) bool {
+	if issuerCert == nil {
+		return IsSelfSigned(cert)
+	}
+	if !bytes.Equal(cert.RawIssuer, issuerCert.RawSubject) {
+		return false
+	}
+	return len(cert.AuthorityKeyId) == 0 || len(issuerCert.SubjectKeyId) == 0 || bytes.Equal(cert.AuthorityKeyId, issuerCert.SubjectKeyId)
+}
+
+// RenewToFile renews the certificate file for its private key with a new validity window, keeping the subject,
+// the SANs and the extensions. A CA in the options re-signs the certificate, which is recorded in the CA database when
+// the CaDBFile of the CA is set, otherwise the certificate must be self-signed and is signed by its own key again.
+func RenewToFile(opts *RenewOptions) error {
+	if opts.KeyFile == "" || opts.CertFile == "" {
+		return errors.New("both keyFile and certFile need to be specified to renew a certificate")
+	}
+	keyBytes, err := os.ReadFile(opts.KeyFile)
+	if err != nil {
+		return fmt.Errorf("failed to read private key file: %w", err)
+	}
+	certBytes, err := os.ReadFile(opts.CertFile)
+	if err != nil {
+		return fmt.Errorf("failed to read certificate file: %w", err)
+	}
+	passphrase, err := readPassphrase(opts.KeyPassphraseFile)
+	if err != nil {
+		return err
+	}
+	key, cert, err := parsePemKeypair(keyBytes, certBytes, passphrase)
+	if err != nil {
+		return fmt.Errorf("failed to load key/cert pair: %w", err)
+	}
+
+	var chain []*x509.Certificate
+	if opts.CaGenOpt != nil && (opts.CaGenOpt.CaKeyFile != "" || opts.CaGenOpt.CaCertFile != "") {
+		caKey, caChain, err := opts.CaGenOpt.LoadCA()
+		if err != nil {
+			return err
+		}
+		renewed, err := ReissueCertificate(cert, key.Public(), caChain[0], caKey, opts.Validity)
+		if err != nil {
+			return err
+		}
+		if err := opts.CaGenOpt.RecordIssued(renewed); err != nil {
+			return err
+		}
+		chain = append([]*x509.Certificate{renewed}, intermediates(caChain)...)
+	} else {
+		if !IsSelfSigned(cert) {
+			return fmt.Errorf("the certificate is issued by %q, the CA key and certificate files of the issuer are required to renew it", cert.Issuer)
+		}
+		renewed, err := ReissueCertificate(cert, key.Public(), nil, key, opts.Validity)
+		if err != nil {
+			return err
+		}
+		chain = []*x509.Certificate{renewed}
+	}
+	outFile := opts.OutFile
+	if outFile == "" {
+		outFile = opts.CertFile
+	}
+	return os.WriteFile(outFile, CertsToPem(chain), 0644)
+}
+
+// CrossSignToFile issues a certificate for the public key and the subject of an existing CA, signed by the issuer
+// CA in the options, so the certificates issued by the existing CA are also trusted through the issuer CA.
+// The output file contains the cross-signed certificate followed by the intermediate CAs of the issuer.
+func CrossSignToFile(opts *CrossSignOptions) error {
+	if opts.CertFile == "" || opts.OutFile == "" {
+		return errors.New("both certFile and outFile need to be specified to cross-sign a CA")
+	}
+	certBytes, err := os.ReadFile(opts.CertFile)
+	if err != nil {
+		return fmt.Errorf("failed to read CA certificate file: %w", err)
+	}
+	cert, err := PemToCertificate(certBytes)
+	if err != nil {
+		return fmt.Errorf("failed to load CA certificate from file: %w", err)
+	}
+	if !cert.IsCA {
+		return fmt.Errorf("%q is not a CA certificate", cert.Subject)
+	}
+	caKey, caChain, err := opts.CaGenOpt.LoadCA()
+	if err != nil {
+		return err
+	}
+	crossSigned, err := ReissueCertificate(cert, cert.PublicKey, caChain[0], caKey, opts.Validity)
+	if err != nil {
+		return err
+	}
+	if err := opts.CaGenOpt.RecordIssued(crossSigned); err != nil {
+		return err
+	}
+	chain := append([]*x509.Certificate{crossSigned}, intermediates(caChain)...)
+	return os.WriteFile(opts.OutFile, CertsToPem(chain), 0644)
+}
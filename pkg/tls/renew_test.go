@@ -0,0 +1,124 @@
+package tls
+
+import (
+	"crypto/x509"
+	"net"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenewAndCrossSign(t *testing.T) {
+	newCA := func(name string) *CAOptions {
+		opts, _ := newTestCA(t, func(o *CAOptions) { o.Subject = "CN=" + name + ", OU=openqe" })
+		return opts
+	}
+	oldCA, nextCA := newCA("old"), newCA("next")
+	dir := t.TempDir()
+	opts := DefaultPKIOptions()
+	opts.CaGenOpt = oldCA
+	opts.KeyFile = filepath.Join(dir, "tls.key")
+	opts.CertFile = filepath.Join(dir, "tls.crt")
+	require.NoError(t, GenerateTLSKeyCertPairToFiles(opts))
+	original := loadCertificate(t, opts.CertFile)
+
+	renewOpts := DefaultRenewOptions()
+	renewOpts.KeyFile = opts.KeyFile
+	renewOpts.CertFile = opts.CertFile
+	assert.Error(t, RenewToFile(renewOpts), "a certificate issued by a CA needs the CA to renew")
+
+	renewOpts.CaGenOpt = nextCA
+	renewOpts.OutFile = filepath.Join(dir, "renewed.crt")
+	renewOpts.Validity = 2 * ValidityOneDay
+	require.NoError(t, RenewToFile(renewOpts))
+	renewed := loadCertificate(t, renewOpts.OutFile)
+	assert.Equal(t, original.RawSubject, renewed.RawSubject)
+	assert.Equal(t, original.RawSubjectPublicKeyInfo, renewed.RawSubjectPublicKeyInfo)
+	assert.Equal(t, original.DNSNames, renewed.DNSNames)
+	assert.NotEqual(t, original.SerialNumber, renewed.SerialNumber)
+	assert.Equal(t, 2*ValidityOneDay, renewed.NotAfter.Sub(renewed.NotBefore))
+	nextCert := loadCertificate(t, nextCA.CaCertFile)
+	assert.NoError(t, renewed.CheckSignatureFrom(nextCert))
+
+	// a self-signed CA is renewed with its own key
+	caRenewOpts := DefaultRenewOptions()
+	caRenewOpts.KeyFile = nextCA.CaKeyFile
+	caRenewOpts.CertFile = nextCA.CaCertFile
+	caRenewOpts.OutFile = filepath.Join(dir, "next-renewed.crt")
+	require.NoError(t, RenewToFile(caRenewOpts))
+	renewedCA := loadCertificate(t, caRenewOpts.OutFile)
+	assert.True(t, renewedCA.IsCA)
+	assert.True(t, IsSelfSigned(renewedCA))
+	assert.Equal(t, nextCert.NotAfter.Sub(nextCert.NotBefore), renewedCA.NotAfter.Sub(renewedCA.NotBefore))
+	assert.NoError(t, renewed.CheckSignatureFrom(renewedCA))
+
+	crossOpts := DefaultCrossSignOptions()
+	crossOpts.CertFile = nextCA.CaCertFile
+	crossOpts.CaGenOpt = oldCA
+	crossOpts.OutFile = filepath.Join(dir, "cross.crt")
+	require.NoError(t, CrossSignToFile(crossOpts))
+	cross := loadCertificate(t, crossOpts.OutFile)
+	roots := x509.NewCertPool()
+	roots.AddCert(loadCertificate(t, oldCA.CaCertFile))
+	intermediates := x509.NewCertPool()
+	intermediates.AddCert(cross)
+	_, err := renewed.Verify(x509.VerifyOptions{Roots: roots, Intermediates: intermediates})
+	assert.NoError(t, err, "the certificate issued by the next CA is trusted by the old CA through the cross-signed CA")
+
+	crossOpts.CertFile = opts.CertFile
+	assert.Error(t, CrossSignToFile(crossOpts), "only a CA can be cross-signed")
+}
+
+func TestReissueCertificate(t *testing.T) {
+	oldCA, _ := newTestCA(t)
+	nextCA, _ := newTestCA(t, func(o *CAOptions) { o.Subject = "CN=next, OU=openqe" })
+	opts := DefaultPKIOptions()
+	opts.CaGenOpt = oldCA
+	opts.CRLDistributionPoints = []string{"http://crl.openqe.github.io/ca.crl"}
+	opts.OCSPServers = []string{"http://ocsp.openqe.github.io"}
+	key, cert, err := GenerateTLSKeyCertPair(opts)
+	require.NoError(t, err)
+
+	// the URLs of the issuer are kept by the same issuer, and dropped by another one
+	for _, ca := range []*CAOptions{oldCA, nextCA} {
+		caKey, caChain, err := ca.LoadCA()
+		require.NoError(t, err)
+		renewed, err := ReissueCertificate(cert, key.Public(), caChain[0], caKey, 0)
+		require.NoError(t, err)
+		if ca == oldCA {
+			assert.Equal(t, cert.CRLDistributionPoints, renewed.CRLDistributionPoints)
+			assert.Equal(t, cert.OCSPServer, renewed.OCSPServer)
+		} else {
+			assert.Empty(t, renewed.CRLDistributionPoints)
+			assert.Empty(t, renewed.OCSPServer)
+		}
+	}
+
+	// the name constraints of a CA are kept
+	ca := loadCertificate(t, oldCA.CaCertFile)
+	caKey, _, err := oldCA.LoadCA()
+	require.NoError(t, err)
+	_, ipRange, err := net.ParseCIDR("10.0.0.0/8")
+	require.NoError(t, err)
+	tmpl := *ca
+	tmpl.PermittedDNSDomainsCritical = true
+	tmpl.PermittedDNSDomains = []string{"openqe.github.io"}
+	tmpl.ExcludedDNSDomains = []string{"bad.openqe.github.io"}
+	tmpl.PermittedIPRanges = []*net.IPNet{ipRange}
+	tmpl.ExcludedEmailAddresses = []string{"bad@openqe.github.io"}
+	tmpl.PermittedURIDomains = []string{".openqe.github.io"}
+	der, err := x509.CreateCertificate(Reader(), &tmpl, &tmpl, caKey.Public(), caKey)
+	require.NoError(t, err)
+	constrained, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	renewed, err := ReissueCertificate(constrained, caKey.Public(), nil, caKey, 0)
+	require.NoError(t, err)
+	assert.True(t, renewed.PermittedDNSDomainsCritical)
+	assert.Equal(t, constrained.PermittedDNSDomains, renewed.PermittedDNSDomains)
+	assert.Equal(t, constrained.ExcludedDNSDomains, renewed.ExcludedDNSDomains)
+	assert.Equal(t, constrained.PermittedIPRanges, renewed.PermittedIPRanges)
+	assert.Equal(t, constrained.ExcludedEmailAddresses, renewed.ExcludedEmailAddresses)
+	assert.Equal(t, constrained.PermittedURIDomains, renewed.PermittedURIDomains)
+}
@@ -0,0 +1,213 @@
+package tls
+
+import (
+	gotls "crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+const (
+	// ClientAuthAuto requires and verifies the client certificates with a client CA, and requests them otherwise
+	ClientAuthAuto = "auto"
+	// ClientAuthNone does not request client certificates
+	ClientAuthNone = "none"
+	// ClientAuthRequest requests client certificates without requiring or verifying them
+	ClientAuthRequest = "request"
+	// ClientAuthRequire requires client certificates without verifying them
+	ClientAuthRequire = "require"
+	// ClientAuthVerifyIfGiven verifies the client certificates if any is presented
+	ClientAuthVerifyIfGiven = "verify-if-given"
+	// ClientAuthRequireAndVerify requires and verifies the client certificates
+	ClientAuthRequireAndVerify = "require-and-verify"
+)
+
+var clientAuthTypes = map[string]gotls.ClientAuthType{
+	ClientAuthNone:             gotls.NoClientCert,
+	ClientAuthRequest:          gotls.RequestClientCert,
+	ClientAuthRequire:          gotls.RequireAnyClientCert,
+	ClientAuthVerifyIfGiven:    gotls.VerifyClientCertIfGiven,
+	ClientAuthRequireAndVerify: gotls.RequireAndVerifyClientCert,
+}
+
+// TLSVersions maps the version names accepted by the tls commands to the protocol versions
+var TLSVersions = map[string]uint16{
+	"1.0": gotls.VersionTLS10,
+	"1.1": gotls.VersionTLS11,
+	"1.2": gotls.VersionTLS12,
+	"1.3": gotls.VersionTLS13,
+}
+
+// ParseTLSVersion converts a version name like 1.2 to the protocol version, an empty name means the default version
+func ParseTLSVersion(name string) (uint16, error) {
+	if name == "" {
+		return 0, nil
+	}
+	version, ok := TLSVersions[strings.TrimPrefix(strings.ToLower(name), "tls")]
+	if !ok {
+		return 0, fmt.Errorf("unsupported TLS version: %s, supported: 1.0, 1.1, 1.2, 1.3", name)
+	}
+	return version, nil
+}
+
+// ParseCipherSuites converts the standard names of cipher suites, like TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+// to their IDs. The insecure cipher suites are accepted for downgrade testing, names can be separated by commas.
+func ParseCipherSuites(names []string) ([]uint16, error) {
+	suites := map[string]uint16{}
+	for _, suite := range append(gotls.CipherSuites(), gotls.InsecureCipherSuites()...) {
+		suites[suite.Name] = suite.ID
+	}
+	var ids []uint16
+	for _, name := range names {
+		for _, n := range strings.Split(name, ",") {
+			if n = strings.TrimSpace(n); n == "" {
+				continue
+			}
+			id, ok := suites[n]
+			if !ok {
+				return nil, fmt.Errorf("unsupported cipher suite: %s", n)
+			}
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+// ServerTLSConfig creates the TLS config of the HTTPS server described by the options
+func ServerTLSConfig(opts *ServeOptions) (*gotls.Config, error) {
+	if opts.CertFile == "" || opts.KeyFile == "" {
+		return nil, errors.New("both certFile and keyFile need to be specified to serve TLS")
+	}
+	keyBytes, err := os.ReadFile(opts.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read private key file: %w", err)
+	}
+	certBytes, err := os.ReadFile(opts.CertFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read certificate file: %w", err)
+	}
+	passphrase, err := readPassphrase(opts.KeyPassphraseFile)
+	if err != nil {
+		return nil, err
+	}
+	key, _, err := parsePemKeypair(keyBytes, certBytes, passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load key/cert pair: %w", err)
+	}
+	chain, err := PemToCertificates(certBytes)
+	if err != nil {
+		return nil, err
+	}
+	cert := gotls.Certificate{PrivateKey: key, Leaf: chain[0]}
+	for _, c := range chain {
+		cert.Certificate = append(cert.Certificate, c.Raw)
+	}
+	cfg := &gotls.Config{Certificates: []gotls.Certificate{cert}}
+
+	if cfg.MinVersion, err = ParseTLSVersion(opts.MinVersion); err != nil {
+		return nil, err
+	}
+	if cfg.MaxVersion, err = ParseTLSVersion(opts.MaxVersion); err != nil {
+		return nil, err
+	}
+	if cfg.CipherSuites, err = ParseCipherSuites(opts.CipherSuites); err != nil {
+		return nil, err
+	}
+
+	clientAuth := opts.ClientAuth
+	if clientAuth == "" || clientAuth == ClientAuthAuto {
+		clientAuth = ClientAuthRequest
+		if opts.ClientCAFile != "" {
+			clientAuth = ClientAuthRequireAndVerify
+		}
+	}
+	var ok bool
+	if cfg.ClientAuth, ok = clientAuthTypes[clientAuth]; !ok {
+		return nil, fmt.Errorf("unsupported client auth: %s, supported: %s, %s, %s, %s, %s, %s", opts.ClientAuth, ClientAuthAuto,
+			ClientAuthNone, ClientAuthRequest, ClientAuthRequire, ClientAuthVerifyIfGiven, ClientAuthRequireAndVerify)
+	}
+	if opts.ClientCAFile != "" {
+		clientCAs, err := certificatesFromFile(opts.ClientCAFile)
+		if err != nil {
+			return nil, err
+		}
+		cfg.ClientCAs = x509.NewCertPool()
+		for _, ca := range clientCAs {
+			cfg.ClientCAs.AddCert(ca)
+		}
+	} else if cfg.ClientAuth == gotls.VerifyClientCertIfGiven || cfg.ClientAuth == gotls.RequireAndVerifyClientCert {
+		return nil, fmt.Errorf("the client CA file is required to verify the client certificates")
+	}
+	return cfg, nil
+}
+
+// EchoResponse contains the details of a request echoed by the EchoHandler
+type EchoResponse struct {
+	Method     string              `json:"method"`
+	URL        string              `json:"url"`
+	Host       string              `json:"host"`
+	Proto      string              `json:"proto"`
+	RemoteAddr string              `json:"remoteAddr"`
+	Headers    map[string][]string `json:"headers"`
+	TLS        *EchoTLS            `json:"tls,omitempty"`
+}
+
+// EchoTLS contains the details of the TLS connection of an echoed request
+type EchoTLS struct {
+	Version            string `json:"version"`
+	CipherSuite        string `json:"cipherSuite"`
+	ServerName         string `json:"serverName,omitempty"`
+	NegotiatedProtocol string `json:"negotiatedProtocol,omitempty"`
+	DidResume          bool   `json:"didResume"`
+	// ClientCertificates is the chain presented by the client, the leaf certificate first
+	ClientCertificates []CertificateSummary `json:"clientCertificates,omitempty"`
+	// VerifiedChains are the subjects of the chains verified against the client CAs
+	VerifiedChains [][]string `json:"verifiedChains,omitempty"`
+}
+
+// NewEchoResponse creates the EchoResponse of a request
+func NewEchoResponse(req *http.Request) *EchoResponse {
+	resp := &EchoResponse{
+		Method:     req.Method,
+		URL:        req.URL.String(),
+		Host:       req.Host,
+		Proto:      req.Proto,
+		RemoteAddr: req.RemoteAddr,
+		Headers:    req.Header,
+	}
+	if req.TLS == nil {
+		return resp
+	}
+	resp.TLS = &EchoTLS{
+		Version:            gotls.VersionName(req.TLS.Version),
+		CipherSuite:        gotls.CipherSuiteName(req.TLS.CipherSuite),
+		ServerName:         req.TLS.ServerName,
+		NegotiatedProtocol: req.TLS.NegotiatedProtocol,
+		DidResume:          req.TLS.DidResume,
+	}
+	for _, cert := range req.TLS.PeerCertificates {
+		resp.TLS.ClientCertificates = append(resp.TLS.ClientCertificates, SummarizeCertificate(cert))
+	}
+	for _, chain := range req.TLS.VerifiedChains {
+		subjects := make([]string, 0, len(chain))
+		for _, cert := range chain {
+			subjects = append(subjects, cert.Subject.String())
+		}
+		resp.TLS.VerifiedChains = append(resp.TLS.VerifiedChains, subjects)
+	}
+	return resp
+}
+
+// EchoHandler is a http.Handler answering every request with the JSON of its EchoResponse
+type EchoHandler struct{}
+
+func (EchoHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(NewEchoResponse(req))
+}
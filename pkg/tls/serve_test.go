@@ -0,0 +1,113 @@
+package tls
+
+import (
+	gotls "crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServerTLSConfig_EchoClientCertificates(t *testing.T) {
+	caOpts, dir := newTestCA(t)
+	for _, profile := range []Profile{ProfileServer, ProfileClient} {
+		opts := DefaultPKIOptions()
+		opts.CaGenOpt = caOpts
+		opts.Profile = string(profile)
+		opts.Subject = "CN=" + string(profile) + ", OU=openqe"
+		opts.KeyFile = filepath.Join(dir, string(profile)+".key")
+		opts.CertFile = filepath.Join(dir, string(profile)+".crt")
+		require.NoError(t, GenerateTLSKeyCertPairToFiles(opts))
+	}
+
+	serveOpts := DefaultServeOptions()
+	serveOpts.CertFile = filepath.Join(dir, "server.crt")
+	serveOpts.KeyFile = filepath.Join(dir, "server.key")
+	serveOpts.ClientCAFile = caOpts.CaCertFile
+	serveOpts.MaxVersion = "1.2"
+	serveOpts.CipherSuites = []string{"TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384"}
+	serverConfig, err := ServerTLSConfig(serveOpts)
+	require.NoError(t, err)
+	assert.Equal(t, gotls.RequireAndVerifyClientCert, serverConfig.ClientAuth)
+	server := httptest.NewUnstartedServer(EchoHandler{})
+	server.TLS = serverConfig
+	server.StartTLS()
+	defer server.Close()
+
+	roots := x509.NewCertPool()
+	_, caChain, err := LoadCAFromFiles(caOpts.CaKeyFile, caOpts.CaCertFile)
+	require.NoError(t, err)
+	roots.AddCert(caChain[0])
+	clientCert, err := gotls.LoadX509KeyPair(filepath.Join(dir, "client.crt"), filepath.Join(dir, "client.key"))
+	require.NoError(t, err)
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &gotls.Config{
+		RootCAs:      roots,
+		ServerName:   "server.openqe.github.io",
+		Certificates: []gotls.Certificate{clientCert},
+	}}}
+	resp, err := client.Get(server.URL + "/echo")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	var echo EchoResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&echo))
+	assert.Equal(t, "/echo", echo.URL)
+	require.NotNil(t, echo.TLS)
+	assert.Equal(t, "TLS 1.2", echo.TLS.Version)
+	assert.Equal(t, "TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384", echo.TLS.CipherSuite)
+	require.Len(t, echo.TLS.ClientCertificates, 1)
+	assert.Equal(t, "CN=client,OU=openqe", echo.TLS.ClientCertificates[0].Subject)
+	require.Len(t, echo.TLS.VerifiedChains, 1)
+
+	client = &http.Client{Transport: &http.Transport{TLSClientConfig: &gotls.Config{
+		RootCAs:    roots,
+		ServerName: "server.openqe.github.io",
+	}}}
+	_, err = client.Get(server.URL)
+	assert.Error(t, err)
+
+	serveOpts.ClientAuth = "bogus"
+	_, err = ServerTLSConfig(serveOpts)
+	assert.Error(t, err)
+}
+
+func TestServerTLSConfig_EncryptedKey(t *testing.T) {
+	caOpts, dir := newTestCA(t)
+	passFile := filepath.Join(dir, "pass.txt")
+	require.NoError(t, os.WriteFile(passFile, []byte("secret\n"), 0600))
+	opts := DefaultPKIOptions()
+	opts.CaGenOpt = caOpts
+	opts.KeyFile = filepath.Join(dir, "tls.key")
+	opts.CertFile = filepath.Join(dir, "tls.crt")
+	opts.KeyPassphraseFile = passFile
+	require.NoError(t, GenerateTLSKeyCertPairToFiles(opts))
+
+	serveOpts := DefaultServeOptions()
+	serveOpts.CertFile = opts.CertFile
+	serveOpts.KeyFile = opts.KeyFile
+	_, err := ServerTLSConfig(serveOpts)
+	assert.ErrorIs(t, err, ErrEncryptedKey)
+	serveOpts.KeyPassphraseFile = passFile
+	serverConfig, err := ServerTLSConfig(serveOpts)
+	require.NoError(t, err)
+	server := httptest.NewUnstartedServer(EchoHandler{})
+	server.TLS = serverConfig
+	server.StartTLS()
+	defer server.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(loadCertificate(t, caOpts.CaCertFile))
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &gotls.Config{
+		RootCAs:    roots,
+		ServerName: "server.openqe.github.io",
+	}}}
+	resp, err := client.Get(server.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
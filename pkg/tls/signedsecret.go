@@ -0,0 +1,58 @@
+package tls
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// certCfg builds the CertCfg of the certificate in the Secret described by the options
+func (o *SignedSecretOptions) certCfg() (*CertCfg, error) {
+	cfg, err := leafCertCfg(o.Subject, &o.SANOptions, o.KeyAlgorithm, o.KeySize)
+	if err != nil {
+		return nil, err
+	}
+	if o.Validity > 0 {
+		cfg.Validity = o.Validity
+	}
+	if err := applyProfileOptions(cfg, o.Profile, o.ExtKeyUsages); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// ReconcileSignedSecret makes the secret a TLS Secret with the tls.crt, tls.key and ca.crt keys of a certificate
+// signed by the CA in the ca Secret, and stamps the hash of the CA in the CAHashAnnotation, the way HyperShift does.
+// The certificate is only re-signed when the CA hash changes, or when the key/cert pair does not match the options
+// or its remaining validity is below opts.MinRemaining, otherwise the secret is left as is.
+// The keys of the CA in the ca Secret default to ca.crt and ca.key, and can be changed by o.
+// It returns whether the secret is changed.
+func ReconcileSignedSecret(secret, ca *corev1.Secret, opts *SignedSecretOptions, o ...func(*CAOpts)) (bool, error) {
+	caOpts := (&CAOpts{}).withDefaults().withOpts(o...)
+	if !validCA(ca, caOpts) {
+		return false, fmt.Errorf("invalid CA signer secret %s/%s, it needs the %s and %s keys", ca.Namespace, ca.Name, caOpts.CASignerCertMapKey, caOpts.CASignerKeyMapKey)
+	}
+	cfg, err := opts.certCfg()
+	if err != nil {
+		return false, err
+	}
+	if HasCAHash(secret, ca, caOpts) && ValidateKeyPair(secret.Data[TLSSignerKeyMapKey], secret.Data[TLSSignerCertMapKey], cfg, opts.MinRemaining) == nil {
+		return false, nil
+	}
+	crtBytes, keyBytes, caBytes, err := signCertificate(cfg, ca, caOpts)
+	if err != nil {
+		return false, err
+	}
+	if secret.Type == "" {
+		// the type of an existing Secret is immutable
+		secret.Type = corev1.SecretTypeTLS
+	}
+	if secret.Data == nil {
+		secret.Data = map[string][]byte{}
+	}
+	secret.Data[TLSSignerCertMapKey] = crtBytes
+	secret.Data[TLSSignerKeyMapKey] = keyBytes
+	secret.Data[CASignerCertMapKey] = caBytes
+	annotateWithCA(secret, ca, caOpts)
+	return true, nil
+}
@@ -0,0 +1,75 @@
+package tls
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestReconcileSignedSecret(t *testing.T) {
+	newCASecret := func() *corev1.Secret {
+		key, cert, err := GenerateCA()
+		require.NoError(t, err)
+		keyPEM, err := PrivateKeyToPem(key)
+		require.NoError(t, err)
+		return NewCASecret(metav1.ObjectMeta{Namespace: "test", Name: "ca"}, keyPEM, CertToPem(cert))
+	}
+	ca := newCASecret()
+	opts := DefaultSignedSecretOptions()
+	opts.Validity = 10 * ValidityOneDay
+	opts.MinRemaining = 5 * ValidityOneDay
+	secret := &corev1.Secret{}
+	changed, err := ReconcileSignedSecret(secret, ca, opts)
+	require.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, corev1.SecretTypeTLS, secret.Type)
+	assert.True(t, HasCAHash(secret, ca, &CAOpts{}))
+	cfg, err := opts.certCfg()
+	require.NoError(t, err)
+	require.NoError(t, ValidateKeyPair(secret.Data[TLSSignerKeyMapKey], secret.Data[TLSSignerCertMapKey], cfg, 0))
+	assert.Equal(t, ca.Data[CASignerCertMapKey], secret.Data[CASignerCertMapKey])
+
+	// nothing changes while the CA and the certificate stay valid
+	crt := secret.Data[TLSSignerCertMapKey]
+	changed, err = ReconcileSignedSecret(secret, ca, opts)
+	require.NoError(t, err)
+	assert.False(t, changed)
+	assert.Equal(t, crt, secret.Data[TLSSignerCertMapKey])
+
+	// the certificate is re-signed when the remaining validity falls below the threshold, or when the SANs change
+	opts.MinRemaining = 20 * ValidityOneDay
+	changed, err = ReconcileSignedSecret(secret, ca, opts)
+	require.NoError(t, err)
+	assert.True(t, changed)
+	opts.MinRemaining = 5 * ValidityOneDay
+	opts.DNSNames = []string{"other.openqe.github.io"}
+	changed, err = ReconcileSignedSecret(secret, ca, opts)
+	require.NoError(t, err)
+	assert.True(t, changed)
+
+	// the certificate is re-signed by the new CA when the CA rotates
+	rotated := newCASecret()
+	assert.False(t, HasCAHash(secret, rotated, &CAOpts{}))
+	changed, err = ReconcileSignedSecret(secret, rotated, opts)
+	require.NoError(t, err)
+	assert.True(t, changed)
+	assert.True(t, HasCAHash(secret, rotated, &CAOpts{}))
+	caCert, err := PemToCertificate(rotated.Data[CASignerCertMapKey])
+	require.NoError(t, err)
+	cert, err := PemToCertificate(secret.Data[TLSSignerCertMapKey])
+	require.NoError(t, err)
+	require.NoError(t, cert.CheckSignatureFrom(caCert))
+
+	// the CA keys can be changed, like the ones of a CA in a TLS Secret
+	tlsCA := &corev1.Secret{Data: map[string][]byte{TLSSignerCertMapKey: rotated.Data[CASignerCertMapKey], TLSSignerKeyMapKey: rotated.Data[CASignerKeyMapKey]}}
+	_, err = ReconcileSignedSecret(&corev1.Secret{}, tlsCA, opts)
+	assert.ErrorContains(t, err, "invalid CA signer secret")
+	changed, err = ReconcileSignedSecret(&corev1.Secret{}, tlsCA, opts, func(o *CAOpts) {
+		o.CASignerCertMapKey, o.CASignerKeyMapKey = TLSSignerCertMapKey, TLSSignerKeyMapKey
+	})
+	require.NoError(t, err)
+	assert.True(t, changed)
+}
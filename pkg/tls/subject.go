@@ -0,0 +1,324 @@
+package tls
+
+import (
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	oidEmailAddress    = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 1}
+	oidDomainComponent = asn1.ObjectIdentifier{0, 9, 2342, 19200300, 100, 1, 25}
+)
+
+// subjectAttributes are the attribute types of a subject by their lower case names, as used by RFC 4514 and OpenSSL
+var subjectAttributes = map[string]asn1.ObjectIdentifier{
+	"cn":                     {2, 5, 4, 3},
+	"commonname":             {2, 5, 4, 3},
+	"sn":                     {2, 5, 4, 4},
+	"surname":                {2, 5, 4, 4},
+	"serialnumber":           {2, 5, 4, 5},
+	"c":                      {2, 5, 4, 6},
+	"countryname":            {2, 5, 4, 6},
+	"l":                      {2, 5, 4, 7},
+	"localityname":           {2, 5, 4, 7},
+	"st":                     {2, 5, 4, 8},
+	"stateorprovincename":    {2, 5, 4, 8},
+	"street":                 {2, 5, 4, 9},
+	"streetaddress":          {2, 5, 4, 9},
+	"o":                      {2, 5, 4, 10},
+	"organizationname":       {2, 5, 4, 10},
+	"ou":                     {2, 5, 4, 11},
+	"organizationalunitname": {2, 5, 4, 11},
+	"title":                  {2, 5, 4, 12},
+	"postalcode":             {2, 5, 4, 17},
+	"gn":                     {2, 5, 4, 42},
+	"givenname":              {2, 5, 4, 42},
+	"initials":               {2, 5, 4, 43},
+	"generationqualifier":    {2, 5, 4, 44},
+	"dnqualifier":            {2, 5, 4, 46},
+	"pseudonym":              {2, 5, 4, 65},
+	"uid":                    {0, 9, 2342, 19200300, 100, 1, 1},
+	"userid":                 {0, 9, 2342, 19200300, 100, 1, 1},
+	"dc":                     oidDomainComponent,
+	"domaincomponent":        oidDomainComponent,
+	"emailaddress":           oidEmailAddress,
+	"email":                  oidEmailAddress,
+	"e":                      oidEmailAddress,
+}
+
+// nameFields are the attribute types with a field in pkix.Name, the others are kept in its ExtraNames
+var nameFields = []asn1.ObjectIdentifier{
+	{2, 5, 4, 3}, {2, 5, 4, 5}, {2, 5, 4, 6}, {2, 5, 4, 7}, {2, 5, 4, 8}, {2, 5, 4, 9}, {2, 5, 4, 10}, {2, 5, 4, 11}, {2, 5, 4, 17},
+}
+
+// ParseSubject parses a subject in the forms accepted by ParseRDNSequence into pkix.Name.
+// The attributes without a field in pkix.Name, like emailAddress, DC or a dotted OID, are set in its ExtraNames.
+// As pkix.Name does not keep the order and the multi-valued RDNs, use the DER encoding of the RDNSequence
+// as the RawSubject of a certificate to keep them.
+func ParseSubject(subject string) (pkix.Name, error) {
+	rdns, err := ParseRDNSequence(subject)
+	if err != nil {
+		return pkix.Name{}, err
+	}
+	return nameFromRDNSequence(rdns), nil
+}
+
+func nameFromRDNSequence(rdns pkix.RDNSequence) pkix.Name {
+	var name pkix.Name
+	name.FillFromRDNSequence(&rdns)
+	for _, rdn := range rdns {
+		for _, atv := range rdn {
+			if !slices.ContainsFunc(nameFields, atv.Type.Equal) {
+				name.ExtraNames = append(name.ExtraNames, atv)
+			}
+		}
+	}
+	return name
+}
+
+// ParseRDNSequence parses a distinguished name in the RFC 4514 form, like CN=server,O=Example\, Inc.,C=US,
+// or in the OpenSSL oneline form, like /C=US/O=Example, Inc./CN=server. The RFC 4514 form lists the most
+// specific RDN first, so it is reversed into the order of the OpenSSL form, which is the order of the encoding.
+//
+// The attributes of a multi-valued RDN are joined by +. An attribute type is one of the names like CN, O, OU,
+// emailAddress, serialNumber, street, postalCode, DC or UID in any case, or a dotted OID like 1.2.3.4.
+// A value can be quoted, can escape a special character or a hex pair with \, or can be # followed by
+// the hex of its DER encoding. Spaces around the separators are ignored.
+func ParseRDNSequence(subject string) (pkix.RDNSequence, error) {
+	s := strings.TrimSpace(subject)
+	oneline := strings.HasPrefix(s, "/")
+	scanner := &dnScanner{s: s, sep: ','}
+	if oneline {
+		scanner.s, scanner.sep = s[1:], '/'
+	}
+	if scanner.s == "" {
+		return nil, nil
+	}
+	var rdns pkix.RDNSequence
+	var rdn pkix.RelativeDistinguishedNameSET
+	for {
+		atv, end, err := scanner.attribute()
+		if err != nil {
+			return nil, fmt.Errorf("invalid subject %q: %w", subject, err)
+		}
+		rdn = append(rdn, atv)
+		if end != '+' {
+			rdns = append(rdns, rdn)
+			rdn = nil
+		}
+		if end == 0 {
+			break
+		}
+	}
+	if !oneline {
+		slices.Reverse(rdns)
+	}
+	return rdns, nil
+}
+
+// IsCountryFirstSubject reports whether a subject in the RFC 4514 form lists the country first and the common name
+// last, like C=US,O=Example,CN=server. As the RFC 4514 form lists the most specific RDN first, such a subject is
+// encoded with the common name first, which is likely not intended when it is written in the order of the encoding.
+func IsCountryFirstSubject(subject string) bool {
+	if strings.HasPrefix(strings.TrimSpace(subject), "/") {
+		return false
+	}
+	rdns, err := ParseRDNSequence(subject)
+	if err != nil || len(rdns) < 2 {
+		return false
+	}
+	hasType := func(rdn pkix.RelativeDistinguishedNameSET, oid asn1.ObjectIdentifier) bool {
+		return slices.ContainsFunc(rdn, func(atv pkix.AttributeTypeAndValue) bool { return atv.Type.Equal(oid) })
+	}
+	return hasType(rdns[len(rdns)-1], subjectAttributes["c"]) && hasType(rdns[0], subjectAttributes["cn"])
+}
+
+// dnScanner scans the attributes of a distinguished name separated by sep or +
+type dnScanner struct {
+	s   string
+	pos int
+	sep byte
+}
+
+func (p *dnScanner) eof() bool {
+	return p.pos >= len(p.s)
+}
+
+func (p *dnScanner) skipSpaces() {
+	for !p.eof() && p.s[p.pos] == ' ' {
+		p.pos++
+	}
+}
+
+// end consumes the separator after an attribute and returns it, or 0 at the end of the name
+func (p *dnScanner) end() (byte, error) {
+	p.skipSpaces()
+	if p.eof() {
+		return 0, nil
+	}
+	c := p.s[p.pos]
+	if c != p.sep && c != '+' {
+		return 0, fmt.Errorf("unexpected %q at offset %d", c, p.pos)
+	}
+	p.pos++
+	return c, nil
+}
+
+// attribute scans a type=value attribute and the separator after it
+func (p *dnScanner) attribute() (pkix.AttributeTypeAndValue, byte, error) {
+	start := p.pos
+	for !p.eof() && p.s[p.pos] != '=' && p.s[p.pos] != p.sep && p.s[p.pos] != '+' {
+		p.pos++
+	}
+	name := strings.TrimSpace(p.s[start:p.pos])
+	if name == "" {
+		return pkix.AttributeTypeAndValue{}, 0, fmt.Errorf("missing attribute type at offset %d", start)
+	}
+	if p.eof() || p.s[p.pos] != '=' {
+		return pkix.AttributeTypeAndValue{}, 0, fmt.Errorf("missing = after %q", name)
+	}
+	p.pos++
+	oid, err := attributeType(name)
+	if err != nil {
+		return pkix.AttributeTypeAndValue{}, 0, err
+	}
+	p.skipSpaces()
+	var value any
+	if !p.eof() && p.s[p.pos] == '#' {
+		value, err = p.hexValue()
+	} else {
+		value, err = p.stringValue(name, oid)
+	}
+	if err != nil {
+		return pkix.AttributeTypeAndValue{}, 0, err
+	}
+	end, err := p.end()
+	if err != nil {
+		return pkix.AttributeTypeAndValue{}, 0, err
+	}
+	return pkix.AttributeTypeAndValue{Type: oid, Value: value}, end, nil
+}
+
+// attributeType returns the OID of an attribute type name or a dotted OID
+func attributeType(name string) (asn1.ObjectIdentifier, error) {
+	if oid, ok := subjectAttributes[strings.ToLower(name)]; ok {
+		return oid, nil
+	}
+	dotted := name
+	if len(dotted) > 4 && strings.EqualFold(dotted[:4], "oid.") {
+		dotted = dotted[4:]
+	}
+	if dotted[0] < '0' || dotted[0] > '9' {
+		return nil, fmt.Errorf("unknown attribute type %q, use a dotted OID for other attributes", name)
+	}
+	return parseOID(dotted)
+}
+
+// hexValue scans a # prefixed hex DER encoding, which is used as the value as is
+func (p *dnScanner) hexValue() (any, error) {
+	p.pos++
+	start := p.pos
+	for !p.eof() && p.s[p.pos] != ' ' && p.s[p.pos] != p.sep && p.s[p.pos] != '+' {
+		p.pos++
+	}
+	der, err := hex.DecodeString(p.s[start:p.pos])
+	if err != nil || len(der) == 0 {
+		return nil, fmt.Errorf("invalid hex value #%s", p.s[start:p.pos])
+	}
+	var raw asn1.RawValue
+	if rest, err := asn1.Unmarshal(der, &raw); err != nil || len(rest) > 0 {
+		return nil, fmt.Errorf("hex value #%s is not a single DER encoded value", p.s[start:p.pos])
+	}
+	return raw, nil
+}
+
+// stringValue scans a quoted or unquoted string value, emailAddress and DC values are encoded as IA5String
+func (p *dnScanner) stringValue(name string, oid asn1.ObjectIdentifier) (any, error) {
+	var buf []byte
+	if !p.eof() && p.s[p.pos] == '"' {
+		p.pos++
+		for {
+			if p.eof() {
+				return nil, fmt.Errorf("missing closing quote in the value of %s", name)
+			}
+			c := p.s[p.pos]
+			if c == '"' {
+				p.pos++
+				break
+			}
+			if c == '\\' {
+				b, err := p.escaped()
+				if err != nil {
+					return nil, err
+				}
+				buf = append(buf, b)
+				continue
+			}
+			buf = append(buf, c)
+			p.pos++
+		}
+	} else {
+		// the trailing spaces are not part of the value unless they are escaped
+		kept := 0
+		for !p.eof() && p.s[p.pos] != p.sep && p.s[p.pos] != '+' {
+			c := p.s[p.pos]
+			if c == '\\' {
+				b, err := p.escaped()
+				if err != nil {
+					return nil, err
+				}
+				buf = append(buf, b)
+				kept = len(buf)
+				continue
+			}
+			buf = append(buf, c)
+			if c != ' ' {
+				kept = len(buf)
+			}
+			p.pos++
+		}
+		buf = buf[:kept]
+	}
+	if len(buf) == 0 {
+		return nil, fmt.Errorf("empty value of %s", name)
+	}
+	if !utf8.Valid(buf) {
+		return nil, fmt.Errorf("the value of %s is not valid UTF-8", name)
+	}
+	value := string(buf)
+	if oid.Equal(oidEmailAddress) || oid.Equal(oidDomainComponent) {
+		for _, r := range value {
+			if r > 0x7f {
+				return nil, fmt.Errorf("the value of %s must be ASCII: %q", name, value)
+			}
+		}
+		return asn1.RawValue{Tag: asn1.TagIA5String, Bytes: buf}, nil
+	}
+	return value, nil
+}
+
+// escaped scans an escaped special character or hex pair after \
+func (p *dnScanner) escaped() (byte, error) {
+	p.pos++
+	if p.eof() {
+		return 0, errors.New("incomplete escape at the end")
+	}
+	c := p.s[p.pos]
+	if strings.IndexByte(` "#+,;<=>\/`, c) >= 0 {
+		p.pos++
+		return c, nil
+	}
+	if p.pos+2 <= len(p.s) {
+		if b, err := hex.DecodeString(p.s[p.pos : p.pos+2]); err == nil {
+			p.pos += 2
+			return b[0], nil
+		}
+	}
+	return 0, fmt.Errorf("invalid escape \\%c at offset %d", c, p.pos-1)
+}
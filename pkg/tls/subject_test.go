@@ -0,0 +1,77 @@
+package tls
+
+import (
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSubject(t *testing.T) {
+	oid := func(arcs ...int) asn1.ObjectIdentifier { return arcs }
+	cn, ou, o, c := oid(2, 5, 4, 3), oid(2, 5, 4, 11), oid(2, 5, 4, 10), oid(2, 5, 4, 6)
+	atv := func(t asn1.ObjectIdentifier, v any) pkix.AttributeTypeAndValue {
+		return pkix.AttributeTypeAndValue{Type: t, Value: v}
+	}
+	ia5 := func(v string) asn1.RawValue { return asn1.RawValue{Tag: asn1.TagIA5String, Bytes: []byte(v)} }
+	tests := []struct {
+		subject string
+		want    pkix.RDNSequence
+	}{
+		{"CN=server, OU=openqe, O=Example\\, Inc., C=US", pkix.RDNSequence{
+			{atv(c, "US")}, {atv(o, "Example, Inc.")}, {atv(ou, "openqe")}, {atv(cn, "server")}}},
+		{"/C=US/O=Example, Inc./OU=openqe/CN=server", pkix.RDNSequence{
+			{atv(c, "US")}, {atv(o, "Example, Inc.")}, {atv(ou, "openqe")}, {atv(cn, "server")}}},
+		{"cn=a + UID=u1,DC=example,DC=com", pkix.RDNSequence{
+			{atv(oidDomainComponent, ia5("com"))}, {atv(oidDomainComponent, ia5("example"))},
+			{atv(cn, "a"), atv(oid(0, 9, 2342, 19200300, 100, 1, 1), "u1")}}},
+		{`emailAddress=qe@example.com,serialNumber=42,street="1 Main St, Suite 2",postalCode=100000,1.2.3.4=raw`, pkix.RDNSequence{
+			{atv(oid(1, 2, 3, 4), "raw")}, {atv(oid(2, 5, 4, 17), "100000")}, {atv(oid(2, 5, 4, 9), "1 Main St, Suite 2")},
+			{atv(oid(2, 5, 4, 5), "42")}, {atv(oidEmailAddress, ia5("qe@example.com"))}}},
+		{`CN=\20lead\C3\A9\+\ ,OU=#0c036f7165`, pkix.RDNSequence{
+			{atv(ou, asn1.RawValue{Tag: asn1.TagUTF8String, Bytes: []byte("oqe"), FullBytes: []byte{0x0c, 3, 'o', 'q', 'e'}})},
+			{atv(cn, " leadé+ ")}}},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		rdns, err := ParseRDNSequence(tt.subject)
+		require.NoError(t, err, tt.subject)
+		assert.Equal(t, tt.want, rdns, tt.subject)
+	}
+
+	for _, subject := range []string{"CN", "CN=a,,O=b", "CN=a,", "XX=a", "CN=", "CN=a\\", "CN=a\\zz", "1.2.x=a", "3.1=a", "CN=#zz",
+		"emailAddress=é@example.com", `CN="a`, `CN="a"b`} {
+		_, err := ParseSubject(subject)
+		assert.Error(t, err, subject)
+	}
+
+	name, err := ParseSubject("CN=server+UID=u1,OU=b,OU=a,DC=example,1.2.3.4=raw")
+	require.NoError(t, err)
+	assert.Equal(t, "server", name.CommonName)
+	assert.Equal(t, []string{"a", "b"}, name.OrganizationalUnit)
+	assert.Len(t, name.ExtraNames, 3)
+
+	// the certificate keeps the order and the multi-valued RDN of the subject
+	opts, _ := newTestCA(t, func(o *CAOptions) { o.Subject = "CN=server+UID=u1,OU=b,OU=a,DC=example,1.2.3.4=raw" })
+	cert := loadCertificate(t, opts.CaCertFile)
+	rdns, err := ParseRDNSequence(opts.Subject)
+	require.NoError(t, err)
+	raw, err := asn1.Marshal(rdns)
+	require.NoError(t, err)
+	assert.Equal(t, raw, cert.RawSubject)
+	assert.Equal(t, "server", cert.Subject.CommonName)
+	assert.Equal(t, []string{"a", "b"}, cert.Subject.OrganizationalUnit)
+
+	opts.Subject = "CN=server,OU"
+	assert.Error(t, GenerateCAToFiles(opts))
+}
+
+func TestIsCountryFirstSubject(t *testing.T) {
+	assert.True(t, IsCountryFirstSubject("C=US, O=Example, CN=server"))
+	assert.False(t, IsCountryFirstSubject("CN=server, O=Example, C=US"))
+	assert.False(t, IsCountryFirstSubject("/C=US/O=Example/CN=server"))
+	assert.False(t, IsCountryFirstSubject("CN=server"))
+	assert.False(t, IsCountryFirstSubject("C=US, CN"))
+}
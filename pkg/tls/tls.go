@@ -1,13 +1,15 @@
 package tls
 
 import (
-	"crypto/rsa"
+	"crypto"
 	"crypto/x509"
 	"crypto/x509/pkix"
+	"encoding/asn1"
 	"encoding/pem"
 	"errors"
 	"fmt"
 	"net"
+	"net/url"
 	"os"
 	"strings"
 	"time"
@@ -16,6 +18,9 @@ import (
 	corev1 "k8s.io/api/core/v1"
 )
 
+// caKeyUsages are the key usages of the generated CAs, certificate signing is required to verify the certificates they issue
+const caKeyUsages = x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature
+
 func defaultCertCfg() CertCfg {
 	def := &CertCfg{
 		KeySize:      DefaultKeySize,
@@ -41,18 +46,22 @@ func defaultCertCfg() CertCfg {
 }
 
 /* Generates CA private key and certificate */
-func GenerateCA() (*rsa.PrivateKey, *x509.Certificate, error) {
+func GenerateCA() (crypto.Signer, *x509.Certificate, error) {
 	cfg := defaultCertCfg()
 	cfg.IsCA = true
+	cfg.KeyUsages = caKeyUsages
 	return GenerateSelfSignedCertificate(&cfg)
 }
 
 /* Generates CA private key and certificate with subject and dnsName specified */
-func GenerateCAWith(subject, dnsName string) (*rsa.PrivateKey, *x509.Certificate, error) {
+func GenerateCAWith(subject, dnsName string) (crypto.Signer, *x509.Certificate, error) {
 	cfg := defaultCertCfg()
 	cfg.IsCA = true
+	cfg.KeyUsages = caKeyUsages
 	if subject != "" {
-		cfg.Subject = ParseSubject(subject)
+		if err := cfg.setSubject(subject); err != nil {
+			return nil, nil, err
+		}
 	}
 	if dnsName != "" {
 		cfg.DNSNames = []string{dnsName}
@@ -61,50 +70,167 @@ func GenerateCAWith(subject, dnsName string) (*rsa.PrivateKey, *x509.Certificate
 	return GenerateSelfSignedCertificate(&cfg)
 }
 
-// Parse the openssl fashion subject into pkix.Name used in go
-func ParseSubject(subject string) pkix.Name {
-	var rdns []string
-	if strings.HasPrefix(subject, "/") {
-		// OpenSSL oneline format: /O=xx/OU=yy/CN=zz/C=cc
-		subject = strings.TrimPrefix(subject, "/")
-		rdns = strings.Split(subject, "/")
-	} else {
-		// RFC2253 format: CN=zz,O=xx,OU=yy,C=cc
-		rdns = strings.Split(subject, ",")
-	}
-	name := pkix.Name{}
-	for _, rdn := range rdns {
-		parts := strings.SplitN(strings.TrimSpace(rdn), "=", 2)
-		if len(parts) != 2 {
+/* Generates CA private key and certificate with the options specified */
+func GenerateCAWithOptions(opts *CAOptions) (crypto.Signer, *x509.Certificate, error) {
+	key, chain, err := GenerateCAChain(opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	return key, chain[0], nil
+}
+
+// certCfg builds the CertCfg of the CA certificate described by the options
+func (o *CAOptions) certCfg() (*CertCfg, error) {
+	cfg := defaultCertCfg()
+	cfg.IsCA = true
+	if o.Subject != "" {
+		if err := cfg.setSubject(o.Subject); err != nil {
+			return nil, err
+		}
+	}
+	if err := o.SANOptions.apply(&cfg); err != nil {
+		return nil, err
+	}
+	if err := setKeyAlgorithm(&cfg, o.KeyAlgorithm, o.KeySize); err != nil {
+		return nil, err
+	}
+	cfg.KeyUsages = caKeyUsages
+	if o.Validity > 0 {
+		cfg.Validity = o.Validity
+	}
+	if o.PathLen >= 0 {
+		cfg.MaxPathLen = &o.PathLen
+	}
+	return &cfg, nil
+}
+
+// certCfg builds the CertCfg of the TLS certificate described by the options
+func (o *PKIOptions) certCfg() (*CertCfg, error) {
+	cfg, err := leafCertCfg(o.Subject, &o.SANOptions, o.KeyAlgorithm, o.KeySize)
+	if err != nil {
+		return nil, err
+	}
+	cfg.CRLDistributionPoints = o.CRLDistributionPoints
+	cfg.OCSPServers = o.OCSPServers
+	cfg.MustStaple = o.MustStaple
+	if o.Validity > 0 {
+		cfg.Validity = o.Validity
+	}
+	if err := applyProfileOptions(cfg, o.Profile, o.ExtKeyUsages); err != nil {
+		return nil, err
+	}
+	if o.NotBefore != "" {
+		if cfg.NotBefore, err = ParseTime(o.NotBefore); err != nil {
+			return nil, err
+		}
+	}
+	if o.NotAfter != "" {
+		if cfg.NotAfter, err = ParseTime(o.NotAfter); err != nil {
+			return nil, err
+		}
+	}
+	return cfg, nil
+}
+
+// certCfg builds the CertCfg of the certificate request described by the options
+func (o *CSROptions) certCfg() (*CertCfg, error) {
+	return leafCertCfg(o.Subject, &o.SANOptions, o.KeyAlgorithm, o.KeySize)
+}
+
+// leafCertCfg builds the CertCfg of a non CA certificate from its command line representation
+func leafCertCfg(subject string, sans *SANOptions, keyAlgorithm string, keySize int) (*CertCfg, error) {
+	cfg := defaultCertCfg()
+	cfg.IsCA = false
+	if subject != "" {
+		if err := cfg.setSubject(subject); err != nil {
+			return nil, err
+		}
+	}
+	if err := sans.apply(&cfg); err != nil {
+		return nil, err
+	}
+	if err := setKeyAlgorithm(&cfg, keyAlgorithm, keySize); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// apply parses the subject alternative names and sets them to the cfg, empty values are ignored
+func (o *SANOptions) apply(cfg *CertCfg) error {
+	cfg.DNSNames = nil
+	for _, dnsName := range o.DNSNames {
+		if dnsName = strings.TrimSpace(dnsName); dnsName != "" {
+			cfg.DNSNames = append(cfg.DNSNames, dnsName)
+		}
+	}
+	cfg.IPAddresses = nil
+	for _, ipAddress := range o.IPAddresses {
+		if ipAddress = strings.TrimSpace(ipAddress); ipAddress == "" {
 			continue
 		}
-		k, v := strings.ToUpper(parts[0]), parts[1]
-		switch k {
-		case "C":
-			name.Country = append(name.Country, v)
-		case "O":
-			name.Organization = append(name.Organization, v)
-		case "OU":
-			name.OrganizationalUnit = append(name.OrganizationalUnit, v)
-		case "CN":
-			name.CommonName = v
-		case "L":
-			name.Locality = append(name.Locality, v)
-		case "ST":
-			name.Province = append(name.Province, v)
-		default:
-			// If you want to support arbitrary attributes, you can store them in ExtraNames
-			name.ExtraNames = append(name.ExtraNames,
-				pkix.AttributeTypeAndValue{Type: []int{2, 5, 4, 9999}, Value: v})
+		ip := net.ParseIP(ipAddress)
+		if ip == nil {
+			return fmt.Errorf("invalid IP address: %s", ipAddress)
 		}
+		cfg.IPAddresses = append(cfg.IPAddresses, ip)
+	}
+	cfg.URIs = nil
+	for _, uri := range o.URIs {
+		if uri = strings.TrimSpace(uri); uri == "" {
+			continue
+		}
+		u, err := url.Parse(uri)
+		if err != nil {
+			return fmt.Errorf("invalid URI SAN %s: %w", uri, err)
+		}
+		if u.Scheme == "" {
+			return fmt.Errorf("invalid URI SAN %s: a scheme is required", uri)
+		}
+		cfg.URIs = append(cfg.URIs, u)
+	}
+	cfg.EmailAddresses = nil
+	for _, email := range o.EmailAddresses {
+		if email = strings.TrimSpace(email); email == "" {
+			continue
+		}
+		if !strings.Contains(email, "@") {
+			return fmt.Errorf("invalid email SAN: %s", email)
+		}
+		cfg.EmailAddresses = append(cfg.EmailAddresses, email)
+	}
+	return nil
+}
+
+// setKeyAlgorithm sets the key algorithm and key size of the cfg from their command line representation
+func setKeyAlgorithm(cfg *CertCfg, keyAlgorithm string, keySize int) error {
+	alg, err := ParseKeyAlgorithm(keyAlgorithm)
+	if err != nil {
+		return err
 	}
-	return name
+	cfg.KeyAlgorithm = alg
+	cfg.KeySize = keySize
+	return nil
+}
+
+// setSubject parses the subject and sets it to the cfg, the DER encoding is kept as the RawSubject to keep
+// the order and the multi-valued RDNs of the subject
+func (cfg *CertCfg) setSubject(subject string) error {
+	rdns, err := ParseRDNSequence(subject)
+	if err != nil {
+		return err
+	}
+	raw, err := asn1.Marshal(rdns)
+	if err != nil {
+		return fmt.Errorf("failed to encode subject %q: %w", subject, err)
+	}
+	cfg.Subject = nameFromRDNSequence(rdns)
+	cfg.RawSubject = raw
+	return nil
 }
 
 /** Generates a CA key/cert pair and save them into different files **/
+// When the CA is an intermediate CA, the certificate file contains the intermediate chain after the CA certificate.
 func GenerateCAToFiles(opts *CAOptions) error {
-	subject := opts.Subject
-	dnsName := opts.DNSName
 	caKeyFile := opts.CaKeyFile
 	caCertFile := opts.CaCertFile
 	if caKeyFile == "" {
@@ -113,11 +239,14 @@ func GenerateCAToFiles(opts *CAOptions) error {
 	if caCertFile == "" {
 		return errors.New("caCertFile needs to be specified to save for the TLS CA certificate")
 	}
-	key, cert, err := GenerateCAWith(subject, dnsName)
+	key, chain, err := GenerateCAChain(opts)
+	if err != nil {
+		return err
+	}
+	keyInPem, err := encodePrivateKey(key, opts.KeyPassphraseFile)
 	if err != nil {
 		return err
 	}
-	keyInPem := PrivateKeyToPem(key)
 	kf, err := os.Create(caKeyFile)
 	if err != nil {
 		return err
@@ -127,7 +256,7 @@ func GenerateCAToFiles(opts *CAOptions) error {
 	if err != nil {
 		return err
 	}
-	certInPem := CertToPem(cert)
+	certInPem := CertsToPem(chain)
 	certf, err := os.Create(caCertFile)
 	if err != nil {
 		return err
@@ -140,11 +269,45 @@ func GenerateCAToFiles(opts *CAOptions) error {
 	return nil
 }
 
+// GenerateCAChain generates a CA key and the CA chain starting with the CA certificate.
+// The CA is self-signed unless a parent CA is specified in the options, then the chain contains
+// the intermediate CA certificate followed by the intermediates of the parent CA, without the root.
+func GenerateCAChain(opts *CAOptions) (crypto.Signer, []*x509.Certificate, error) {
+	cfg, err := opts.certCfg()
+	if err != nil {
+		return nil, nil, err
+	}
+	if opts.ParentCaKeyFile == "" && opts.ParentCaCertFile == "" {
+		key, cert, err := GenerateSelfSignedCertificate(cfg)
+		if err != nil {
+			return nil, nil, err
+		}
+		return key, []*x509.Certificate{cert}, nil
+	}
+	parentPassphrase, err := readPassphrase(opts.ParentKeyPassphraseFile)
+	if err != nil {
+		return nil, nil, err
+	}
+	parentKey, parentChain, err := loadCAFromFiles(opts.ParentCaKeyFile, opts.ParentCaCertFile, parentPassphrase)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load the parent CA: %w", err)
+	}
+	key, cert, err := GenerateSignedCertificate(parentKey, parentChain[0], cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	if opts.ParentCaDBFile != "" {
+		if err := RecordIssued(opts.ParentCaDBFile, cert); err != nil {
+			return nil, nil, err
+		}
+	}
+	return key, append([]*x509.Certificate{cert}, intermediates(parentChain)...), nil
+}
+
+// GenerateTLSKeyCertPairToFiles generates a TLS key/cert pair signed by a CA and saves them into different files.
+// The certificate file contains the leaf certificate followed by the intermediate CAs,
+// unless a chain file is specified, then the certificate file contains only the leaf certificate.
 func GenerateTLSKeyCertPairToFiles(opts *PKIOptions) error {
-	subject := opts.Subject
-	dnsName := opts.DNSName
-	caKeyFile := opts.CaGenOpt.CaKeyFile
-	caCertFile := opts.CaGenOpt.CaCertFile
 	tlsKeyFile := opts.KeyFile
 	tlsCertFile := opts.CertFile
 	if tlsKeyFile == "" {
@@ -153,11 +316,14 @@ func GenerateTLSKeyCertPairToFiles(opts *PKIOptions) error {
 	if tlsCertFile == "" {
 		return errors.New("tlsCertFile needs to be specified to save for the TLS certificate")
 	}
-	key, cert, err := GenerateTLSKeyCertPair(subject, dnsName, caKeyFile, caCertFile)
+	key, chain, err := GenerateTLSKeyCertChain(opts)
 	if err != nil {
 		return fmt.Errorf("Failed to generate TLS certificate: %w", err)
 	}
-	keyInPem := PrivateKeyToPem(key)
+	keyInPem, err := encodePrivateKey(key, opts.KeyPassphraseFile)
+	if err != nil {
+		return err
+	}
 	kf, err := os.Create(tlsKeyFile)
 	if err != nil {
 		return err
@@ -167,7 +333,16 @@ func GenerateTLSKeyCertPairToFiles(opts *PKIOptions) error {
 	if err != nil {
 		return err
 	}
-	certInPem := CertToPem(cert)
+	certInPem := CertsToPem(chain)
+	if opts.ChainFile != "" {
+		if err := os.WriteFile(opts.ChainFile, certInPem, 0644); err != nil {
+			return err
+		}
+		certInPem = CertToPem(chain[0])
+	}
+	if Defect(opts.Defect) == DefectTruncatedPEM {
+		certInPem = truncatePEM(certInPem)
+	}
 	certf, err := os.Create(tlsCertFile)
 	if err != nil {
 		return err
@@ -180,7 +355,75 @@ func GenerateTLSKeyCertPairToFiles(opts *PKIOptions) error {
 	return nil
 }
 
-func GenerateTLSKeyCertPair(subject, dnsName, caKeyFile, caCertFile string) (*rsa.PrivateKey, *x509.Certificate, error) {
+// GenerateTLSKeyCertPair generates a TLS key/cert pair signed by the CA key/cert files in the options.
+// The CA key can be any of the supported key algorithms, regardless of the algorithm of the generated key.
+func GenerateTLSKeyCertPair(opts *PKIOptions) (crypto.Signer, *x509.Certificate, error) {
+	key, chain, err := GenerateTLSKeyCertChain(opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	return key, chain[0], nil
+}
+
+// GenerateTLSKeyCertChain generates a TLS key and the certificate chain starting with the leaf certificate,
+// followed by the intermediate CAs found in the CA certificate file. The leaf certificate is recorded in the CA database
+// when the CaDBFile of the CA options is set.
+// The defect of the options, if any, is put in the generated key or certificate.
+func GenerateTLSKeyCertChain(opts *PKIOptions) (crypto.Signer, []*x509.Certificate, error) {
+	cfg, err := opts.certCfg()
+	if err != nil {
+		return nil, nil, err
+	}
+	defect, err := ParseDefect(opts.Defect)
+	if err != nil {
+		return nil, nil, err
+	}
+	if defect == DefectSelfSigned {
+		key, cert, err := GenerateSelfSignedCertificate(cfg)
+		if err != nil {
+			return nil, nil, err
+		}
+		return key, []*x509.Certificate{cert}, nil
+	}
+	caKey, caChain, err := opts.CaGenOpt.LoadCA()
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := applyDefect(cfg, defect, caKey); err != nil {
+		return nil, nil, err
+	}
+	key, cert, err := GenerateSignedCertificate(caKey, caChain[0], cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := opts.CaGenOpt.RecordIssued(cert); err != nil {
+		return nil, nil, err
+	}
+	if defect == DefectMismatchedKey {
+		if key, err = GeneratePrivateKey(cfg.KeyAlgorithm, cfg.KeySize); err != nil {
+			return nil, nil, err
+		}
+	}
+	return key, append([]*x509.Certificate{cert}, intermediates(caChain)...), nil
+}
+
+// LoadCA loads the CA private key and the CA certificate chain of the options,
+// the private key is decrypted with the passphrase in KeyPassphraseFile when it is set.
+func (o *CAOptions) LoadCA() (crypto.Signer, []*x509.Certificate, error) {
+	passphrase, err := readPassphrase(o.KeyPassphraseFile)
+	if err != nil {
+		return nil, nil, err
+	}
+	return loadCAFromFiles(o.CaKeyFile, o.CaCertFile, passphrase)
+}
+
+// LoadCAFromFiles loads a CA private key and the CA certificate chain from files.
+// The first certificate in caCertFile is the CA certificate, the following ones are its chain.
+func LoadCAFromFiles(caKeyFile, caCertFile string) (crypto.Signer, []*x509.Certificate, error) {
+	return loadCAFromFiles(caKeyFile, caCertFile, nil)
+}
+
+func loadCAFromFiles(caKeyFile, caCertFile string, passphrase []byte) (crypto.Signer, []*x509.Certificate, error) {
 	if caKeyFile == "" || !utils.FileExists(caKeyFile) {
 		return nil, nil, errors.New("A valid caKeyFile needs to be specified to read the TLS CA private key")
 	}
@@ -191,7 +434,7 @@ func GenerateTLSKeyCertPair(subject, dnsName, caKeyFile, caCertFile string) (*rs
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to read CA private key file: %w", err)
 	}
-	caKey, err := PemToPrivateKey(caKeyBytes)
+	caKey, err := ParsePrivateKey(caKeyBytes, passphrase)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to load CA private key from file: %w", err)
 	}
@@ -199,19 +442,22 @@ func GenerateTLSKeyCertPair(subject, dnsName, caKeyFile, caCertFile string) (*rs
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to read CA certificate file: %w", err)
 	}
-	caCert, err := PemToCertificate(caCertBytes)
+	caChain, err := PemToCertificates(caCertBytes)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to load CA certificate from file: %w", err)
 	}
-	cfg := defaultCertCfg()
-	cfg.IsCA = false
-	if subject != "" {
-		cfg.Subject = ParseSubject(subject)
-	}
-	if dnsName != "" {
-		cfg.DNSNames = []string{dnsName}
+	return caKey, caChain, nil
+}
+
+// intermediates returns the certificates of the chain which are not self-signed roots
+func intermediates(chain []*x509.Certificate) []*x509.Certificate {
+	var certs []*x509.Certificate
+	for _, cert := range chain {
+		if !IsSelfSigned(cert) {
+			certs = append(certs, cert)
+		}
 	}
-	return GenerateSignedCertificate(caKey, caCert, &cfg)
+	return certs
 }
 
 // CertInCAFile checks if a certificate represented by certs in PEM format is already inside the ca-bundle ConfigMap.
@@ -245,28 +491,35 @@ func CheckCert(cert string, certs string) (bool, error) {
 	}
 
 	// Walk through all certs
-	rest := []byte(certs)
+	found := false
+	walkCertificates([]byte(certs), func(cert *x509.Certificate, err error) bool {
+		if err != nil {
+			return true
+		}
+		// Compare Raw DER (byte-for-byte identity)
+		found = cert.Equal(wantCert)
+		return !found
+	})
+	return found, nil
+}
+
+// walkCertificates calls fn with each CERTIFICATE block of data in order, or with the error of parsing it.
+// The walk stops when fn returns false.
+func walkCertificates(data []byte, fn func(cert *x509.Certificate, err error) bool) {
+	rest := data
 	for {
 		var b *pem.Block
 		b, rest = pem.Decode(rest)
 		if b == nil {
-			break
+			return
 		}
 		if b.Type != "CERTIFICATE" {
 			continue
 		}
-
-		cert, err := x509.ParseCertificate(b.Bytes)
-		if err != nil {
-			continue
-		}
-
-		// Compare Raw DER (byte-for-byte identity)
-		if cert.Equal(wantCert) {
-			return true, nil
+		if !fn(x509.ParseCertificate(b.Bytes)) {
+			return
 		}
 	}
-	return false, nil
 }
 
 // CheckCACertInBundle checks if a CA certificate file is included in a CA bundle file
@@ -2,6 +2,10 @@ package tls
 
 import (
 	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
 	"crypto/md5"
 	"crypto/rand"
 	cryptorand "crypto/rand"
@@ -9,6 +13,7 @@ import (
 	"crypto/sha512"
 	"crypto/x509"
 	"crypto/x509/pkix"
+	"encoding/asn1"
 	"encoding/base64"
 	"encoding/pem"
 	"fmt"
@@ -17,6 +22,8 @@ import (
 	"math/big"
 	mathrand "math/rand"
 	"net"
+	"net/url"
+	"strings"
 	"sync"
 	"time"
 
@@ -51,21 +58,70 @@ const (
 	UserCABundleMapKey = "ca-bundle.crt"
 )
 
+// KeyAlgorithm is the public key algorithm of a generated private key
+type KeyAlgorithm string
+
+const (
+	KeyAlgorithmRSA     KeyAlgorithm = "rsa"
+	KeyAlgorithmECDSA   KeyAlgorithm = "ecdsa"
+	KeyAlgorithmEd25519 KeyAlgorithm = "ed25519"
+
+	// DefaultECDSAKeySize is the curve size used when no key size is given for an ECDSA key
+	DefaultECDSAKeySize = 256
+)
+
+// ParseKeyAlgorithm converts the name of a key algorithm to a KeyAlgorithm, an empty name means RSA
+func ParseKeyAlgorithm(name string) (KeyAlgorithm, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", "rsa":
+		return KeyAlgorithmRSA, nil
+	case "ecdsa", "ec":
+		return KeyAlgorithmECDSA, nil
+	case "ed25519":
+		return KeyAlgorithmEd25519, nil
+	}
+	return "", errors.Errorf("unsupported key algorithm: %s, supported: rsa, ecdsa, ed25519", name)
+}
+
 // CertCfg contains all needed fields to configure a new certificate
 type CertCfg struct {
-	KeySize      int
-	DNSNames     []string
-	ExtKeyUsages []x509.ExtKeyUsage
-	IPAddresses  []net.IP
-	KeyUsages    x509.KeyUsage
-	Subject      pkix.Name
-	Validity     time.Duration
-	IsCA         bool
+	KeySize        int
+	DNSNames       []string
+	ExtKeyUsages   []x509.ExtKeyUsage
+	IPAddresses    []net.IP
+	URIs           []*url.URL
+	EmailAddresses []string
+	KeyUsages      x509.KeyUsage
+	Subject        pkix.Name
+	Validity       time.Duration
+	IsCA           bool
+
+	// RawSubject is the DER encoded subject, it takes precedence over Subject when set
+	RawSubject []byte
+
+	// KeyAlgorithm defaults to RSA when empty, KeySize is the RSA modulus size
+	// or the ECDSA curve size (256, 384, 521) and is ignored for Ed25519
+	KeyAlgorithm KeyAlgorithm
+	// MaxPathLen limits the number of intermediate CAs below a CA, nil means unlimited
+	MaxPathLen *int
+	// CRLDistributionPoints are the URLs of the CRLs of the issuer
+	CRLDistributionPoints []string
+	// OCSPServers are the URLs of the OCSP responders of the issuer, set in the authority information access extension
+	OCSPServers []string
+	// MustStaple adds the TLS feature extension requiring a stapled OCSP response (RFC 7633)
+	MustStaple bool
+	// NotBefore and NotAfter override the validity window starting from now when they are set
+	NotBefore time.Time
+	NotAfter  time.Time
+	// SignatureAlgorithm defaults to the algorithm chosen by x509.CreateCertificate for the signing key
+	SignatureAlgorithm x509.SignatureAlgorithm
+	// UnknownExtKeyUsages are the extended key usages by OID which crypto/x509 has no constants for
+	UnknownExtKeyUsages []asn1.ObjectIdentifier
 }
 
 // GenerateSelfSignedCertificate generates a key/cert pair defined by CertCfg.
-func GenerateSelfSignedCertificate(cfg *CertCfg) (*rsa.PrivateKey, *x509.Certificate, error) {
-	key, err := PrivateKey(cfg.KeySize)
+func GenerateSelfSignedCertificate(cfg *CertCfg) (crypto.Signer, *x509.Certificate, error) {
+	key, err := GeneratePrivateKey(cfg.KeyAlgorithm, cfg.KeySize)
 	if err != nil {
 		return nil, nil, errors.Wrap(err, "failed to generate private key")
 	}
@@ -78,28 +134,17 @@ func GenerateSelfSignedCertificate(cfg *CertCfg) (*rsa.PrivateKey, *x509.Certifi
 }
 
 // GenerateSignedCertificate generate a key and cert defined by CertCfg and signed by CA.
-func GenerateSignedCertificate(caKey *rsa.PrivateKey, caCert *x509.Certificate,
-	cfg *CertCfg) (*rsa.PrivateKey, *x509.Certificate, error) {
-
-	// create a private key
-	key, err := PrivateKey(DefaultKeySize)
-	if err != nil {
-		return nil, nil, errors.Wrap(err, "failed to generate private key")
-	}
+func GenerateSignedCertificate(caKey crypto.Signer, caCert *x509.Certificate,
+	cfg *CertCfg) (crypto.Signer, *x509.Certificate, error) {
 
-	// create a CSR
-	csrTmpl := x509.CertificateRequest{Subject: cfg.Subject, DNSNames: cfg.DNSNames, IPAddresses: cfg.IPAddresses}
-	csrBytes, err := x509.CreateCertificateRequest(Reader(), &csrTmpl, key)
+	// create a private key and a CSR
+	key, csr, err := GenerateCSR(cfg)
 	if err != nil {
-		return nil, nil, errors.Wrap(err, "failed to create certificate request")
-	}
-	csr, err := x509.ParseCertificateRequest(csrBytes)
-	if err != nil {
-		return nil, nil, errors.Wrap(err, "error parsing x509 certificate request")
+		return nil, nil, err
 	}
 
 	// create a cert
-	cert, err := signedCertificate(cfg, csr, key, caCert, caKey)
+	cert, err := signedCertificate(cfg, csr, caCert, caKey)
 	if err != nil {
 		return nil, nil, errors.Wrap(err, "failed to create a signed certificate")
 	}
@@ -119,31 +164,76 @@ func PrivateKey(size int) (*rsa.PrivateKey, error) {
 	return rsaKey, nil
 }
 
+// GeneratePrivateKey generates a private key of the given algorithm and size.
+// A size of 0 means the default size of the algorithm.
+func GeneratePrivateKey(alg KeyAlgorithm, size int) (crypto.Signer, error) {
+	switch alg {
+	case "", KeyAlgorithmRSA:
+		return PrivateKey(size)
+	case KeyAlgorithmECDSA:
+		var curve elliptic.Curve
+		switch size {
+		case 0, 256:
+			curve = elliptic.P256()
+		case 384:
+			curve = elliptic.P384()
+		case 521:
+			curve = elliptic.P521()
+		default:
+			return nil, errors.Errorf("unsupported ECDSA key size %d, supported: 256, 384, 521", size)
+		}
+		ecKey, err := ecdsa.GenerateKey(curve, Reader())
+		if err != nil {
+			return nil, errors.Wrap(err, "error generating ECDSA private key")
+		}
+		return ecKey, nil
+	case KeyAlgorithmEd25519:
+		_, edKey, err := ed25519.GenerateKey(Reader())
+		if err != nil {
+			return nil, errors.Wrap(err, "error generating Ed25519 private key")
+		}
+		return edKey, nil
+	}
+	return nil, errors.Errorf("unsupported key algorithm: %s", alg)
+}
+
 // SelfSignedCertificate creates a self-signed certificate
-func SelfSignedCertificate(cfg *CertCfg, key *rsa.PrivateKey) (*x509.Certificate, error) {
+func SelfSignedCertificate(cfg *CertCfg, key crypto.Signer) (*x509.Certificate, error) {
 	serial, err := rand.Int(Reader(), new(big.Int).SetInt64(math.MaxInt64))
 	if err != nil {
 		return nil, err
 	}
 
-	now := time.Now()
+	notBefore, notAfter := cfg.validity()
 	cert := x509.Certificate{
 		BasicConstraintsValid: true,
 		IsCA:                  cfg.IsCA,
 		KeyUsage:              cfg.KeyUsages,
-		NotAfter:              now.Add(cfg.Validity),
-		NotBefore:             now,
+		NotAfter:              notAfter,
+		NotBefore:             notBefore,
+		SignatureAlgorithm:    cfg.SignatureAlgorithm,
 		SerialNumber:          serial,
 		Subject:               cfg.Subject,
+		RawSubject:            cfg.RawSubject,
 		DNSNames:              cfg.DNSNames,
+		IPAddresses:           cfg.IPAddresses,
+		URIs:                  cfg.URIs,
+		EmailAddresses:        cfg.EmailAddresses,
 		ExtKeyUsage:           cfg.ExtKeyUsages,
+		CRLDistributionPoints: cfg.CRLDistributionPoints,
+		OCSPServer:            cfg.OCSPServers,
+		UnknownExtKeyUsage:    cfg.UnknownExtKeyUsages,
+	}
+	setMaxPathLen(&cert, cfg)
+	if err := setMustStaple(&cert, cfg); err != nil {
+		return nil, err
 	}
 	// verifies that the CN and/or OU for the cert is set
 	if len(cfg.Subject.CommonName) == 0 || len(cfg.Subject.OrganizationalUnit) == 0 {
 		return nil, errors.Errorf("certificate subject is not set, or invalid")
 	}
 
-	cert.SubjectKeyId, err = rsaPubKeySHA512Hash(&key.PublicKey)
+	cert.SubjectKeyId, err = pubKeySHA512Hash(key.Public())
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to set subject key identifier")
 	}
@@ -156,63 +246,132 @@ func SelfSignedCertificate(cfg *CertCfg, key *rsa.PrivateKey) (*x509.Certificate
 }
 
 // signedCertificate creates a new X.509 certificate based on a template.
+// The subject, the SANs and the public key come from the csr, all others come from the cfg.
 func signedCertificate(
 	cfg *CertCfg,
 	csr *x509.CertificateRequest,
-	key *rsa.PrivateKey,
 	caCert *x509.Certificate,
-	caKey *rsa.PrivateKey,
+	caKey crypto.Signer,
 ) (*x509.Certificate, error) {
 	serial, err := rand.Int(Reader(), new(big.Int).SetInt64(math.MaxInt64))
 	if err != nil {
 		return nil, err
 	}
 
-	now := time.Now()
+	notBefore, notAfter := cfg.validity()
 	certTmpl := x509.Certificate{
 		DNSNames:              csr.DNSNames,
 		ExtKeyUsage:           cfg.ExtKeyUsages,
 		IPAddresses:           csr.IPAddresses,
+		URIs:                  csr.URIs,
+		EmailAddresses:        csr.EmailAddresses,
 		KeyUsage:              cfg.KeyUsages,
-		NotAfter:              now.Add(cfg.Validity),
-		NotBefore:             now,
+		NotAfter:              notAfter,
+		NotBefore:             notBefore,
+		SignatureAlgorithm:    cfg.SignatureAlgorithm,
 		SerialNumber:          serial,
 		Subject:               csr.Subject,
+		RawSubject:            csr.RawSubject,
 		IsCA:                  cfg.IsCA,
 		Version:               3,
 		BasicConstraintsValid: true,
+		CRLDistributionPoints: cfg.CRLDistributionPoints,
+		OCSPServer:            cfg.OCSPServers,
+		UnknownExtKeyUsage:    cfg.UnknownExtKeyUsages,
+	}
+	setMaxPathLen(&certTmpl, cfg)
+	if err := setMustStaple(&certTmpl, cfg); err != nil {
+		return nil, err
 	}
 
-	certTmpl.SubjectKeyId, err = rsaPubKeySHA512Hash(&key.PublicKey)
+	certTmpl.SubjectKeyId, err = pubKeySHA512Hash(csr.PublicKey)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to set subject key identifier")
 	}
 
-	certBytes, err := x509.CreateCertificate(Reader(), &certTmpl, caCert, key.Public(), caKey)
+	certBytes, err := x509.CreateCertificate(Reader(), &certTmpl, caCert, csr.PublicKey, caKey)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to create x509 certificate")
 	}
 	return x509.ParseCertificate(certBytes)
 }
 
-func rsaPubKeySHA512Hash(pub *rsa.PublicKey) ([]byte, error) {
+// validity returns the validity window of the certificate, NotBefore and NotAfter take precedence over Validity
+func (cfg *CertCfg) validity() (time.Time, time.Time) {
+	notBefore, notAfter := cfg.NotBefore, cfg.NotAfter
+	if notBefore.IsZero() {
+		notBefore = time.Now()
+	}
+	if notAfter.IsZero() {
+		notAfter = notBefore.Add(cfg.Validity)
+	}
+	return notBefore, notAfter
+}
+
+// setMustStaple adds the OCSP must-staple extension to a certificate template when required by the cfg
+func setMustStaple(tmpl *x509.Certificate, cfg *CertCfg) error {
+	if !cfg.MustStaple {
+		return nil
+	}
+	value, err := mustStapleExtension()
+	if err != nil {
+		return errors.Wrap(err, "failed to create the must-staple extension")
+	}
+	tmpl.ExtraExtensions = append(tmpl.ExtraExtensions, pkix.Extension{Id: oidTLSFeature, Value: value})
+	return nil
+}
+
+// setMaxPathLen sets the path length constraint of a CA certificate template
+func setMaxPathLen(tmpl *x509.Certificate, cfg *CertCfg) {
+	if !cfg.IsCA || cfg.MaxPathLen == nil {
+		return
+	}
+	tmpl.MaxPathLen = *cfg.MaxPathLen
+	tmpl.MaxPathLenZero = *cfg.MaxPathLen == 0
+}
+
+// pubKeySHA512Hash hashes the RSA modulus, or the PKIX encoding for the other key types
+func pubKeySHA512Hash(pub crypto.PublicKey) ([]byte, error) {
+	var data []byte
+	if rsaPub, ok := pub.(*rsa.PublicKey); ok {
+		data = rsaPub.N.Bytes()
+	} else {
+		der, err := x509.MarshalPKIXPublicKey(pub)
+		if err != nil {
+			return nil, err
+		}
+		data = der
+	}
 	hash := sha512.New()
-	if _, err := hash.Write(pub.N.Bytes()); err != nil {
+	if _, err := hash.Write(data); err != nil {
 		return nil, err
 	}
 	return hash.Sum(nil), nil
 }
 
-// PrivateKeyToPem converts a rsa.PrivateKey object to pem string
-func PrivateKeyToPem(key *rsa.PrivateKey) []byte {
-	keyInBytes := x509.MarshalPKCS1PrivateKey(key)
-	keyinPem := pem.EncodeToMemory(
-		&pem.Block{
-			Type:  "RSA PRIVATE KEY",
-			Bytes: keyInBytes,
-		},
-	)
-	return keyinPem
+// PrivateKeyToPem converts a private key object to pem string.
+// RSA keys are written as PKCS#1, ECDSA keys as SEC1 and Ed25519 keys as PKCS#8.
+func PrivateKeyToPem(key crypto.Signer) ([]byte, error) {
+	var block *pem.Block
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		block = &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(k)}
+	case *ecdsa.PrivateKey:
+		keyInBytes, err := x509.MarshalECPrivateKey(k)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to MarshalECPrivateKey")
+		}
+		block = &pem.Block{Type: "EC PRIVATE KEY", Bytes: keyInBytes}
+	case ed25519.PrivateKey:
+		keyInBytes, err := x509.MarshalPKCS8PrivateKey(k)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to MarshalPKCS8PrivateKey")
+		}
+		block = &pem.Block{Type: "PRIVATE KEY", Bytes: keyInBytes}
+	default:
+		return nil, errors.Errorf("unsupported private key type %T", key)
+	}
+	return pem.EncodeToMemory(block), nil
 }
 
 // CertToPem converts an x509.Certificate object to a pem string
@@ -226,28 +385,63 @@ func CertToPem(cert *x509.Certificate) []byte {
 	return certInPem
 }
 
-// PublicKeyToPem converts a rsa.PublicKey object to pem string
-func PublicKeyToPem(key *rsa.PublicKey) ([]byte, error) {
+// PublicKeyToPem converts a public key object to pem string
+func PublicKeyToPem(key crypto.PublicKey) ([]byte, error) {
 	keyInBytes, err := x509.MarshalPKIXPublicKey(key)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to MarshalPKIXPublicKey")
 	}
 	keyinPem := pem.EncodeToMemory(
 		&pem.Block{
-			Type:  "RSA PUBLIC KEY",
+			Type:  "PUBLIC KEY",
 			Bytes: keyInBytes,
 		},
 	)
 	return keyinPem, nil
 }
 
-// PemToPrivateKey converts a data block to rsa.PrivateKey.
-func PemToPrivateKey(data []byte) (*rsa.PrivateKey, error) {
-	block, _ := pem.Decode(data)
-	if block == nil {
-		return nil, errors.Errorf("could not find a PEM block in the private key")
+// PemToPrivateKey converts a data block to a RSA, ECDSA or Ed25519 private key.
+// Any unencrypted format accepted by ParsePrivateKey is supported, in PEM or DER.
+func PemToPrivateKey(data []byte) (crypto.Signer, error) {
+	return ParsePrivateKey(data, nil)
+}
+
+// CertsToPem converts x509.Certificate objects to a pem string, in the same order
+func CertsToPem(certs []*x509.Certificate) []byte {
+	var certsInPem []byte
+	for _, cert := range certs {
+		certsInPem = append(certsInPem, CertToPem(cert)...)
+	}
+	return certsInPem
+}
+
+// IsSelfSigned checks if a certificate is issued and signed by itself
+func IsSelfSigned(cert *x509.Certificate) bool {
+	if !bytes.Equal(cert.RawIssuer, cert.RawSubject) {
+		return false
 	}
-	return x509.ParsePKCS1PrivateKey(block.Bytes)
+	return cert.CheckSignature(cert.SignatureAlgorithm, cert.RawTBSCertificate, cert.Signature) == nil
+}
+
+// PemToCertificates converts all CERTIFICATE blocks of data to x509.Certificate objects, in the same order.
+func PemToCertificates(data []byte) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	var parseErr error
+	walkCertificates(data, func(cert *x509.Certificate, err error) bool {
+		if err != nil {
+			parseErr = err
+			return false
+		}
+		certs = append(certs, cert)
+		return true
+	})
+	if parseErr != nil {
+		return nil, parseErr
+	}
+	if len(certs) == 0 {
+		return nil, errors.Errorf("could not find a CERTIFICATE PEM block")
+	}
+	return certs, nil
 }
 
 // PemToCertificate converts a data block to x509.Certificate.
@@ -263,8 +457,10 @@ func Base64(data []byte) string {
 	return base64.StdEncoding.EncodeToString(data)
 }
 
-func parsePemKeypair(key, certificate []byte) (*rsa.PrivateKey, *x509.Certificate, error) {
-	privKey, err := PemToPrivateKey(key)
+// parsePemKeypair parses the private key, decrypted with the passphrase when it is encrypted, and the certificate,
+// and checks that they match
+func parsePemKeypair(key, certificate, passphrase []byte) (crypto.Signer, *x509.Certificate, error) {
+	privKey, err := ParsePrivateKey(key, passphrase)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -272,13 +468,12 @@ func parsePemKeypair(key, certificate []byte) (*rsa.PrivateKey, *x509.Certificat
 	if err != nil {
 		return nil, nil, err
 	}
-	rsaPublicKey, ok := cert.PublicKey.(*rsa.PublicKey)
+	// all public key types in the standard library implement Equal
+	publicKey, ok := privKey.Public().(interface{ Equal(crypto.PublicKey) bool })
 	if !ok {
-		return nil, nil, fmt.Errorf("certificate does not have a RSA public key but a %T, not supported", cert.PublicKey)
+		return nil, nil, fmt.Errorf("private key has an unsupported public key %T", privKey.Public())
 	}
-
-	// https://cs.opensource.google/go/go/+/refs/tags/go1.17.5:src/crypto/tls/tls.go;drc=860704317e02d699e4e4a24103853c4782d746c1;l=310
-	if rsaPublicKey.N.Cmp(privKey.N) != 0 {
+	if !publicKey.Equal(cert.PublicKey) {
 		return nil, nil, errors.New("private key does not match certificate")
 	}
 
@@ -286,11 +481,16 @@ func parsePemKeypair(key, certificate []byte) (*rsa.PrivateKey, *x509.Certificat
 }
 
 func ValidateKeyPair(pemKey, pemCertificate []byte, cfg *CertCfg, minimumRemainingValidity time.Duration) error {
-	_, cert, err := parsePemKeypair(pemKey, pemCertificate)
+	_, cert, err := parsePemKeypair(pemKey, pemCertificate, nil)
 	if err != nil {
 		return fmt.Errorf("failed to parse keypair: %w", err)
 	}
+	return validateCertificate(cert, cfg, minimumRemainingValidity)
+}
 
+// validateCertificate checks that the certificate is the one described by the cfg, and that its remaining validity
+// is at least the minimum remaining validity
+func validateCertificate(cert *x509.Certificate, cfg *CertCfg, minimumRemainingValidity time.Duration) error {
 	var errs []error
 	stringLessFN := func(a, b string) bool { return a < b }
 
@@ -316,12 +516,23 @@ func ValidateKeyPair(pemKey, pemCertificate []byte, cfg *CertCfg, minimumRemaini
 		errs = append(errs, fmt.Errorf("actual ip addresses differ from expected: %s", ipAddressDiff))
 	}
 
+	uriDiff := cmp.Diff(uriStrings(cert.URIs), uriStrings(cfg.URIs), cmpopts.SortSlices(stringLessFN))
+	if uriDiff != "" {
+		errs = append(errs, fmt.Errorf("actual URIs differ from expected: %s", uriDiff))
+	}
+
+	emailDiff := cmp.Diff(cert.EmailAddresses, cfg.EmailAddresses, cmpopts.SortSlices(stringLessFN))
+	if emailDiff != "" {
+		errs = append(errs, fmt.Errorf("actual email addresses differ from expected: %s", emailDiff))
+	}
+
 	if cert.KeyUsage != cfg.KeyUsages {
 		errs = append(errs, fmt.Errorf("actual key usage %d differs from expected %d", cert.KeyUsage, cfg.KeyUsages))
 	}
 
-	// subjectDiff ignores the "Names" field, as it contains the parsed attributes but is ignored during marshaling.
-	subjectDiff := cmp.Diff(cert.Subject, cfg.Subject, cmpopts.SortSlices(stringLessFN), cmpopts.IgnoreFields(pkix.Name{}, "Names"))
+	// subjectDiff ignores the "Names" field, as it contains the parsed attributes but is ignored during marshaling,
+	// and the "ExtraNames" field, as it is only used for marshaling and is never set by parsing.
+	subjectDiff := cmp.Diff(cert.Subject, cfg.Subject, cmpopts.SortSlices(stringLessFN), cmpopts.IgnoreFields(pkix.Name{}, "Names", "ExtraNames"))
 	if subjectDiff != "" {
 		errs = append(errs, fmt.Errorf("actual subject differs from expected: %s", subjectDiff))
 	}
@@ -337,6 +548,14 @@ func ValidateKeyPair(pemKey, pemCertificate []byte, cfg *CertCfg, minimumRemaini
 	return utilerrors.NewAggregate(errs)
 }
 
+func uriStrings(uris []*url.URL) []string {
+	var s []string
+	for _, uri := range uris {
+		s = append(s, uri.String())
+	}
+	return s
+}
+
 type CAOpts struct {
 	CASignerCertMapKey string
 	CASignerKeyMapKey  string
@@ -371,9 +590,13 @@ func signCertificate(cfg *CertCfg, ca *corev1.Secret, opts *CAOpts) (crtBytes []
 	}
 	key, crt, err := GenerateSignedCertificate(caKey, caCert, cfg)
 	if err != nil {
-		return nil, nil, nil, fmt.Errorf("failed to generate etcd client secret: %w", err)
+		return nil, nil, nil, fmt.Errorf("failed to generate signed certificate: %w", err)
+	}
+	keyBytes, err = PrivateKeyToPem(key)
+	if err != nil {
+		return nil, nil, nil, err
 	}
-	return CertToPem(crt), PrivateKeyToPem(key), CertToPem(caCert), nil
+	return CertToPem(crt), keyBytes, CertToPem(caCert), nil
 }
 
 func HasCAHash(secret *corev1.Secret, ca *corev1.Secret, opts *CAOpts) bool {
@@ -400,7 +623,7 @@ func annotateWithCA(secret, ca *corev1.Secret, opts *CAOpts) {
 	secret.Annotations[CAHashAnnotation] = computeCAHash(ca, opts)
 }
 
-func decodeCA(ca *corev1.Secret, opts *CAOpts) (*x509.Certificate, *rsa.PrivateKey, error) {
+func decodeCA(ca *corev1.Secret, opts *CAOpts) (*x509.Certificate, crypto.Signer, error) {
 	crt, err := PemToCertificate(ca.Data[opts.CASignerCertMapKey])
 	if err != nil {
 		return nil, nil, err
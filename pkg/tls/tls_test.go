@@ -0,0 +1,184 @@
+package tls
+
+import (
+	"crypto"
+	"crypto/x509"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateSignedCertificate_KeyAlgorithms(t *testing.T) {
+	algorithms := []struct {
+		alg  KeyAlgorithm
+		size int
+	}{
+		{alg: KeyAlgorithmRSA, size: 2048},
+		{alg: KeyAlgorithmECDSA, size: 256},
+		{alg: KeyAlgorithmECDSA, size: 384},
+		{alg: KeyAlgorithmEd25519},
+	}
+	for _, ca := range algorithms {
+		for _, leaf := range algorithms {
+			t.Run(string(ca.alg)+"-"+string(leaf.alg), func(t *testing.T) {
+				caCfg := defaultCertCfg()
+				caCfg.IsCA = true
+				caCfg.KeyUsages = x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature
+				caCfg.KeyAlgorithm, caCfg.KeySize = ca.alg, ca.size
+				caKey, caCert, err := GenerateSelfSignedCertificate(&caCfg)
+				require.NoError(t, err)
+
+				// the CA must survive a PEM round trip to be usable from files
+				caKeyPem, err := PrivateKeyToPem(caKey)
+				require.NoError(t, err)
+				caKey, err = PemToPrivateKey(caKeyPem)
+				require.NoError(t, err)
+
+				cfg := defaultCertCfg()
+				cfg.KeyAlgorithm, cfg.KeySize = leaf.alg, leaf.size
+				key, cert, err := GenerateSignedCertificate(caKey, caCert, &cfg)
+				require.NoError(t, err)
+				assert.NoError(t, cert.CheckSignatureFrom(caCert))
+
+				keyPem, err := PrivateKeyToPem(key)
+				require.NoError(t, err)
+				assert.NoError(t, ValidateKeyPair(keyPem, CertToPem(cert), &cfg, 0))
+			})
+		}
+	}
+}
+
+func TestGeneratePrivateKey_InvalidInput(t *testing.T) {
+	_, err := GeneratePrivateKey(KeyAlgorithmECDSA, 2048)
+	assert.Error(t, err)
+
+	_, err = ParseKeyAlgorithm("dsa")
+	assert.Error(t, err)
+}
+
+func TestGenerateTLSKeyCertPairToFiles_NonRSA(t *testing.T) {
+	caOpts, dir := newTestCA(t, func(o *CAOptions) { o.KeyAlgorithm = string(KeyAlgorithmEd25519) })
+	opts := DefaultPKIOptions()
+	opts.CaGenOpt = caOpts
+	opts.KeyAlgorithm = string(KeyAlgorithmECDSA)
+	opts.KeySize = 384
+	opts.KeyFile = filepath.Join(dir, "tls.key")
+	opts.CertFile = filepath.Join(dir, "tls.crt")
+	require.NoError(t, GenerateTLSKeyCertPairToFiles(opts))
+
+	found, err := CheckCACertInBundle(opts.CaGenOpt.CaCertFile, opts.CaGenOpt.CaCertFile)
+	require.NoError(t, err)
+	assert.True(t, found)
+
+	_, cert, err := GenerateTLSKeyCertPair(opts)
+	require.NoError(t, err)
+	assert.Equal(t, x509.ECDSA, cert.PublicKeyAlgorithm)
+	assert.Equal(t, x509.PureEd25519, cert.SignatureAlgorithm)
+}
+
+func TestGenerateTLSKeyCertPair_SANs(t *testing.T) {
+	caOpts, _ := newTestCA(t, func(o *CAOptions) { o.IPAddresses = []string{"10.0.0.1"} })
+	opts := DefaultPKIOptions()
+	opts.CaGenOpt = caOpts
+	opts.DNSNames = []string{"*.apps.example.com", "api.example.com", ""}
+	opts.IPAddresses = []string{"192.168.1.1", "::1"}
+	opts.URIs = []string{"spiffe://cluster.local/ns/default/sa/default"}
+	opts.EmailAddresses = []string{"qe@example.com"}
+	_, cert, err := GenerateTLSKeyCertPair(opts)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"*.apps.example.com", "api.example.com"}, cert.DNSNames)
+	require.Len(t, cert.IPAddresses, 2)
+	assert.Equal(t, "192.168.1.1", cert.IPAddresses[0].String())
+	assert.Equal(t, "::1", cert.IPAddresses[1].String())
+	require.Len(t, cert.URIs, 1)
+	assert.Equal(t, "spiffe://cluster.local/ns/default/sa/default", cert.URIs[0].String())
+	assert.Equal(t, []string{"qe@example.com"}, cert.EmailAddresses)
+
+	opts.IPAddresses = []string{"not-an-ip"}
+	_, _, err = GenerateTLSKeyCertPair(opts)
+	assert.Error(t, err)
+}
+
+func TestGenerateTLSKeyCertPairToFiles_IntermediateChain(t *testing.T) {
+	root, dir := newTestCA(t)
+
+	intermediate := DefaultCAOptions()
+	intermediate.Subject = "O=OpenShift, OU=Hypershift QE, CN=intermediate-ca"
+	intermediate.ParentCaKeyFile = root.CaKeyFile
+	intermediate.ParentCaCertFile = root.CaCertFile
+	intermediate.CaKeyFile = filepath.Join(dir, "intermediate.key")
+	intermediate.CaCertFile = filepath.Join(dir, "intermediate.crt")
+	intermediate.PathLen = 0
+	require.NoError(t, GenerateCAToFiles(intermediate))
+
+	opts := DefaultPKIOptions()
+	opts.CaGenOpt = intermediate
+	opts.KeyFile = filepath.Join(dir, "tls.key")
+	opts.CertFile = filepath.Join(dir, "tls.crt")
+	opts.ChainFile = filepath.Join(dir, "chain.crt")
+	require.NoError(t, GenerateTLSKeyCertPairToFiles(opts))
+
+	certBytes, err := os.ReadFile(opts.CertFile)
+	require.NoError(t, err)
+	certs, err := PemToCertificates(certBytes)
+	require.NoError(t, err)
+	assert.Len(t, certs, 1)
+
+	chainBytes, err := os.ReadFile(opts.ChainFile)
+	require.NoError(t, err)
+	chain, err := PemToCertificates(chainBytes)
+	require.NoError(t, err)
+	require.Len(t, chain, 2)
+	assert.True(t, chain[1].IsCA)
+	assert.True(t, chain[1].MaxPathLenZero)
+
+	rootBytes, err := os.ReadFile(root.CaCertFile)
+	require.NoError(t, err)
+	roots := x509.NewCertPool()
+	require.True(t, roots.AppendCertsFromPEM(rootBytes))
+	inters := x509.NewCertPool()
+	inters.AddCert(chain[1])
+	_, err = chain[0].Verify(x509.VerifyOptions{
+		DNSName:       "server.openqe.github.io",
+		Roots:         roots,
+		Intermediates: inters,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	})
+	assert.NoError(t, err)
+}
+
+func loadCertificate(t *testing.T, file string) *x509.Certificate {
+	t.Helper()
+	data, err := os.ReadFile(file)
+	require.NoError(t, err)
+	cert, err := PemToCertificate(data)
+	require.NoError(t, err)
+	return cert
+}
+
+func parsePemKeypairFiles(t *testing.T, keyFile, certFile string) (crypto.Signer, *x509.Certificate, error) {
+	t.Helper()
+	keyBytes, err := os.ReadFile(keyFile)
+	require.NoError(t, err)
+	certBytes, err := os.ReadFile(certFile)
+	require.NoError(t, err)
+	return parsePemKeypair(keyBytes, certBytes, nil)
+}
+
+// newTestCA generates a CA with the default options to the ca.key and ca.crt files of a temporary directory.
+// The options can be changed by opts before the CA is generated. It returns the CA options and the directory.
+func newTestCA(t *testing.T, opts ...func(*CAOptions)) (*CAOptions, string) {
+	t.Helper()
+	dir := t.TempDir()
+	ca := DefaultCAOptions()
+	ca.CaKeyFile = filepath.Join(dir, "ca.key")
+	ca.CaCertFile = filepath.Join(dir, "ca.crt")
+	for _, o := range opts {
+		o(ca)
+	}
+	require.NoError(t, GenerateCAToFiles(ca))
+	return ca, dir
+}
@@ -0,0 +1,215 @@
+package tls
+
+import (
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	// UsageServer verifies the certificate for TLS server authentication
+	UsageServer = "server"
+	// UsageClient verifies the certificate for TLS client authentication
+	UsageClient = "client"
+	// UsageAny verifies the certificate for any extended key usage
+	UsageAny = "any"
+)
+
+// VerifyFailure is the reason of a failed certificate verification
+type VerifyFailure string
+
+const (
+	VerifyUnknownAuthority VerifyFailure = "unknown authority"
+	VerifyExpired          VerifyFailure = "expired"
+	VerifyNotYetValid      VerifyFailure = "not yet valid"
+	VerifyNameMismatch     VerifyFailure = "name mismatch"
+	VerifyWrongUsage       VerifyFailure = "wrong extended key usage"
+	VerifyInvalid          VerifyFailure = "invalid certificate"
+)
+
+// VerifyError explains why a certificate failed the verification
+type VerifyError struct {
+	Reason VerifyFailure
+	Detail string
+	Err    error
+}
+
+func (e *VerifyError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Reason, e.Detail)
+}
+
+func (e *VerifyError) Unwrap() error {
+	return e.Err
+}
+
+// ParseTime parses a time in RFC 3339, a date like 2006-01-02, or a duration relative to now like +720h or -24h
+func ParseTime(value string) (time.Time, error) {
+	if strings.HasPrefix(value, "+") || strings.HasPrefix(value, "-") {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid relative time: %s: %w", value, err)
+		}
+		return time.Now().Add(d), nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time: %s, RFC 3339, 2006-01-02 or a duration like +720h is expected", value)
+	}
+	return t, nil
+}
+
+// ParseUsage converts a usage name to the extended key usage to verify
+func ParseUsage(usage string) (x509.ExtKeyUsage, error) {
+	switch usage {
+	case "", UsageServer:
+		return x509.ExtKeyUsageServerAuth, nil
+	case UsageClient:
+		return x509.ExtKeyUsageClientAuth, nil
+	case UsageAny:
+		return x509.ExtKeyUsageAny, nil
+	}
+	return 0, fmt.Errorf("unsupported usage: %s, supported: %s, %s, %s", usage, UsageServer, UsageClient, UsageAny)
+}
+
+// VerifyCertificate verifies the leaf certificate with x509.Verify, a failure is explained by a *VerifyError
+func VerifyCertificate(leaf *x509.Certificate, opts x509.VerifyOptions) ([][]*x509.Certificate, error) {
+	chains, err := leaf.Verify(opts)
+	if err != nil {
+		return nil, explainVerifyError(leaf, opts, err)
+	}
+	return chains, nil
+}
+
+// VerifyCertificateFile verifies the certificate file as described by the options and returns the verified chains
+func VerifyCertificateFile(opts *VerifyOptions) ([][]*x509.Certificate, error) {
+	usage, err := ParseUsage(opts.Usage)
+	if err != nil {
+		return nil, err
+	}
+	verifyOpts := x509.VerifyOptions{
+		DNSName:       opts.Hostname,
+		Intermediates: x509.NewCertPool(),
+		KeyUsages:     []x509.ExtKeyUsage{usage},
+	}
+	if opts.AtTime != "" {
+		if verifyOpts.CurrentTime, err = ParseTime(opts.AtTime); err != nil {
+			return nil, err
+		}
+	}
+	if opts.CAFile != "" {
+		roots, err := certificatesFromFile(opts.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		verifyOpts.Roots = x509.NewCertPool()
+		for _, root := range roots {
+			verifyOpts.Roots.AddCert(root)
+		}
+	}
+	certs, err := certificatesFromFile(opts.CertFile)
+	if err != nil {
+		return nil, err
+	}
+	untrusted := certs[1:]
+	if opts.UntrustedFile != "" {
+		moreCerts, err := certificatesFromFile(opts.UntrustedFile)
+		if err != nil {
+			return nil, err
+		}
+		untrusted = append(untrusted, moreCerts...)
+	}
+	for _, cert := range untrusted {
+		verifyOpts.Intermediates.AddCert(cert)
+	}
+	return VerifyCertificate(certs[0], verifyOpts)
+}
+
+// certificatesFromFile reads all certificates of a PEM file
+func certificatesFromFile(file string) ([]*x509.Certificate, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read certificate file: %w", err)
+	}
+	certs, err := PemToCertificates(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load certificates from %s: %w", file, err)
+	}
+	return certs, nil
+}
+
+// explainVerifyError converts an error of x509.Verify to a *VerifyError with the details of the failure
+func explainVerifyError(leaf *x509.Certificate, opts x509.VerifyOptions, err error) error {
+	now := opts.CurrentTime
+	if now.IsZero() {
+		now = time.Now()
+	}
+	var unknownAuthorityErr x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+	var invalidErr x509.CertificateInvalidError
+	switch {
+	case errors.As(err, &unknownAuthorityErr):
+		cert := unknownAuthorityErr.Cert
+		if cert == nil {
+			cert = leaf
+		}
+		detail := fmt.Sprintf("%q is issued by %q which is not trusted", cert.Subject, cert.Issuer)
+		if !IsSelfSigned(cert) {
+			detail += ", the CA or an intermediate CA may be missing"
+		}
+		return &VerifyError{Reason: VerifyUnknownAuthority, Detail: detail, Err: err}
+	case errors.As(err, &hostnameErr):
+		sans := slices.Concat(hostnameErr.Certificate.DNSNames, ipStrings(hostnameErr.Certificate))
+		detail := fmt.Sprintf("%q does not match the SANs %v of %q", hostnameErr.Host, sans, hostnameErr.Certificate.Subject)
+		if len(sans) == 0 {
+			detail = fmt.Sprintf("%q has no SANs to match %q, the common name is not used", hostnameErr.Certificate.Subject, hostnameErr.Host)
+		}
+		return &VerifyError{Reason: VerifyNameMismatch, Detail: detail, Err: err}
+	case errors.As(err, &invalidErr):
+		cert := invalidErr.Cert
+		switch invalidErr.Reason {
+		case x509.Expired:
+			if now.Before(cert.NotBefore) {
+				return &VerifyError{Reason: VerifyNotYetValid, Err: err,
+					Detail: fmt.Sprintf("%q is valid from %s, verified at %s", cert.Subject, cert.NotBefore.UTC().Format(time.RFC3339), now.UTC().Format(time.RFC3339))}
+			}
+			return &VerifyError{Reason: VerifyExpired, Err: err,
+				Detail: fmt.Sprintf("%q expired at %s, verified at %s", cert.Subject, cert.NotAfter.UTC().Format(time.RFC3339), now.UTC().Format(time.RFC3339))}
+		case x509.IncompatibleUsage:
+			return &VerifyError{Reason: VerifyWrongUsage, Err: err,
+				Detail: fmt.Sprintf("%q has the extended key usages %v, %v is required", leaf.Subject, extKeyUsageNames(leaf.ExtKeyUsage), extKeyUsageNames(opts.KeyUsages))}
+		}
+		return &VerifyError{Reason: VerifyInvalid, Detail: invalidErr.Error(), Err: err}
+	}
+	return &VerifyError{Reason: VerifyInvalid, Detail: err.Error(), Err: err}
+}
+
+// ipStrings returns the IP address SANs of a certificate as strings
+func ipStrings(cert *x509.Certificate) []string {
+	var ips []string
+	for _, ip := range cert.IPAddresses {
+		ips = append(ips, ip.String())
+	}
+	return ips
+}
+
+// extKeyUsageNames returns the names of the extended key usages, unknown ones are printed as numbers
+func extKeyUsageNames(usages []x509.ExtKeyUsage) []string {
+	names := []string{}
+	for _, usage := range usages {
+		name := fmt.Sprintf("%d", usage)
+		for n, u := range ExtKeyUsages {
+			if u == usage {
+				name = n
+			}
+		}
+		names = append(names, name)
+	}
+	return names
+}
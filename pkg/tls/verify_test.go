@@ -0,0 +1,55 @@
+package tls
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyCertificate(t *testing.T) {
+	caKey, caCert, err := GenerateSelfSignedCertificate(&CertCfg{
+		Subject:   pkix.Name{CommonName: "verify-ca", OrganizationalUnit: []string{"openqe"}},
+		KeySize:   2048,
+		Validity:  ValidityOneDay,
+		IsCA:      true,
+		KeyUsages: caKeyUsages,
+	})
+	require.NoError(t, err)
+	_, leaf, err := GenerateSignedCertificate(caKey, caCert, &CertCfg{
+		Subject:      pkix.Name{CommonName: "verify-leaf", OrganizationalUnit: []string{"openqe"}},
+		KeySize:      2048,
+		Validity:     ValidityOneDay,
+		DNSNames:     []string{"server.openqe.github.io"},
+		ExtKeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+	require.NoError(t, err)
+	roots := x509.NewCertPool()
+	roots.AddCert(caCert)
+
+	_, err = VerifyCertificate(leaf, x509.VerifyOptions{Roots: roots, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}})
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		opts   x509.VerifyOptions
+		reason VerifyFailure
+	}{
+		{"unknown authority", x509.VerifyOptions{Roots: x509.NewCertPool()}, VerifyUnknownAuthority},
+		{"expired", x509.VerifyOptions{Roots: roots, CurrentTime: leaf.NotAfter.Add(ValidityOneDay)}, VerifyExpired},
+		{"not yet valid", x509.VerifyOptions{Roots: roots, CurrentTime: leaf.NotBefore.Add(-ValidityOneDay)}, VerifyNotYetValid},
+		{"name mismatch", x509.VerifyOptions{Roots: roots, DNSName: "other.openqe.github.io"}, VerifyNameMismatch},
+		{"wrong usage", x509.VerifyOptions{Roots: roots, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}}, VerifyWrongUsage},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := VerifyCertificate(leaf, tt.opts)
+			var verifyErr *VerifyError
+			require.True(t, errors.As(err, &verifyErr), "unexpected error: %v", err)
+			assert.Equal(t, tt.reason, verifyErr.Reason)
+		})
+	}
+}
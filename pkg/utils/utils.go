@@ -7,7 +7,9 @@ import (
 	"os/exec"
 	"path/filepath"
 	"runtime/debug"
+	"strconv"
 	"strings"
+	"time"
 )
 
 func ExpandPath(path string) (string, error) {
@@ -65,3 +67,15 @@ type ExitError struct {
 	StdErr string
 	*exec.ExitError
 }
+
+// ParseDuration parses a duration like time.ParseDuration, a number of days like 30d is accepted as well
+func ParseDuration(value string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration: %s", value)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(value)
+}
@@ -0,0 +1,27 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDuration(t *testing.T) {
+	tests := map[string]time.Duration{
+		"30d":   30 * 24 * time.Hour,
+		"0d":    0,
+		"720h":  720 * time.Hour,
+		"1h30m": 90 * time.Minute,
+	}
+	for value, want := range tests {
+		d, err := ParseDuration(value)
+		require.NoError(t, err, value)
+		assert.Equal(t, want, d, value)
+	}
+	for _, value := range []string{"d", "1.5d", "xd", "30", ""} {
+		_, err := ParseDuration(value)
+		assert.Error(t, err, value)
+	}
+}